@@ -0,0 +1,38 @@
+package account
+
+import (
+	"context"
+	"slices"
+
+	"github.com/master-wayne7/go-microservices/auth"
+)
+
+// PromoteAdmin gives the admin role to the account registered with email,
+// connecting to the account database directly. It bootstraps the first
+// admin, who can then assign roles through AssignRoles; registering never
+// grants more than the customer role.
+func PromoteAdmin(ctx context.Context, databaseURL, email string) (*Account, error) {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewPostgresRepository(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	c, err := r.GetCredentialsByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	s := &accountService{repository: r}
+	acc, err := s.GetAccount(ctx, c.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(acc.Roles, auth.RoleAdmin) {
+		return acc, nil
+	}
+	return s.AssignRoles(ctx, acc.ID, append(slices.Clone(acc.Roles), auth.RoleAdmin), acc.Version)
+}
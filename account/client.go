@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/master-wayne7/go-microservices/account/pb"
+	"github.com/master-wayne7/go-microservices/auth"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 )
@@ -14,7 +15,13 @@ type Client struct {
 }
 
 func NewClient(url string) (*Client, error) {
-	conn, err := grpc.Dial(url, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	// Calls carry the caller's identity from the context to the service.
+	conn, err := grpc.Dial(
+		url,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(auth.UnaryClientInterceptor()),
+		grpc.WithStreamInterceptor(auth.StreamClientInterceptor()),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -27,19 +34,18 @@ func (c *Client) Close() {
 	c.conn.Close()
 }
 
-func (c *Client) PostAccount(ctx context.Context, name string) (*Account, error) {
+// PostAccount creates an account. A non-empty idempotencyKey makes retries
+// return the account created by the first attempt.
+func (c *Client) PostAccount(ctx context.Context, name, idempotencyKey string) (*Account, error) {
 	r, err := c.service.PostAccount(
 		ctx,
-		&pb.PostAccountRequest{Name: name},
+		&pb.PostAccountRequest{Name: name, IdempotencyKey: idempotencyKey},
 	)
 
 	if err != nil {
 		return nil, err
 	}
-	return &Account{
-		ID:   r.Account.Id,
-		Name: r.Account.Name,
-	}, nil
+	return accountFromProto(r.Account), nil
 }
 
 func (c *Client) GetAccount(ctx context.Context, id string) (*Account, error) {
@@ -50,10 +56,7 @@ func (c *Client) GetAccount(ctx context.Context, id string) (*Account, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Account{
-		ID:   r.Account.Id,
-		Name: r.Account.Name,
-	}, nil
+	return accountFromProto(r.Account), nil
 }
 
 func (c *Client) GetAccounts(ctx context.Context, take, skip uint64) ([]Account, error) {
@@ -70,12 +73,124 @@ func (c *Client) GetAccounts(ctx context.Context, take, skip uint64) ([]Account,
 
 	accounts := []Account{}
 	for _, p := range r.Accounts {
-		a := Account{
-			ID:   p.Id,
-			Name: p.Name,
-		}
-		accounts = append(accounts, a)
+		accounts = append(accounts, *accountFromProto(p))
 	}
 
 	return accounts, nil
 }
+
+// UpdateAccount renames an account. version must be the version the caller
+// last read, or 0 to overwrite unconditionally.
+func (c *Client) UpdateAccount(ctx context.Context, id, name string, version uint64) (*Account, error) {
+	r, err := c.service.UpdateAccount(
+		ctx,
+		&pb.UpdateAccountRequest{Id: id, Name: name, Version: version},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return accountFromProto(r.Account), nil
+}
+
+func (c *Client) DeactivateAccount(ctx context.Context, id string, version uint64) (*Account, error) {
+	r, err := c.service.DeactivateAccount(
+		ctx,
+		&pb.DeactivateAccountRequest{Id: id, Version: version},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return accountFromProto(r.Account), nil
+}
+
+func (c *Client) DeleteAccount(ctx context.Context, id string, version uint64) (*Account, error) {
+	r, err := c.service.DeleteAccount(
+		ctx,
+		&pb.DeleteAccountRequest{Id: id, Version: version},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return accountFromProto(r.Account), nil
+}
+
+// Register creates an account with login credentials and returns its first
+// token pair.
+func (c *Client) Register(ctx context.Context, name, email, password string) (*Account, *auth.Tokens, error) {
+	r, err := c.service.Register(
+		ctx,
+		&pb.RegisterRequest{Name: name, Email: email, Password: password},
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	return accountFromProto(r.Account), tokensFromProto(r.Tokens), nil
+}
+
+func (c *Client) Login(ctx context.Context, email, password string) (*Account, *auth.Tokens, error) {
+	r, err := c.service.Login(
+		ctx,
+		&pb.LoginRequest{Email: email, Password: password},
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	return accountFromProto(r.Account), tokensFromProto(r.Tokens), nil
+}
+
+func (c *Client) RefreshTokens(ctx context.Context, refreshToken string) (*auth.Tokens, error) {
+	r, err := c.service.RefreshTokens(
+		ctx,
+		&pb.RefreshTokensRequest{RefreshToken: refreshToken},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return tokensFromProto(r.Tokens), nil
+}
+
+func (c *Client) ChangePassword(ctx context.Context, accountID, currentPassword, newPassword string) (*auth.Tokens, error) {
+	r, err := c.service.ChangePassword(
+		ctx,
+		&pb.ChangePasswordRequest{AccountId: accountID, CurrentPassword: currentPassword, NewPassword: newPassword},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return tokensFromProto(r.Tokens), nil
+}
+
+// AssignRoles replaces the roles of an account. Only admins may call it.
+func (c *Client) AssignRoles(ctx context.Context, accountID string, roles []auth.Role, version uint64) (*Account, error) {
+	r, err := c.service.AssignRoles(
+		ctx,
+		&pb.AssignRolesRequest{AccountId: accountID, Roles: auth.RoleNames(roles), Version: version},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return accountFromProto(r.Account), nil
+}
+
+func tokensFromProto(p *pb.Tokens) *auth.Tokens {
+	t := &auth.Tokens{
+		AccessToken:  p.AccessToken,
+		RefreshToken: p.RefreshToken,
+	}
+	t.AccessExpiresAt.UnmarshalBinary(p.AccessTokenExpiresAt)
+	t.RefreshExpiresAt.UnmarshalBinary(p.RefreshTokenExpiresAt)
+	return t
+}
+
+func accountFromProto(p *pb.Account) *Account {
+	a := &Account{
+		ID:      p.Id,
+		Name:    p.Name,
+		Status:  Status(p.Status),
+		Email:   p.Email,
+		Roles:   storedRoles(p.Roles),
+		Version: p.Version,
+	}
+	a.UpdatedAt.UnmarshalBinary(p.UpdatedAt)
+	return a
+}
@@ -1,18 +1,31 @@
 package main
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"time"
 
 	"github.com/kelseyhightower/envconfig"
 	"github.com/master-wayne7/go-microservices/account"
+	"github.com/master-wayne7/go-microservices/auth"
+	"github.com/master-wayne7/go-microservices/events"
+	"github.com/master-wayne7/go-microservices/idempotency"
 	"github.com/master-wayne7/go-microservices/monitoring"
+	"github.com/master-wayne7/go-microservices/schema"
 	"github.com/tinrab/retry"
 )
 
 type Config struct {
 	DatabaseURL string `envconfig:"DATABASE_URL"`
+	// Where outbox events are published, e.g. file:///var/lib/events/account.ndjson
+	EventBrokerURL string `envconfig:"EVENT_BROKER_URL"`
+	// How often the outbox is relayed to the broker
+	EventRelayInterval time.Duration `envconfig:"EVENT_RELAY_INTERVAL" default:"5s"`
+	// HMAC secret for issued tokens, shared with everything that verifies them
+	TokenSecret     string        `envconfig:"AUTH_TOKEN_SECRET" required:"true"`
+	AccessTokenTTL  time.Duration `envconfig:"AUTH_ACCESS_TOKEN_TTL" default:"15m"`
+	RefreshTokenTTL time.Duration `envconfig:"AUTH_REFRESH_TOKEN_TTL" default:"720h"`
 }
 
 // Health check handler for container orchestration
@@ -46,6 +59,9 @@ func main() {
 	var r account.Repository
 	retry.ForeverSleep(2*time.Second, func(_ int) (err error) {
 		r, err = account.NewPostgresRepository(cfg.DatabaseURL)
+		if errors.Is(err, schema.ErrOutdated) {
+			log.Fatal(err)
+		}
 		if err != nil {
 			log.Println("DB connection failed, retrying...", err)
 		}
@@ -62,8 +78,28 @@ func main() {
 	// Pass DB handle for DB metrics
 	metrics.StartSystemMetricsCollection(r.(*account.PostgresRepository).DB())
 
+	// Relay outbox events to the configured broker
+	broker, err := events.NewBroker(cfg.EventBrokerURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer broker.Close()
+	source := events.NewPostgresSource(r.(*account.PostgresRepository).DB())
+	source.SetMetrics(metrics)
+	events.StartRelay(source, broker, cfg.EventRelayInterval)
+
+	signer, err := auth.NewSigner(cfg.TokenSecret)
+	if err != nil {
+		log.Fatal(err)
+	}
+	signer.AccessTTL = cfg.AccessTokenTTL
+	signer.RefreshTTL = cfg.RefreshTokenTTL
+
 	// ✅ Start gRPC server with metrics interceptors
 	log.Println("Account service gRPC listening on port 8081...")
-	s := account.NewService(r)
-	log.Fatal(account.ListenGRPC(s, 8081, metrics))
+	s := account.NewService(r, signer)
+	// Idempotency keys for PostAccount share the account database
+	store := idempotency.NewPostgresStore(r.(*account.PostgresRepository).DB())
+	store.SetMetrics(metrics)
+	log.Fatal(account.ListenGRPC(s, store, signer, 8081, metrics))
 }
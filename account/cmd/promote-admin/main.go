@@ -0,0 +1,35 @@
+// Command promote-admin gives the admin role to the account registered with
+// the email address given as its argument, e.g. to create the first admin.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/kelseyhightower/envconfig"
+	"github.com/master-wayne7/go-microservices/account"
+)
+
+type Config struct {
+	DatabaseURL string `envconfig:"DATABASE_URL"`
+}
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: promote-admin EMAIL")
+		os.Exit(2)
+	}
+	var cfg Config
+	err := envconfig.Process("", &cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	acc, err := account.PromoteAdmin(context.Background(), cfg.DatabaseURL, os.Args[1])
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("account %s (%s) is an admin; the role applies from its next token pair", acc.ID, acc.Email)
+}
@@ -0,0 +1,34 @@
+package account
+
+import (
+	"github.com/master-wayne7/go-microservices/account/pb"
+	"github.com/master-wayne7/go-microservices/auth"
+)
+
+// permissions decides who may call each AccountService method.
+var permissions = auth.Rules{
+	pb.AccountService_Register_FullMethodName:      auth.Public,
+	pb.AccountService_Login_FullMethodName:         auth.Public,
+	pb.AccountService_RefreshTokens_FullMethodName: auth.Public,
+
+	pb.AccountService_PostAccount_FullMethodName: auth.RequireRole(auth.RoleStaff),
+	pb.AccountService_GetAccount_FullMethodName: auth.AccountOwner(func(r *pb.GetAccountRequest) string {
+		return r.Id
+	}),
+	pb.AccountService_GetAccounts_FullMethodName: auth.RequireRole(auth.RoleStaff),
+
+	pb.AccountService_UpdateAccount_FullMethodName: auth.AccountOwner(func(r *pb.UpdateAccountRequest) string {
+		return r.Id
+	}),
+	pb.AccountService_DeactivateAccount_FullMethodName: auth.AccountOwner(func(r *pb.DeactivateAccountRequest) string {
+		return r.Id
+	}),
+	pb.AccountService_DeleteAccount_FullMethodName: auth.AccountOwner(func(r *pb.DeleteAccountRequest) string {
+		return r.Id
+	}),
+	pb.AccountService_ChangePassword_FullMethodName: auth.AccountOwner(func(r *pb.ChangePasswordRequest) string {
+		return r.AccountId
+	}),
+
+	pb.AccountService_AssignRoles_FullMethodName: auth.RequireRole(auth.RoleAdmin),
+}
@@ -3,27 +3,59 @@ package account
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+	"github.com/master-wayne7/go-microservices/auth"
+	"github.com/master-wayne7/go-microservices/events"
 	"github.com/master-wayne7/go-microservices/monitoring"
+	"github.com/master-wayne7/go-microservices/schema"
 )
 
 type Repository interface {
 	Close()
 	// Expose DB for metrics
 	DB() *sql.DB
-	PutAccount(ctx context.Context, a Account) error
+	// PutAccount stores a new account. c is nil for accounts without a
+	// login.
+	PutAccount(ctx context.Context, a Account, c *Credentials) error
 	GetAccountByID(ctx context.Context, id string) (*Account, error)
 	ListAccounts(ctx context.Context, skip uint64, take uint64) ([]Account, error)
+	// UpdateAccount saves a and an event of eventType if the stored version
+	// still equals fromVersion, and returns ErrVersionConflict otherwise.
+	UpdateAccount(ctx context.Context, a Account, fromVersion uint64, eventType string) error
+	GetCredentials(ctx context.Context, accountID string) (*Credentials, error)
+	GetCredentialsByEmail(ctx context.Context, email string) (*Credentials, error)
+	// UpdatePassword replaces the password hash and bumps the credential
+	// generation if it still equals fromGeneration.
+	UpdatePassword(ctx context.Context, accountID, passwordHash string, fromGeneration uint64, at time.Time) error
 }
 
+// uniqueViolation is the Postgres error code for a violated unique
+// constraint.
+const uniqueViolation = "23505"
+
 type PostgresRepository struct {
 	db *sql.DB
 	// Attach metrics collector for DB query metrics
 	metrics *monitoring.MetricsCollector
 }
 
+// columns are the columns added since the first release, whose absence
+// means the database was created from an older up.sql.
+var columns = []schema.Column{
+	{Table: "accounts", Name: "status"},
+	{Table: "accounts", Name: "version"},
+	{Table: "accounts", Name: "updated_at"},
+	{Table: "accounts", Name: "deleted_at"},
+	{Table: "accounts", Name: "roles", Type: "ARRAY"},
+	{Table: "credentials", Name: "generation"},
+	{Table: "idempotency_keys", Name: "request_hash"},
+	{Table: "outbox", Name: "published_at"},
+}
+
 func NewPostgresRepository(url string) (Repository, error) {
 	db, err := sql.Open("postgres", url)
 	if err != nil {
@@ -34,6 +66,10 @@ func NewPostgresRepository(url string) (Repository, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := schema.Check(context.Background(), db, columns); err != nil {
+		db.Close()
+		return nil, err
+	}
 
 	return &PostgresRepository{db: db}, nil
 }
@@ -56,35 +92,139 @@ func (r *PostgresRepository) SetMetrics(mc *monitoring.MetricsCollector) {
 	r.metrics = mc
 }
 
-func (r *PostgresRepository) PutAccount(ctx context.Context, a Account) error {
+// PutAccount stores a, its credentials and its AccountCreated event in one
+// transaction.
+func (r *PostgresRepository) PutAccount(ctx context.Context, a Account, c *Credentials) (err error) {
+	event, err := events.NewEvent(events.AccountCreated, "account", a.ID, a)
+	if err != nil {
+		return err
+	}
 	start := time.Now()
-	_, err := r.db.ExecContext(ctx, "INSERT INTO accounts(id,name) VALUES($1,$2)", a.ID, a.Name)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO accounts(id,name,status,version,updated_at,roles) VALUES($1,$2,$3,$4,$5,$6)",
+		a.ID, a.Name, a.Status, a.Version, a.UpdatedAt, pq.Array(auth.RoleNames(a.Roles)),
+	)
+	if err != nil {
+		return err
+	}
+	if c != nil {
+		_, err = tx.ExecContext(
+			ctx,
+			"INSERT INTO credentials(account_id,email,password_hash,generation,updated_at) VALUES($1,$2,$3,$4,$5)",
+			c.AccountID, c.Email, c.PasswordHash, c.Generation, c.UpdatedAt,
+		)
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return fmt.Errorf("%w: %s", ErrEmailTaken, c.Email)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err = events.WriteOutbox(ctx, tx, event); err != nil {
+		return err
+	}
 	if r.metrics != nil {
 		r.metrics.RecordDBQuery("insert", "accounts", time.Since(start))
 	}
-	return err
+	return
+}
+
+// UpdateAccount writes a together with an event describing the change. The
+// version check makes concurrent writers fail instead of overwriting each
+// other.
+func (r *PostgresRepository) UpdateAccount(ctx context.Context, a Account, fromVersion uint64, eventType string) (err error) {
+	event, err := events.NewEvent(eventType, "account", a.ID, a)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
 
+	var deletedAt interface{}
+	if a.Status == StatusDeleted {
+		deletedAt = a.UpdatedAt
+	}
+	res, err := tx.ExecContext(
+		ctx,
+		`UPDATE accounts SET name=$3, status=$4, version=$5, updated_at=$6, deleted_at=COALESCE(deleted_at, $7), roles=$8
+		WHERE id=$1 AND version=$2`,
+		a.ID, fromVersion, a.Name, a.Status, a.Version, a.UpdatedAt, deletedAt, pq.Array(auth.RoleNames(a.Roles)),
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %s is no longer at version %d", ErrVersionConflict, a.ID, fromVersion)
+	}
+	if err = events.WriteOutbox(ctx, tx, event); err != nil {
+		return err
+	}
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("update", "accounts", time.Since(start))
+	}
+	return
 }
+
+// GetAccountByID also resolves deleted accounts.
 func (r *PostgresRepository) GetAccountByID(ctx context.Context, id string) (*Account, error) {
 	start := time.Now()
-	row := r.db.QueryRowContext(ctx, "SELECT id, name FROM accounts WHERE id=$1", id)
+	row := r.db.QueryRowContext(ctx, `SELECT a.id, a.name, a.status, a.version, a.updated_at, COALESCE(c.email, ''), a.roles
+		FROM accounts a LEFT JOIN credentials c ON c.account_id = a.id
+		WHERE a.id=$1`, id)
 	a := &Account{}
-	if err := row.Scan(&a.ID, &a.Name); err != nil {
+	var roles pq.StringArray
+	if err := row.Scan(&a.ID, &a.Name, &a.Status, &a.Version, &a.UpdatedAt, &a.Email, &roles); err != nil {
 		if r.metrics != nil {
 			r.metrics.RecordDBQuery("select", "accounts", time.Since(start))
 		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+		}
 		return nil, err
 	}
+	a.Roles = storedRoles(roles)
 	if r.metrics != nil {
 		r.metrics.RecordDBQuery("select", "accounts", time.Since(start))
 	}
 	return a, nil
 }
+
+// ListAccounts skips deleted accounts.
 func (r *PostgresRepository) ListAccounts(ctx context.Context, skip uint64, take uint64) ([]Account, error) {
 	start := time.Now()
 	rows, err := r.db.QueryContext(
 		ctx,
-		"SELECT id, name FROM accounts ORDER BY id DESC OFFSET $1 LIMIT $2",
+		`SELECT a.id, a.name, a.status, a.version, a.updated_at, COALESCE(c.email, ''), a.roles
+		FROM accounts a LEFT JOIN credentials c ON c.account_id = a.id
+		WHERE a.status <> 'deleted' ORDER BY a.id DESC OFFSET $1 LIMIT $2`,
 		skip,
 		take,
 	)
@@ -101,7 +241,9 @@ func (r *PostgresRepository) ListAccounts(ctx context.Context, skip uint64, take
 
 	for rows.Next() {
 		a := Account{}
-		if err := rows.Scan(&a.ID, &a.Name); err == nil {
+		var roles pq.StringArray
+		if err := rows.Scan(&a.ID, &a.Name, &a.Status, &a.Version, &a.UpdatedAt, &a.Email, &roles); err == nil {
+			a.Roles = storedRoles(roles)
 			accounts = append(accounts, a)
 		}
 	}
@@ -117,3 +259,67 @@ func (r *PostgresRepository) ListAccounts(ctx context.Context, skip uint64, take
 	}
 	return accounts, nil
 }
+
+// storedRoles converts the roles column, skipping roles this version does
+// not know.
+func storedRoles(names []string) []auth.Role {
+	roles := make([]auth.Role, 0, len(names))
+	for _, name := range names {
+		if r, err := auth.ParseRole(name); err == nil {
+			roles = append(roles, r)
+		}
+	}
+	return roles
+}
+
+func (r *PostgresRepository) GetCredentials(ctx context.Context, accountID string) (*Credentials, error) {
+	return r.getCredentials(ctx, "account_id", accountID)
+}
+
+func (r *PostgresRepository) GetCredentialsByEmail(ctx context.Context, email string) (*Credentials, error) {
+	return r.getCredentials(ctx, "email", email)
+}
+
+func (r *PostgresRepository) getCredentials(ctx context.Context, column, value string) (*Credentials, error) {
+	start := time.Now()
+	row := r.db.QueryRowContext(
+		ctx,
+		"SELECT account_id, email, password_hash, generation, updated_at FROM credentials WHERE "+column+"=$1",
+		value,
+	)
+	c := &Credentials{}
+	err := row.Scan(&c.AccountID, &c.Email, &c.PasswordHash, &c.Generation, &c.UpdatedAt)
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("select", "credentials", time.Since(start))
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCredentialsNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (r *PostgresRepository) UpdatePassword(ctx context.Context, accountID, passwordHash string, fromGeneration uint64, at time.Time) error {
+	start := time.Now()
+	res, err := r.db.ExecContext(
+		ctx,
+		"UPDATE credentials SET password_hash=$3, generation=generation+1, updated_at=$4 WHERE account_id=$1 AND generation=$2",
+		accountID, fromGeneration, passwordHash, at,
+	)
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("update", "credentials", time.Since(start))
+	}
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: credentials of %s changed concurrently", ErrVersionConflict, accountID)
+	}
+	return nil
+}
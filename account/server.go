@@ -3,61 +3,70 @@ package account
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 
 	"github.com/master-wayne7/go-microservices/account/pb"
+	"github.com/master-wayne7/go-microservices/auth"
+	"github.com/master-wayne7/go-microservices/idempotency"
 	"github.com/master-wayne7/go-microservices/monitoring"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 )
 
 type grpcServer struct {
 	pb.UnimplementedAccountServiceServer
-	service Service
+	service     Service
+	idempotency idempotency.Store
 }
 
-func ListenGRPC(s Service, port int, metrics *monitoring.MetricsCollector) error {
+func ListenGRPC(s Service, store idempotency.Store, signer *auth.Signer, port int, metrics *monitoring.MetricsCollector) error {
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
 	if err != nil {
 		return err
 	}
 
-	// Add gRPC interceptors for metrics
+	// Add gRPC interceptors for metrics, then authentication and
+	// per-method permissions
 	serv := grpc.NewServer(
-		grpc.UnaryInterceptor(monitoring.GRPCUnaryServerInterceptor(metrics)),
-		grpc.StreamInterceptor(monitoring.GRPCStreamServerInterceptor(metrics)),
+		grpc.ChainUnaryInterceptor(
+			monitoring.GRPCUnaryServerInterceptor(metrics),
+			auth.UnaryServerInterceptor(signer, permissions),
+		),
+		grpc.ChainStreamInterceptor(
+			monitoring.GRPCStreamServerInterceptor(metrics),
+			auth.StreamServerInterceptor(signer, permissions),
+		),
 	)
-	pb.RegisterAccountServiceServer(serv, &grpcServer{service: s, UnimplementedAccountServiceServer: pb.UnimplementedAccountServiceServer{}})
+	pb.RegisterAccountServiceServer(serv, &grpcServer{service: s, idempotency: store, UnimplementedAccountServiceServer: pb.UnimplementedAccountServiceServer{}})
 	reflection.Register(serv)
 	return serv.Serve(lis)
 }
 
 func (s *grpcServer) PostAccount(ctx context.Context, r *pb.PostAccountRequest) (*pb.PostAccountResponse, error) {
-	a, err := s.service.PostAccount(ctx, r.Name)
-	if err != nil {
-		return nil, err
-	}
+	return idempotency.Do(ctx, s.idempotency, "PostAccount", r.IdempotencyKey, r, func() (*pb.PostAccountResponse, error) {
+		a, err := s.service.PostAccount(ctx, r.Name)
+		if err != nil {
+			return nil, accountError(err)
+		}
 
-	return &pb.PostAccountResponse{
-		Account: &pb.Account{
-			Id:   a.ID,
-			Name: a.Name,
-		},
-	}, nil
+		return &pb.PostAccountResponse{
+			Account: accountToProto(a),
+		}, nil
+	})
 }
 
 func (s *grpcServer) GetAccount(ctx context.Context, r *pb.GetAccountRequest) (*pb.GetAccountResponse, error) {
 	a, err := s.service.GetAccount(ctx, r.Id)
 	if err != nil {
-		return nil, err
+		return nil, accountError(err)
 	}
 
 	return &pb.GetAccountResponse{
-		Account: &pb.Account{
-			Id:   a.ID,
-			Name: a.Name,
-		},
+		Account: accountToProto(a),
 	}, nil
 }
 
@@ -68,14 +77,123 @@ func (s *grpcServer) GetAccounts(ctx context.Context, r *pb.GetAccountsRequest)
 	}
 
 	accounts := []*pb.Account{}
-	for _, p := range a {
-		accounts = append(accounts, &pb.Account{
-			Id:   p.ID,
-			Name: p.Name,
-		})
+	for i := range a {
+		accounts = append(accounts, accountToProto(&a[i]))
 	}
 
 	return &pb.GetAccountsResponse{
 		Accounts: accounts,
 	}, nil
 }
+
+func (s *grpcServer) UpdateAccount(ctx context.Context, r *pb.UpdateAccountRequest) (*pb.UpdateAccountResponse, error) {
+	a, err := s.service.UpdateAccount(ctx, r.Id, r.Name, r.Version)
+	if err != nil {
+		return nil, accountError(err)
+	}
+	return &pb.UpdateAccountResponse{Account: accountToProto(a)}, nil
+}
+
+func (s *grpcServer) DeactivateAccount(ctx context.Context, r *pb.DeactivateAccountRequest) (*pb.DeactivateAccountResponse, error) {
+	a, err := s.service.DeactivateAccount(ctx, r.Id, r.Version)
+	if err != nil {
+		return nil, accountError(err)
+	}
+	return &pb.DeactivateAccountResponse{Account: accountToProto(a)}, nil
+}
+
+func (s *grpcServer) DeleteAccount(ctx context.Context, r *pb.DeleteAccountRequest) (*pb.DeleteAccountResponse, error) {
+	a, err := s.service.DeleteAccount(ctx, r.Id, r.Version)
+	if err != nil {
+		return nil, accountError(err)
+	}
+	return &pb.DeleteAccountResponse{Account: accountToProto(a)}, nil
+}
+
+func (s *grpcServer) Register(ctx context.Context, r *pb.RegisterRequest) (*pb.RegisterResponse, error) {
+	a, tokens, err := s.service.Register(ctx, r.Name, r.Email, r.Password)
+	if err != nil {
+		return nil, accountError(err)
+	}
+	return &pb.RegisterResponse{Account: accountToProto(a), Tokens: tokensToProto(tokens)}, nil
+}
+
+func (s *grpcServer) Login(ctx context.Context, r *pb.LoginRequest) (*pb.LoginResponse, error) {
+	a, tokens, err := s.service.Login(ctx, r.Email, r.Password)
+	if err != nil {
+		return nil, accountError(err)
+	}
+	return &pb.LoginResponse{Account: accountToProto(a), Tokens: tokensToProto(tokens)}, nil
+}
+
+func (s *grpcServer) RefreshTokens(ctx context.Context, r *pb.RefreshTokensRequest) (*pb.RefreshTokensResponse, error) {
+	tokens, err := s.service.RefreshTokens(ctx, r.RefreshToken)
+	if err != nil {
+		return nil, accountError(err)
+	}
+	return &pb.RefreshTokensResponse{Tokens: tokensToProto(tokens)}, nil
+}
+
+func (s *grpcServer) ChangePassword(ctx context.Context, r *pb.ChangePasswordRequest) (*pb.ChangePasswordResponse, error) {
+	tokens, err := s.service.ChangePassword(ctx, r.AccountId, r.CurrentPassword, r.NewPassword)
+	if err != nil {
+		return nil, accountError(err)
+	}
+	return &pb.ChangePasswordResponse{Tokens: tokensToProto(tokens)}, nil
+}
+
+func (s *grpcServer) AssignRoles(ctx context.Context, r *pb.AssignRolesRequest) (*pb.AssignRolesResponse, error) {
+	roles, err := auth.ParseRoles(r.Roles)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	a, err := s.service.AssignRoles(ctx, r.AccountId, roles, r.Version)
+	if err != nil {
+		return nil, accountError(err)
+	}
+	return &pb.AssignRolesResponse{Account: accountToProto(a)}, nil
+}
+
+func tokensToProto(t *auth.Tokens) *pb.Tokens {
+	accessExpiresAt, _ := t.AccessExpiresAt.MarshalBinary()
+	refreshExpiresAt, _ := t.RefreshExpiresAt.MarshalBinary()
+	return &pb.Tokens{
+		AccessToken:           t.AccessToken,
+		AccessTokenExpiresAt:  accessExpiresAt,
+		RefreshToken:          t.RefreshToken,
+		RefreshTokenExpiresAt: refreshExpiresAt,
+	}
+}
+
+func accountToProto(a *Account) *pb.Account {
+	updatedAt, _ := a.UpdatedAt.MarshalBinary()
+	return &pb.Account{
+		Id:        a.ID,
+		Name:      a.Name,
+		Status:    string(a.Status),
+		Version:   a.Version,
+		UpdatedAt: updatedAt,
+		Email:     a.Email,
+		Roles:     auth.RoleNames(a.Roles),
+	}
+}
+
+// accountError maps service errors onto gRPC status codes.
+func accountError(err error) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, ErrInvalidName), errors.Is(err, ErrInvalidEmail), errors.Is(err, ErrInvalidPassword),
+		errors.Is(err, auth.ErrInvalidRole):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, ErrEmailTaken):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, ErrInvalidCredentials), errors.Is(err, auth.ErrInvalidToken):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, ErrInvalidState), errors.Is(err, ErrCredentialsNotFound):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, ErrVersionConflict):
+		return status.Error(codes.Aborted, err.Error())
+	}
+	return auth.StatusError(err)
+}
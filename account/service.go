@@ -2,21 +2,90 @@ package account
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/master-wayne7/go-microservices/auth"
+	"github.com/master-wayne7/go-microservices/events"
 	"github.com/segmentio/ksuid"
 )
 
+// MaxNameLength matches the accounts.name column.
+const MaxNameLength = 24
+
+var (
+	ErrNotFound        = errors.New("account not found")
+	ErrInvalidName     = errors.New("account name must be 1 to 24 characters")
+	ErrVersionConflict = errors.New("account was modified concurrently")
+	ErrInvalidState    = errors.New("account cannot be changed in its current state")
+	// ErrCredentialsNotFound is returned for accounts that were created
+	// without an email and password.
+	ErrCredentialsNotFound = errors.New("account has no login credentials")
+)
+
 type Service interface {
 	PostAccount(ctx context.Context, name string) (*Account, error)
 	GetAccount(ctx context.Context, id string) (*Account, error)
 	GetAccounts(ctx context.Context, skip, take uint64) ([]Account, error)
+	UpdateAccount(ctx context.Context, id, name string, version uint64) (*Account, error)
+	DeactivateAccount(ctx context.Context, id string, version uint64) (*Account, error)
+	DeleteAccount(ctx context.Context, id string, version uint64) (*Account, error)
+	// Register creates an account that can log in with email and password.
+	Register(ctx context.Context, name, email, password string) (*Account, *auth.Tokens, error)
+	Login(ctx context.Context, email, password string) (*Account, *auth.Tokens, error)
+	// RefreshTokens exchanges a refresh token for a new token pair.
+	RefreshTokens(ctx context.Context, refreshToken string) (*auth.Tokens, error)
+	// ChangePassword replaces the password and revokes every refresh token
+	// issued before.
+	ChangePassword(ctx context.Context, accountID, currentPassword, newPassword string) (*auth.Tokens, error)
+	// AssignRoles replaces the roles of an account. They take effect with
+	// the account's next token pair.
+	AssignRoles(ctx context.Context, id string, roles []auth.Role, version uint64) (*Account, error)
 }
 
+type Status string
+
+// Deactivated accounts can no longer place orders. Deleted accounts are
+// additionally hidden from listings but still resolve by ID, so historic
+// orders keep their owner.
+const (
+	StatusActive      Status = "active"
+	StatusDeactivated Status = "deactivated"
+	StatusDeleted     Status = "deleted"
+)
+
 type Account struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status Status `json:"status"`
+	// Email is empty for accounts without login credentials.
+	Email string      `json:"email,omitempty"`
+	Roles []auth.Role `json:"roles"`
+	// Version increases with every change and guards updates against
+	// concurrent writers.
+	Version   uint64    `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
+
+func (a Account) IsActive() bool {
+	return a.Status == StatusActive
+}
+
+// Credentials let an account log in. They are never returned to clients.
+type Credentials struct {
+	AccountID    string
+	Email        string
+	PasswordHash string
+	Generation   uint64
+	UpdatedAt    time.Time
+}
+
 type accountService struct {
 	repository Repository
+	signer     *auth.Signer
 }
 
 // GetAccount implements Service.
@@ -34,16 +103,265 @@ func (a *accountService) GetAccounts(ctx context.Context, skip uint64, take uint
 
 // PostAccount implements Service.
 func (a *accountService) PostAccount(ctx context.Context, name string) (*Account, error) {
-	acc := &Account{
-		ID:   ksuid.New().String(),
-		Name: name,
+	name, err := normalizeName(name)
+	if err != nil {
+		return nil, err
 	}
-	if err := a.repository.PutAccount(ctx, *acc); err != nil {
+	acc := newAccount(name)
+	if err := a.repository.PutAccount(ctx, *acc, nil); err != nil {
 		return nil, err
 	}
 	return acc, nil
 }
 
-func NewService(r Repository) Service {
-	return &accountService{repository: r}
+// Register implements Service.
+func (a *accountService) Register(ctx context.Context, name, email, password string) (*Account, *auth.Tokens, error) {
+	name, err := normalizeName(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if email, err = normalizeEmail(email); err != nil {
+		return nil, nil, err
+	}
+	if err := validatePassword(password); err != nil {
+		return nil, nil, err
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	acc := newAccount(name)
+	acc.Email = email
+	c := &Credentials{
+		AccountID:    acc.ID,
+		Email:        email,
+		PasswordHash: hash,
+		Generation:   1,
+		UpdatedAt:    acc.UpdatedAt,
+	}
+	if err := a.repository.PutAccount(ctx, *acc, c); err != nil {
+		return nil, nil, err
+	}
+	tokens, err := a.signer.Issue(acc.ID, c.Generation, acc.Roles)
+	if err != nil {
+		return nil, nil, err
+	}
+	return acc, &tokens, nil
+}
+
+// Login implements Service. Unknown emails and wrong passwords fail the same
+// way.
+func (a *accountService) Login(ctx context.Context, email, password string) (*Account, *auth.Tokens, error) {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return nil, nil, ErrInvalidCredentials
+	}
+	c, err := a.repository.GetCredentialsByEmail(ctx, email)
+	if errors.Is(err, ErrCredentialsNotFound) {
+		verifyPassword(password, dummyHash)
+		return nil, nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	ok, err := verifyPassword(password, c.PasswordHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		return nil, nil, ErrInvalidCredentials
+	}
+
+	acc, err := a.loginAccount(ctx, c.AccountID)
+	if err != nil {
+		return nil, nil, err
+	}
+	tokens, err := a.signer.Issue(acc.ID, c.Generation, acc.Roles)
+	if err != nil {
+		return nil, nil, err
+	}
+	return acc, &tokens, nil
+}
+
+// RefreshTokens implements Service.
+func (a *accountService) RefreshTokens(ctx context.Context, refreshToken string) (*auth.Tokens, error) {
+	claims, err := a.signer.Verify(refreshToken, auth.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+	c, err := a.repository.GetCredentials(ctx, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Generation != c.Generation {
+		return nil, fmt.Errorf("%w: refresh token was revoked", auth.ErrInvalidToken)
+	}
+	acc, err := a.loginAccount(ctx, c.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := a.signer.Issue(acc.ID, c.Generation, acc.Roles)
+	if err != nil {
+		return nil, err
+	}
+	return &tokens, nil
+}
+
+// ChangePassword implements Service.
+func (a *accountService) ChangePassword(ctx context.Context, accountID, currentPassword, newPassword string) (*auth.Tokens, error) {
+	if err := validatePassword(newPassword); err != nil {
+		return nil, err
+	}
+	c, err := a.repository.GetCredentials(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := verifyPassword(currentPassword, c.PasswordHash)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	acc, err := a.loginAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := hashPassword(newPassword)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.repository.UpdatePassword(ctx, accountID, hash, c.Generation, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	tokens, err := a.signer.Issue(acc.ID, c.Generation+1, acc.Roles)
+	if err != nil {
+		return nil, err
+	}
+	return &tokens, nil
+}
+
+// loginAccount loads an account that may be issued tokens.
+func (a *accountService) loginAccount(ctx context.Context, id string) (*Account, error) {
+	acc, err := a.repository.GetAccountByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !acc.IsActive() {
+		return nil, fmt.Errorf("%w: %s is %s", ErrInvalidState, acc.ID, acc.Status)
+	}
+	return acc, nil
+}
+
+func newAccount(name string) *Account {
+	return &Account{
+		ID:        ksuid.New().String(),
+		Name:      name,
+		Status:    StatusActive,
+		Version:   1,
+		UpdatedAt: time.Now().UTC(),
+		Roles:     []auth.Role{auth.RoleCustomer},
+	}
+}
+
+// UpdateAccount implements Service. It renames an account that is not
+// deleted.
+func (a *accountService) UpdateAccount(ctx context.Context, id, name string, version uint64) (*Account, error) {
+	name, err := normalizeName(name)
+	if err != nil {
+		return nil, err
+	}
+	return a.change(ctx, id, version, events.AccountUpdated, func(acc *Account) error {
+		if acc.Status == StatusDeleted {
+			return fmt.Errorf("%w: %s is deleted", ErrInvalidState, acc.ID)
+		}
+		acc.Name = name
+		return nil
+	})
+}
+
+// DeactivateAccount implements Service.
+func (a *accountService) DeactivateAccount(ctx context.Context, id string, version uint64) (*Account, error) {
+	return a.change(ctx, id, version, events.AccountDeactivated, func(acc *Account) error {
+		if acc.Status != StatusActive {
+			return fmt.Errorf("%w: %s is %s", ErrInvalidState, acc.ID, acc.Status)
+		}
+		acc.Status = StatusDeactivated
+		return nil
+	})
+}
+
+// DeleteAccount implements Service. Deletion is soft: the row stays so
+// orders can still resolve the account.
+func (a *accountService) DeleteAccount(ctx context.Context, id string, version uint64) (*Account, error) {
+	return a.change(ctx, id, version, events.AccountDeleted, func(acc *Account) error {
+		if acc.Status == StatusDeleted {
+			return fmt.Errorf("%w: %s is already deleted", ErrInvalidState, acc.ID)
+		}
+		acc.Status = StatusDeleted
+		return nil
+	})
+}
+
+// AssignRoles implements Service. An account always keeps at least one
+// role, and admins cannot drop their own admin role, so the last admin
+// cannot lock everyone out by accident.
+func (a *accountService) AssignRoles(ctx context.Context, id string, roles []auth.Role, version uint64) (*Account, error) {
+	roles, err := auth.ParseRoles(auth.RoleNames(roles))
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) == 0 {
+		return nil, fmt.Errorf("%w: an account needs at least one role", auth.ErrInvalidRole)
+	}
+	if p, ok := auth.FromContext(ctx); ok && p.AccountID == id && p.HasRole(auth.RoleAdmin) {
+		if !(&auth.Principal{Roles: roles}).HasRole(auth.RoleAdmin) {
+			return nil, fmt.Errorf("%w: admins cannot remove their own admin role", ErrInvalidState)
+		}
+	}
+	return a.change(ctx, id, version, events.AccountRolesChanged, func(acc *Account) error {
+		if acc.Status == StatusDeleted {
+			return fmt.Errorf("%w: %s is deleted", ErrInvalidState, acc.ID)
+		}
+		acc.Roles = roles
+		return nil
+	})
+}
+
+// change applies mutate to the stored account and saves it with an event of
+// eventType if nobody else changed it in the meantime. version must match
+// the stored version unless it is 0.
+func (a *accountService) change(ctx context.Context, id string, version uint64, eventType string, mutate func(*Account) error) (*Account, error) {
+	acc, err := a.repository.GetAccountByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && version != acc.Version {
+		return nil, fmt.Errorf("%w: expected version %d, found %d", ErrVersionConflict, version, acc.Version)
+	}
+	if err := mutate(acc); err != nil {
+		return nil, err
+	}
+	from := acc.Version
+	acc.Version++
+	acc.UpdatedAt = time.Now().UTC()
+	if err := a.repository.UpdateAccount(ctx, *acc, from, eventType); err != nil {
+		return nil, err
+	}
+	return acc, nil
+}
+
+func normalizeName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > MaxNameLength {
+		return "", ErrInvalidName
+	}
+	return name, nil
+}
+
+// NewService returns the account service. signer issues the tokens handed
+// out by Register, Login, RefreshTokens and ChangePassword.
+func NewService(r Repository, signer *auth.Signer) Service {
+	return &accountService{repository: r, signer: signer}
 }
@@ -1,4 +1,44 @@
 CREATE TABLE IF NOT EXISTS accounts(
     id CHAR (27) PRIMARY KEY,
-    name VARCHAR(24) NOT NULL
-);
\ No newline at end of file
+    name VARCHAR(24) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'active',
+    version BIGINT NOT NULL DEFAULT 1,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    -- customer, staff and/or admin
+    roles TEXT[] NOT NULL DEFAULT '{customer}'
+);
+
+CREATE INDEX IF NOT EXISTS accounts_listed_idx ON accounts (id DESC) WHERE status <> 'deleted';
+
+-- Login credentials. email is stored normalized (trimmed, lowercase);
+-- password_hash is an Argon2id PHC string. generation is bumped on every
+-- password change and invalidates refresh tokens issued before it.
+CREATE TABLE IF NOT EXISTS credentials (
+    account_id CHAR(27) PRIMARY KEY REFERENCES accounts (id),
+    email VARCHAR(254) NOT NULL UNIQUE,
+    password_hash TEXT NOT NULL,
+    generation BIGINT NOT NULL DEFAULT 1,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    scope VARCHAR(64) NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    request_hash CHAR(64) NOT NULL,
+    response BYTEA,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (scope, key)
+);
+
+CREATE TABLE IF NOT EXISTS outbox (
+    id CHAR(27) PRIMARY KEY,
+    type VARCHAR(64) NOT NULL,
+    aggregate_type VARCHAR(32) NOT NULL,
+    aggregate_id VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL,
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    published_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS outbox_unpublished_idx ON outbox (occurred_at, id) WHERE published_at IS NULL;
@@ -0,0 +1,191 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// MetadataKey is the gRPC metadata key carrying "Bearer <access token>".
+const MetadataKey = "authorization"
+
+// Rule decides whether principal may call a method with req. principal is
+// nil for anonymous callers. Rules return ErrUnauthenticated or ErrForbidden.
+type Rule func(principal *Principal, req interface{}) error
+
+// Rules maps full gRPC method names, e.g. "/pb.AccountService/GetAccount",
+// to their rule. Methods without a rule are denied.
+type Rules map[string]Rule
+
+// Public lets anyone call a method.
+func Public(*Principal, interface{}) error {
+	return nil
+}
+
+// Authenticated requires a valid token of any account or service.
+func Authenticated(p *Principal, _ interface{}) error {
+	if p == nil {
+		return ErrUnauthenticated
+	}
+	return nil
+}
+
+// ServiceOnly restricts a method to other services.
+func ServiceOnly(p *Principal, _ interface{}) error {
+	if p == nil {
+		return ErrUnauthenticated
+	}
+	if !p.IsService() {
+		return fmt.Errorf("%w: only services may call this method", ErrForbidden)
+	}
+	return nil
+}
+
+// AccountOwner lets the account named by the request, staff and services
+// call a method. accountID extracts that account from the request.
+func AccountOwner[T any](accountID func(req T) string) Rule {
+	return func(p *Principal, req interface{}) error {
+		if p == nil {
+			return ErrUnauthenticated
+		}
+		if p.IsService() || p.HasRole(RoleStaff) {
+			return nil
+		}
+		r, ok := req.(T)
+		if !ok || p.AccountID != accountID(r) {
+			return fmt.Errorf("%w: not allowed to act for another account", ErrForbidden)
+		}
+		return nil
+	}
+}
+
+// UnaryServerInterceptor authenticates the bearer token in the request
+// metadata, applies the method's rule and puts the principal into the
+// handler's context.
+func UnaryServerInterceptor(signer *Signer, rules Rules) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authorize(ctx, signer, rules, info.FullMethod, req)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is UnaryServerInterceptor for streams. Rules see a
+// nil request.
+func StreamServerInterceptor(signer *Signer, rules Rules) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authorize(ss.Context(), signer, rules, info.FullMethod, nil)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+func authorize(ctx context.Context, signer *Signer, rules Rules, method string, req interface{}) (context.Context, error) {
+	var principal *Principal
+	if values := metadata.ValueFromIncomingContext(ctx, MetadataKey); len(values) > 0 {
+		token, ok := BearerToken(values[0])
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "malformed authorization metadata")
+		}
+		var err error
+		if principal, err = signer.Authenticate(token); err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		ctx = NewContext(ctx, principal)
+	}
+
+	rule, ok := rules[method]
+	if !ok {
+		return nil, status.Errorf(codes.PermissionDenied, "no permission rule for %s", method)
+	}
+	if err := rule(principal, req); err != nil {
+		return nil, StatusError(err)
+	}
+	return ctx, nil
+}
+
+// StatusError maps ErrUnauthenticated and ErrForbidden onto gRPC status
+// codes and returns other errors unchanged.
+func StatusError(err error) error {
+	switch {
+	case errors.Is(err, ErrUnauthenticated):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, ErrForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return err
+}
+
+// UnaryClientInterceptor forwards the token of the principal in ctx, so the
+// called service sees the same caller as the calling one.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(outgoingContext(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor is UnaryClientInterceptor for streams.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(outgoingContext(ctx), desc, cc, method, opts...)
+	}
+}
+
+func outgoingContext(ctx context.Context) context.Context {
+	p, ok := FromContext(ctx)
+	if !ok || p.Token == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, MetadataKey, "Bearer "+p.Token)
+}
+
+// ServiceIdentity lets a service call others as itself, for work that is
+// not done on behalf of an account, such as background jobs.
+type ServiceIdentity struct {
+	signer *Signer
+	name   string
+
+	mu        sync.Mutex
+	principal *Principal
+	expiresAt time.Time
+}
+
+func NewServiceIdentity(signer *Signer, name string) *ServiceIdentity {
+	return &ServiceIdentity{signer: signer, name: name}
+}
+
+// Context returns ctx with the service as its principal. The token is
+// reused until shortly before it expires.
+func (s *ServiceIdentity) Context(ctx context.Context) (context.Context, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.principal == nil || time.Until(s.expiresAt) < time.Minute {
+		token, expiresAt, err := s.signer.IssueService(s.name)
+		if err != nil {
+			return nil, err
+		}
+		s.principal = &Principal{Service: s.name, Token: token}
+		s.expiresAt = expiresAt
+	}
+	return NewContext(ctx, s.principal), nil
+}
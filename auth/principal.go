@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrUnauthenticated = errors.New("authentication required")
+	ErrForbidden       = errors.New("permission denied")
+)
+
+// Principal is the authenticated caller of a request: an account, or a
+// service acting on its own behalf.
+type Principal struct {
+	AccountID string
+	Roles     []Role
+	Service   string
+	// Token is the access token the principal authenticated with. Outgoing
+	// gRPC calls forward it so downstream services see the same caller.
+	Token string
+}
+
+func (p *Principal) IsService() bool {
+	return p.Service != ""
+}
+
+// Name identifies p in audit records such as the status history of an
+// order: the service name for services, the account ID otherwise.
+func (p *Principal) Name() string {
+	if p.IsService() {
+		return p.Service
+	}
+	return p.AccountID
+}
+
+// PrincipalFromClaims returns the principal an access token was issued to.
+func PrincipalFromClaims(c *Claims) *Principal {
+	if c.Service != "" {
+		return &Principal{Service: c.Service}
+	}
+	return &Principal{AccountID: c.Subject, Roles: c.Roles}
+}
+
+// ActsFor reports whether p may act for the account accountID: it is that
+// account, staff or a service.
+func (p *Principal) ActsFor(accountID string) bool {
+	return p.IsService() || p.HasRole(RoleStaff) || p.AccountID == accountID
+}
+
+// AuthorizeAccount fails unless the caller in ctx may act for the account
+// accountID. Handlers use it for checks that need the stored resource, such
+// as the owner of an order.
+func AuthorizeAccount(ctx context.Context, accountID string) error {
+	p, ok := FromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+	if p.ActsFor(accountID) {
+		return nil
+	}
+	return fmt.Errorf("%w: not allowed to access account %s", ErrForbidden, accountID)
+}
+
+type principalKey struct{}
+
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal of the request, if it was authenticated.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// BearerToken extracts the token from an "Authorization: Bearer <token>"
+// header value.
+func BearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+type Role string
+
+// Roles are ranked: staff can do everything a customer can, and admins
+// everything staff can.
+//
+//   - customer: shops, and sees and changes only their own account and orders.
+//   - staff: manages the catalog, stock, every account and every order.
+//   - admin: additionally assigns roles.
+const (
+	RoleCustomer Role = "customer"
+	RoleStaff    Role = "staff"
+	RoleAdmin    Role = "admin"
+)
+
+var ErrInvalidRole = errors.New("invalid role")
+
+var roleRank = map[Role]int{
+	RoleCustomer: 1,
+	RoleStaff:    2,
+	RoleAdmin:    3,
+}
+
+func ParseRole(s string) (Role, error) {
+	if _, ok := roleRank[Role(s)]; !ok {
+		return "", fmt.Errorf("%w: %q", ErrInvalidRole, s)
+	}
+	return Role(s), nil
+}
+
+// ParseRoles parses, deduplicates and sorts a role list.
+func ParseRoles(names []string) ([]Role, error) {
+	seen := map[Role]bool{}
+	roles := make([]Role, 0, len(names))
+	for _, name := range names {
+		r, err := ParseRole(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[r] {
+			seen[r] = true
+			roles = append(roles, r)
+		}
+	}
+	sort.Slice(roles, func(i, j int) bool { return roleRank[roles[i]] < roleRank[roles[j]] })
+	return roles, nil
+}
+
+// Includes reports whether holding r grants other.
+func (r Role) Includes(other Role) bool {
+	rank, ok := roleRank[r]
+	return ok && rank >= roleRank[other]
+}
+
+// HasRole reports whether any of the principal's roles grants role.
+func (p *Principal) HasRole(role Role) bool {
+	for _, r := range p.Roles {
+		if r.Includes(role) {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireRole lets services and principals holding role call a method.
+func RequireRole(role Role) Rule {
+	return func(p *Principal, _ interface{}) error {
+		if p == nil {
+			return ErrUnauthenticated
+		}
+		if p.IsService() || p.HasRole(role) {
+			return nil
+		}
+		return fmt.Errorf("%w: requires role %s", ErrForbidden, role)
+	}
+}
+
+// RoleNames converts roles to their string form, e.g. for protos.
+func RoleNames(roles []Role) []string {
+	names := make([]string, len(roles))
+	for i, r := range roles {
+		names[i] = string(r)
+	}
+	return names
+}
@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/segmentio/ksuid"
+)
+
+const (
+	Issuer = "go-microservices"
+	// MinSecretLength keeps HMAC secrets at least as long as the SHA-256
+	// output.
+	MinSecretLength = 32
+
+	DefaultAccessTTL  = 15 * time.Minute
+	DefaultRefreshTTL = 30 * 24 * time.Hour
+)
+
+var (
+	ErrSecretTooShort = errors.New("token secret must be at least 32 bytes")
+	ErrInvalidToken   = errors.New("invalid token")
+)
+
+type TokenType string
+
+// Access tokens authenticate requests. Refresh tokens are only accepted to
+// obtain a new pair.
+const (
+	AccessToken  TokenType = "access"
+	RefreshToken TokenType = "refresh"
+)
+
+// Claims are carried by every token. Subject is the account ID, or the
+// service name on service tokens.
+type Claims struct {
+	jwt.RegisteredClaims
+	Type TokenType `json:"typ"`
+	// Generation is the account's credential generation at issue time.
+	// Changing the password bumps it, which invalidates older refresh
+	// tokens.
+	Generation uint64 `json:"gen,omitempty"`
+	// Roles are the account's roles at issue time. They are refreshed
+	// with every new token pair.
+	Roles []Role `json:"roles,omitempty"`
+	// Service is set instead of an account subject on tokens a service
+	// issues for its own calls.
+	Service string `json:"svc,omitempty"`
+}
+
+// Tokens is an issued access and refresh token pair.
+type Tokens struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+// Signer issues and verifies HS256 tokens. Every holder of the secret can
+// verify tokens, so it is shared by the account service and the gateway.
+type Signer struct {
+	secret     []byte
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+func NewSigner(secret string) (*Signer, error) {
+	if len(secret) < MinSecretLength {
+		return nil, ErrSecretTooShort
+	}
+	return &Signer{
+		secret:     []byte(secret),
+		AccessTTL:  DefaultAccessTTL,
+		RefreshTTL: DefaultRefreshTTL,
+	}, nil
+}
+
+// Issue signs a new token pair for the account subject holding roles.
+func (s *Signer) Issue(subject string, generation uint64, roles []Role) (Tokens, error) {
+	now := time.Now().UTC()
+	t := Tokens{
+		AccessExpiresAt:  now.Add(s.AccessTTL),
+		RefreshExpiresAt: now.Add(s.RefreshTTL),
+	}
+	var err error
+	if t.AccessToken, err = s.sign(subject, AccessToken, generation, roles, now, t.AccessExpiresAt); err != nil {
+		return Tokens{}, err
+	}
+	if t.RefreshToken, err = s.sign(subject, RefreshToken, generation, roles, now, t.RefreshExpiresAt); err != nil {
+		return Tokens{}, err
+	}
+	return t, nil
+}
+
+// IssueService signs an access token for the named service. Services use it
+// for calls that are not made on behalf of an account.
+func (s *Signer) IssueService(name string) (string, time.Time, error) {
+	now := time.Now().UTC()
+	expiresAt := now.Add(s.AccessTTL)
+	claims := s.claims(name, AccessToken, now, expiresAt)
+	claims.Service = name
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+	return token, expiresAt, err
+}
+
+// Authenticate verifies an access token and returns its principal.
+func (s *Signer) Authenticate(token string) (*Principal, error) {
+	claims, err := s.Verify(token, AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	p := PrincipalFromClaims(claims)
+	p.Token = token
+	return p, nil
+}
+
+func (s *Signer) sign(subject string, typ TokenType, generation uint64, roles []Role, now, expiresAt time.Time) (string, error) {
+	claims := s.claims(subject, typ, now, expiresAt)
+	claims.Generation = generation
+	claims.Roles = roles
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+}
+
+func (s *Signer) claims(subject string, typ TokenType, now, expiresAt time.Time) Claims {
+	return Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        ksuid.New().String(),
+			Issuer:    Issuer,
+			Subject:   subject,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		Type: typ,
+	}
+}
+
+// Verify checks the signature, expiry and issuer of token and that it is of
+// the expected type.
+func (s *Signer) Verify(token string, typ TokenType) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(
+		token,
+		claims,
+		func(*jwt.Token) (interface{}, error) { return s.secret, nil },
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(Issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if claims.Type != typ {
+		return nil, fmt.Errorf("%w: expected a %s token", ErrInvalidToken, typ)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidToken)
+	}
+	return claims, nil
+}
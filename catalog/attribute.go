@@ -0,0 +1,350 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxEnumValues bounds the values of an enum attribute.
+const MaxEnumValues = 100
+
+// AttributeType is the kind of values an attribute takes.
+type AttributeType string
+
+const (
+	// AttributeString takes any value.
+	AttributeString AttributeType = "string"
+	// AttributeNumber takes decimal numbers such as "42" or "-1.5".
+	AttributeNumber AttributeType = "number"
+	// AttributeEnum takes one of the values of its definition.
+	AttributeEnum AttributeType = "enum"
+	// AttributeBoolean takes "true" or "false".
+	AttributeBoolean AttributeType = "boolean"
+)
+
+// AttributeDefinition describes an attribute of the products in a category
+// and its subcategories.
+type AttributeDefinition struct {
+	Name string        `json:"name"`
+	Type AttributeType `json:"type"`
+	// Values are the values an enum accepts. Other types have none.
+	Values []string `json:"values,omitempty"`
+	// Required attributes have to be set on the product, or else on every
+	// one of its variants.
+	Required bool `json:"required,omitempty"`
+}
+
+func (d AttributeDefinition) equal(other AttributeDefinition) bool {
+	return d.Name == other.Name && d.Type == other.Type && d.Required == other.Required && slices.Equal(d.Values, other.Values)
+}
+
+// value checks value against d and returns it in canonical form: numbers
+// formatted the shortest way, booleans lowercase and enum values as
+// defined, so filters find them whatever way they were written.
+func (d AttributeDefinition) value(value string) (string, error) {
+	switch d.Type {
+	case AttributeNumber:
+		if n, ok := parseNumber(value); ok {
+			return strconv.FormatFloat(n, 'f', -1, 64), nil
+		}
+		return "", fmt.Errorf("%w: attribute %q must be a number, not %q", ErrInvalidProduct, d.Name, value)
+	case AttributeBoolean:
+		if b, err := strconv.ParseBool(strings.ToLower(value)); err == nil {
+			return strconv.FormatBool(b), nil
+		}
+		return "", fmt.Errorf("%w: attribute %q must be true or false, not %q", ErrInvalidProduct, d.Name, value)
+	case AttributeEnum:
+		for _, v := range d.Values {
+			if strings.EqualFold(v, value) {
+				return v, nil
+			}
+		}
+		return "", fmt.Errorf("%w: attribute %q must be one of %s, not %q", ErrInvalidProduct, d.Name, strings.Join(d.Values, ", "), value)
+	}
+	return value, nil
+}
+
+// parseNumber reads a finite decimal number.
+func parseNumber(s string) (float64, bool) {
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsInf(n, 0) || math.IsNaN(n) {
+		return 0, false
+	}
+	return n, true
+}
+
+// SetCategoryAttributes implements Service. The definitions apply to
+// products written from now on; stored products are checked once they
+// change.
+func (c *catalogService) SetCategoryAttributes(ctx context.Context, id string, attributes []AttributeDefinition) (*Category, error) {
+	attributes, err := normalizeDefinitions(attributes)
+	if err != nil {
+		return nil, err
+	}
+	cat, err := c.repository.GetCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	cat.Attributes = attributes
+	if len(cat.Path) > 1 {
+		ancestors, err := c.repository.ListCategoriesWithIDs(ctx, cat.Path[:len(cat.Path)-1])
+		if err != nil {
+			return nil, err
+		}
+		if err := redefined(ancestors, []Category{*cat}); err != nil {
+			return nil, err
+		}
+	}
+	subtree, err := c.repository.ListCategorySubtree(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	subtree = slices.DeleteFunc(subtree, func(sub Category) bool { return sub.ID == id })
+	if err := redefined([]Category{*cat}, subtree); err != nil {
+		return nil, err
+	}
+	if err := c.repository.PutCategory(ctx, *cat); err != nil {
+		return nil, err
+	}
+	return cat, nil
+}
+
+// redefined fails if a category of below defines an attribute that a
+// category of above defines too. Subcategories inherit the definitions of
+// their ancestors and cannot change them.
+func redefined(above, below []Category) error {
+	definedBy := map[string]string{}
+	for _, cat := range above {
+		for _, d := range cat.Attributes {
+			definedBy[d.Name] = cat.ID
+		}
+	}
+	for _, cat := range below {
+		for _, d := range cat.Attributes {
+			if id, ok := definedBy[d.Name]; ok {
+				return fmt.Errorf("%w: attribute %q is defined by both %s and its subcategory %s", ErrInvalidCategory, d.Name, id, cat.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// normalizeDefinitions trims and checks attribute definitions. Names follow
+// the rules of product attributes and have to be unique.
+func normalizeDefinitions(in []AttributeDefinition) ([]AttributeDefinition, error) {
+	if len(in) == 0 {
+		return nil, nil
+	}
+	if len(in) > MaxAttributes {
+		return nil, fmt.Errorf("%w: more than %d attributes", ErrInvalidCategory, MaxAttributes)
+	}
+	definitions := make([]AttributeDefinition, 0, len(in))
+	for _, d := range in {
+		d.Name = strings.TrimSpace(d.Name)
+		if d.Name == "" || utf8.RuneCountInString(d.Name) > MaxAttributeNameLength || strings.Contains(d.Name, "=") {
+			return nil, fmt.Errorf("%w: attribute name %q", ErrInvalidCategory, d.Name)
+		}
+		if slices.ContainsFunc(definitions, func(other AttributeDefinition) bool { return other.Name == d.Name }) {
+			return nil, fmt.Errorf("%w: duplicate attribute %q", ErrInvalidCategory, d.Name)
+		}
+		switch d.Type {
+		case AttributeString, AttributeNumber, AttributeBoolean:
+			if len(d.Values) > 0 {
+				return nil, fmt.Errorf("%w: only enum attributes have values, %q is a %s", ErrInvalidCategory, d.Name, d.Type)
+			}
+			d.Values = nil
+		case AttributeEnum:
+			values, err := normalizeEnumValues(d)
+			if err != nil {
+				return nil, err
+			}
+			d.Values = values
+		default:
+			return nil, fmt.Errorf("%w: attribute %q has unknown type %q", ErrInvalidCategory, d.Name, d.Type)
+		}
+		definitions = append(definitions, d)
+	}
+	return definitions, nil
+}
+
+// normalizeEnumValues trims the values of an enum, which must differ in more
+// than case as they are matched ignoring it.
+func normalizeEnumValues(d AttributeDefinition) ([]string, error) {
+	if len(d.Values) == 0 || len(d.Values) > MaxEnumValues {
+		return nil, fmt.Errorf("%w: enum attribute %q needs 1 to %d values", ErrInvalidCategory, d.Name, MaxEnumValues)
+	}
+	values := make([]string, 0, len(d.Values))
+	for _, v := range d.Values {
+		v = strings.TrimSpace(v)
+		if v == "" || utf8.RuneCountInString(v) > MaxAttributeValueLength {
+			return nil, fmt.Errorf("%w: value %q of attribute %q", ErrInvalidCategory, v, d.Name)
+		}
+		if slices.ContainsFunc(values, func(other string) bool { return strings.EqualFold(other, v) }) {
+			return nil, fmt.Errorf("%w: duplicate value %q of attribute %q", ErrInvalidCategory, v, d.Name)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// categoriesWithAncestors looks up the categories ids and all their
+// ancestors by ID.
+func (c *catalogService) categoriesWithAncestors(ctx context.Context, ids []string) (map[string]Category, error) {
+	byID := map[string]Category{}
+	if len(ids) == 0 {
+		return byID, nil
+	}
+	categories, err := c.repository.ListCategoriesWithIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	var ancestorIDs []string
+	for _, cat := range categories {
+		byID[cat.ID] = cat
+		ancestorIDs = append(ancestorIDs, cat.Path...)
+	}
+	ancestorIDs = slices.DeleteFunc(slices.Compact(slices.Sorted(slices.Values(ancestorIDs))), func(id string) bool {
+		_, ok := byID[id]
+		return ok
+	})
+	if len(ancestorIDs) == 0 {
+		return byID, nil
+	}
+	ancestors, err := c.repository.ListCategoriesWithIDs(ctx, ancestorIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, cat := range ancestors {
+		byID[cat.ID] = cat
+	}
+	return byID, nil
+}
+
+// attributeSchema collects the definitions of the categories categoryIDs and
+// their ancestors, which have to be in byID. Categories in different
+// branches of the tree may define the same attribute, but only alike.
+func attributeSchema(categoryIDs []string, byID map[string]Category) (map[string]AttributeDefinition, error) {
+	schema := map[string]AttributeDefinition{}
+	for _, id := range categoryIDs {
+		for _, ancestor := range byID[id].Path {
+			for _, d := range byID[ancestor].Attributes {
+				if defined, ok := schema[d.Name]; ok && !defined.equal(d) {
+					return nil, fmt.Errorf("%w: categories define attribute %q differently", ErrInvalidProduct, d.Name)
+				}
+				schema[d.Name] = d
+			}
+		}
+	}
+	return schema, nil
+}
+
+// applySchema checks the attributes of p and its variants against schema
+// and puts their values in canonical form. Attributes schema does not
+// define stay free-form.
+func applySchema(p *Product, schema map[string]AttributeDefinition) error {
+	if len(schema) == 0 {
+		return nil
+	}
+	canonical := func(attributes map[string]string) error {
+		for name, value := range attributes {
+			if d, ok := schema[name]; ok {
+				v, err := d.value(value)
+				if err != nil {
+					return err
+				}
+				attributes[name] = v
+			}
+		}
+		return nil
+	}
+	if err := canonical(p.Attributes); err != nil {
+		return err
+	}
+	combinations := map[string]bool{}
+	for _, v := range p.Variants {
+		if err := canonical(v.Attributes); err != nil {
+			return fmt.Errorf("variant %s: %w", v.SKU, err)
+		}
+		key := variantKey(v.Attributes)
+		if combinations[key] {
+			return fmt.Errorf("%w: variant %s has the same attributes as another", ErrInvalidProduct, v.SKU)
+		}
+		combinations[key] = true
+	}
+
+	for name, d := range schema {
+		if !d.Required {
+			continue
+		}
+		if _, ok := p.Attributes[name]; ok {
+			continue
+		}
+		onVariants := len(p.Variants) > 0 && !slices.ContainsFunc(p.Variants, func(v Variant) bool {
+			_, ok := v.Attributes[name]
+			return !ok
+		})
+		if !onVariants {
+			return fmt.Errorf("%w: attribute %q is required", ErrInvalidProduct, name)
+		}
+	}
+	return nil
+}
+
+// numericAttributeDocument indexes an attribute value that is a number, so
+// it can be filtered by range.
+type numericAttributeDocument struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+}
+
+// newNumericAttributeDocuments picks the attributes whose values are
+// numbers. Every such value is indexed, whether the attribute is defined as
+// a number or not, so documents do not depend on the category definitions
+// at the time they were written.
+func newNumericAttributeDocuments(attributes map[string]string) []numericAttributeDocument {
+	var docs []numericAttributeDocument
+	for name, value := range attributes {
+		if n, ok := parseNumber(value); ok {
+			docs = append(docs, numericAttributeDocument{Name: name, Value: n})
+		}
+	}
+	slices.SortFunc(docs, func(a, b numericAttributeDocument) int { return strings.Compare(a.Name, b.Name) })
+	return docs
+}
+
+// AttributeRange bounds the numeric values of an attribute, inclusively.
+// A nil bound is open.
+type AttributeRange struct {
+	Min *float64
+	Max *float64
+}
+
+// attributeRangeFilter matches products with a numeric value of attribute
+// name within r.
+func attributeRangeFilter(name string, r AttributeRange) map[string]interface{} {
+	bounds := map[string]interface{}{}
+	if r.Min != nil {
+		bounds["gte"] = *r.Min
+	}
+	if r.Max != nil {
+		bounds["lte"] = *r.Max
+	}
+	return map[string]interface{}{
+		"nested": map[string]interface{}{
+			"path": "numeric_attributes",
+			"query": map[string]interface{}{
+				"bool": map[string]interface{}{
+					"filter": []interface{}{
+						map[string]interface{}{"term": map[string]interface{}{"numeric_attributes.name": name}},
+						map[string]interface{}{"range": map[string]interface{}{"numeric_attributes.value": bounds}},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,52 @@
+package catalog
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAttributeDefinitionValue(t *testing.T) {
+	number := AttributeDefinition{Name: "weight", Type: AttributeNumber}
+	boolean := AttributeDefinition{Name: "organic", Type: AttributeBoolean}
+	enum := AttributeDefinition{Name: "color", Type: AttributeEnum, Values: []string{"Red", "blue"}}
+	str := AttributeDefinition{Name: "material", Type: AttributeString}
+	tests := []struct {
+		d     AttributeDefinition
+		value string
+		want  string
+		valid bool
+	}{
+		{number, "42", "42", true},
+		{number, "1.50", "1.5", true},
+		{number, "-0.25", "-0.25", true},
+		{number, "10.0", "10", true},
+		{number, "1e3", "1000", true},
+		{number, "heavy", "", false},
+		{number, "", "", false},
+		{number, "NaN", "", false},
+		{number, "Inf", "", false},
+		{number, "1e400", "", false},
+		{boolean, "true", "true", true},
+		{boolean, "TRUE", "true", true},
+		{boolean, "False", "false", true},
+		{boolean, "1", "true", true},
+		{boolean, "yes", "", false},
+		{enum, "red", "Red", true},
+		{enum, "BLUE", "blue", true},
+		{enum, "green", "", false},
+		{str, "Cotton ", "Cotton ", true},
+		{str, "", "", true},
+	}
+	for _, tt := range tests {
+		got, err := tt.d.value(tt.value)
+		if !tt.valid {
+			if !errors.Is(err, ErrInvalidProduct) {
+				t.Errorf("%s value %q: error = %v, want %v", tt.d.Type, tt.value, err, ErrInvalidProduct)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s value %q = %q, %v, want %q", tt.d.Type, tt.value, got, err, tt.want)
+		}
+	}
+}
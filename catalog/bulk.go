@@ -0,0 +1,312 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v9/esapi"
+	"github.com/master-wayne7/go-microservices/events"
+	"github.com/segmentio/ksuid"
+)
+
+const (
+	// ImportBatchSize is how many products are written per bulk request.
+	ImportBatchSize = 500
+	// exportBatchSize is how many products are read per export page.
+	exportBatchSize = 500
+	// maxProductIDLength is the longest document ID Elasticsearch accepts.
+	maxProductIDLength = 512
+)
+
+var ErrProductExists = errors.New("product already exists")
+
+// ImportRow is a product to import. Row numbers the product in its source,
+// e.g. the line of a file, for error reports.
+type ImportRow struct {
+	Row     uint64
+	Product Product
+}
+
+// ImportError reports why a row was not imported.
+type ImportError struct {
+	Row       uint64
+	ProductID string
+	Message   string
+}
+
+type ImportResult struct {
+	Imported uint64
+	Errors   []ImportError
+}
+
+// ImportProducts implements Service. Products without an ID get a new one,
+// which is filled in. Products are checked and created like by PostProduct,
+// except that an existing ID fails with ErrProductExists. A product with a
+// SKU already in the catalog or earlier in products fails with ErrSKUExists.
+func (c *catalogService) ImportProducts(ctx context.Context, products []Product) ([]error, error) {
+	errs := make([]error, len(products))
+	var categoryIDs []string
+	for _, p := range products {
+		categoryIDs = append(categoryIDs, p.CategoryIDs...)
+	}
+	byID, err := c.categoriesWithAncestors(ctx, slices.Compact(slices.Sorted(slices.Values(categoryIDs))))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	valid := make([]Product, 0, len(products))
+	positions := make([]int, 0, len(products))
+	for i := range products {
+		p := &products[i]
+		p.ID = strings.TrimSpace(p.ID)
+		if p.ID == "" {
+			p.ID = ksuid.New().String()
+		}
+		p.CreatedAt = now
+		p.DeletedAt = time.Time{}
+		p.Version = Version{}
+		if len(p.ID) > maxProductIDLength {
+			errs[i] = fmt.Errorf("%w: id longer than %d bytes", ErrInvalidProduct, maxProductIDLength)
+			continue
+		}
+		if err := validateProduct(p); err != nil {
+			errs[i] = err
+			continue
+		}
+		if len(p.CategoryIDs) == 0 {
+			p.CategoryIDs = nil
+		} else {
+			p.CategoryIDs = slices.Compact(slices.Sorted(slices.Values(p.CategoryIDs)))
+		}
+		for _, id := range p.CategoryIDs {
+			if _, ok := byID[id]; !ok {
+				errs[i] = fmt.Errorf("%w: unknown category %s", ErrInvalidProduct, id)
+				break
+			}
+		}
+		if errs[i] == nil {
+			schema, err := attributeSchema(p.CategoryIDs, byID)
+			if err == nil {
+				err = applySchema(p, schema)
+			}
+			errs[i] = err
+		}
+		if errs[i] == nil {
+			valid = append(valid, *p)
+			positions = append(positions, i)
+		}
+	}
+	if len(valid) == 0 {
+		return errs, nil
+	}
+
+	// SKUs have to be unique within the batch as well as in the catalog.
+	var skus []string
+	batchOwners := map[string]string{}
+	for j := range valid {
+		for _, sku := range valid[j].SKUs() {
+			if id, ok := batchOwners[sku]; ok {
+				errs[positions[j]] = fmt.Errorf("%w: %s is also a SKU of product %s", ErrSKUExists, sku, id)
+				break
+			}
+			batchOwners[sku] = valid[j].ID
+			skus = append(skus, sku)
+		}
+	}
+	owners, err := c.skuOwners(ctx, skus)
+	if err != nil {
+		return nil, err
+	}
+	var unique []Product
+	var uniquePositions []int
+	for j := range valid {
+		i := positions[j]
+		if errs[i] == nil {
+			errs[i] = skuTaken(&valid[j], owners)
+		}
+		if errs[i] == nil {
+			unique = append(unique, valid[j])
+			uniquePositions = append(uniquePositions, i)
+		}
+	}
+	valid, positions = unique, uniquePositions
+	if len(valid) == 0 {
+		return errs, nil
+	}
+
+	putErrs, err := c.repository.PutProducts(ctx, valid)
+	if err != nil {
+		return nil, err
+	}
+	for j, err := range putErrs {
+		errs[positions[j]] = err
+	}
+	return errs, nil
+}
+
+// ExportProducts implements Service.
+func (c *catalogService) ExportProducts(ctx context.Context, category string, fn func([]Product) error) error {
+	categoryIDs, err := c.categoryFilter(ctx, category)
+	if err != nil {
+		return err
+	}
+	return c.repository.ExportProducts(ctx, categoryIDs, fn)
+}
+
+// PutProducts implements Repository. It creates all products with a single
+// bulk request, each with its ProductCreated event.
+func (r *elasticRepository) PutProducts(ctx context.Context, products []Product) ([]error, error) {
+	start := time.Now()
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, p := range products {
+		event, err := events.NewEvent(events.ProductCreated, "product", p.ID, p)
+		if err != nil {
+			return nil, err
+		}
+		doc := newProductDocument(p)
+		doc.Outbox = append(doc.Outbox, newOutboxEntry(event))
+		if err := enc.Encode(map[string]interface{}{
+			"create": map[string]interface{}{"_id": p.ID},
+		}); err != nil {
+			return nil, err
+		}
+		if err := enc.Encode(doc); err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := esapi.BulkRequest{
+		Index:   productWriteAlias,
+		Body:    &buf,
+		Refresh: "wait_for",
+	}.Do(ctx, r.client)
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("bulk", productIndex, time.Since(start))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return nil, fmt.Errorf("error importing products: %s", res.String())
+	}
+
+	var bulk struct {
+		Items []map[string]struct {
+			Status int `json:"status"`
+			Error  *struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&bulk); err != nil {
+		return nil, err
+	}
+	if len(bulk.Items) != len(products) {
+		return nil, fmt.Errorf("error importing products: %d results for %d products", len(bulk.Items), len(products))
+	}
+	errs := make([]error, len(products))
+	for i, item := range bulk.Items {
+		result := item["create"]
+		switch {
+		case result.Status == http.StatusConflict:
+			errs[i] = fmt.Errorf("%w: %s", ErrProductExists, products[i].ID)
+		case result.Error != nil:
+			errs[i] = fmt.Errorf("error indexing product %s: %s: %s", products[i].ID, result.Error.Type, result.Error.Reason)
+		}
+	}
+	return errs, nil
+}
+
+// ExportProducts implements Repository. It pages through a point in time,
+// so products changed during the export are seen as they were when it
+// started. Deleted products are left out.
+func (r *elasticRepository) ExportProducts(ctx context.Context, categoryIDs []string, fn func([]Product) error) error {
+	res, err := esapi.OpenPointInTimeRequest{
+		Index:     []string{productIndex},
+		KeepAlive: "1m",
+	}.Do(ctx, r.client)
+	if err != nil {
+		return err
+	}
+	if res.IsError() {
+		err := fmt.Errorf("error exporting products: %s", res.String())
+		res.Body.Close()
+		return err
+	}
+	var pit struct {
+		ID string `json:"id"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&pit)
+	res.Body.Close()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		body, _ := json.Marshal(map[string]string{"id": pit.ID})
+		res, err := esapi.ClosePointInTimeRequest{Body: bytes.NewReader(body)}.Do(context.Background(), r.client)
+		if err == nil {
+			res.Body.Close()
+		}
+	}()
+
+	var after json.RawMessage
+	for {
+		start := time.Now()
+		body := map[string]interface{}{
+			"size":                exportBatchSize,
+			"seq_no_primary_term": true,
+			"pit":                 map[string]interface{}{"id": pit.ID, "keep_alive": "1m"},
+			"sort":                []interface{}{map[string]interface{}{"_shard_doc": "asc"}},
+			"query": listed(map[string]interface{}{
+				"match_all": map[string]interface{}{},
+			}, categoryIDs),
+		}
+		if after != nil {
+			body["search_after"] = after
+		}
+		var buf bytes.Buffer
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return err
+		}
+		res, err := esapi.SearchRequest{Body: &buf}.Do(ctx, r.client)
+		if r.metrics != nil {
+			r.metrics.RecordDBQuery("export", productIndex, time.Since(start))
+		}
+		if err != nil {
+			return err
+		}
+		if res.IsError() {
+			err := fmt.Errorf("error exporting products: %s", res.String())
+			res.Body.Close()
+			return err
+		}
+		var page struct {
+			esSearchResponse
+			PitID string `json:"pit_id"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&page)
+		res.Body.Close()
+		if err != nil {
+			return err
+		}
+		if len(page.Hits.Hits) == 0 {
+			return nil
+		}
+		if err := fn(page.products()); err != nil {
+			return err
+		}
+		pit.ID = page.PitID
+		after = page.Hits.Hits[len(page.Hits.Hits)-1].Sort
+	}
+}
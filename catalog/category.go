@@ -0,0 +1,448 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/elastic/go-elasticsearch/v9/esapi"
+	"github.com/master-wayne7/go-microservices/events"
+	"github.com/segmentio/ksuid"
+)
+
+const categoryIndex = "categories"
+
+// maxCategories bounds how many categories a single read returns. The
+// taxonomy is expected to stay far smaller.
+const maxCategories = 10000
+
+var (
+	ErrCategoryNotFound = errors.New("category not found")
+	ErrInvalidCategory  = errors.New("invalid category")
+	ErrCategoryNotEmpty = errors.New("category has subcategories")
+)
+
+// Category is a node of the category tree. Products can be in any number of
+// categories; filtering by a category also matches its subcategories.
+type Category struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	ParentID string `json:"parentId,omitempty"`
+	// Path lists the IDs from the root category down to this one.
+	Path []string `json:"path"`
+	// Attributes defines attributes of the products in the category, on top
+	// of those its ancestors define.
+	Attributes []AttributeDefinition `json:"attributes,omitempty"`
+}
+
+// CreateCategory implements Service. An empty parentID creates a root
+// category.
+func (c *catalogService) CreateCategory(ctx context.Context, name, parentID string) (*Category, error) {
+	name, err := normalizeCategoryName(name)
+	if err != nil {
+		return nil, err
+	}
+	cat := Category{ID: ksuid.New().String(), Name: name, ParentID: parentID}
+	if parentID != "" {
+		parent, err := c.repository.GetCategory(ctx, parentID)
+		if err != nil {
+			return nil, err
+		}
+		cat.Path = append(cat.Path, parent.Path...)
+	}
+	cat.Path = append(cat.Path, cat.ID)
+	if err := c.repository.PutCategory(ctx, cat); err != nil {
+		return nil, err
+	}
+	return &cat, nil
+}
+
+// RenameCategory implements Service.
+func (c *catalogService) RenameCategory(ctx context.Context, id, name string) (*Category, error) {
+	name, err := normalizeCategoryName(name)
+	if err != nil {
+		return nil, err
+	}
+	cat, err := c.repository.GetCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	cat.Name = name
+	if err := c.repository.PutCategory(ctx, *cat); err != nil {
+		return nil, err
+	}
+	return cat, nil
+}
+
+// MoveCategory implements Service. The category keeps its subcategories and
+// products. An empty parentID makes it a root category. The new ancestors
+// cannot define attributes the category or its subcategories define.
+func (c *catalogService) MoveCategory(ctx context.Context, id, parentID string) (*Category, error) {
+	cat, err := c.repository.GetCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if cat.ParentID == parentID {
+		return cat, nil
+	}
+	var prefix []string
+	if parentID != "" {
+		parent, err := c.repository.GetCategory(ctx, parentID)
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(parent.Path, id) {
+			return nil, fmt.Errorf("%w: cannot move %s below itself", ErrInvalidCategory, id)
+		}
+		prefix = parent.Path
+		ancestors, err := c.repository.ListCategoriesWithIDs(ctx, prefix)
+		if err != nil {
+			return nil, err
+		}
+		subtree, err := c.repository.ListCategorySubtree(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if err := redefined(ancestors, subtree); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.repository.MoveCategory(ctx, id, parentID, prefix); err != nil {
+		return nil, err
+	}
+	cat.ParentID = parentID
+	cat.Path = append(slices.Clone(prefix), id)
+	return cat, nil
+}
+
+// DeleteCategory implements Service. Only categories without subcategories
+// can be deleted. Their products are taken out of them one by one, each with
+// a ProductUpdated event, so a failed delete can simply be retried.
+func (c *catalogService) DeleteCategory(ctx context.Context, id string) (*Category, error) {
+	cat, err := c.repository.GetCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	subtree, err := c.repository.ListCategorySubtree(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(subtree) > 1 {
+		return nil, fmt.Errorf("%w: %s", ErrCategoryNotEmpty, id)
+	}
+
+	var productIDs []string
+	err = c.repository.ExportProducts(ctx, []string{id}, func(products []Product) error {
+		for _, p := range products {
+			productIDs = append(productIDs, p.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, productID := range productIDs {
+		_, err := c.change(ctx, productID, Version{}, events.ProductUpdated, func(p *Product) error {
+			p.CategoryIDs = slices.DeleteFunc(p.CategoryIDs, func(categoryID string) bool { return categoryID == id })
+			return nil
+		})
+		// Products deleted in the meantime need no event.
+		if err != nil && !errors.Is(err, ErrDeleted) && !errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("taking product %s out of category %s: %w", productID, id, err)
+		}
+	}
+	if err := c.repository.DeleteCategory(ctx, id); err != nil {
+		return nil, err
+	}
+	return cat, nil
+}
+
+// GetCategories implements Service.
+func (c *catalogService) GetCategories(ctx context.Context) ([]Category, error) {
+	return c.repository.ListCategories(ctx)
+}
+
+// categoryFilter returns the IDs of category and its subcategories, or nil
+// when category is empty.
+func (c *catalogService) categoryFilter(ctx context.Context, category string) ([]string, error) {
+	if category == "" {
+		return nil, nil
+	}
+	subtree, err := c.repository.ListCategorySubtree(ctx, category)
+	if err != nil {
+		return nil, err
+	}
+	if len(subtree) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrCategoryNotFound, category)
+	}
+	ids := make([]string, 0, len(subtree))
+	for _, cat := range subtree {
+		ids = append(ids, cat.ID)
+	}
+	return ids, nil
+}
+
+// checkCategories deduplicates the category IDs of p and fails unless they
+// all exist. It then checks the attributes of p against the definitions of
+// its categories.
+func (c *catalogService) checkCategories(ctx context.Context, p *Product) error {
+	if len(p.CategoryIDs) == 0 {
+		p.CategoryIDs = nil
+		return nil
+	}
+	p.CategoryIDs = slices.Compact(slices.Sorted(slices.Values(p.CategoryIDs)))
+	byID, err := c.categoriesWithAncestors(ctx, p.CategoryIDs)
+	if err != nil {
+		return err
+	}
+	for _, id := range p.CategoryIDs {
+		if _, ok := byID[id]; !ok {
+			return fmt.Errorf("%w: unknown category %s", ErrInvalidProduct, id)
+		}
+	}
+	schema, err := attributeSchema(p.CategoryIDs, byID)
+	if err != nil {
+		return err
+	}
+	return applySchema(p, schema)
+}
+
+func normalizeCategoryName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > MaxNameLength {
+		return "", fmt.Errorf("%w: name must have 1 to %d characters", ErrInvalidCategory, MaxNameLength)
+	}
+	return name, nil
+}
+
+type categoryDocument struct {
+	Name       string                `json:"name"`
+	ParentID   string                `json:"parent_id"`
+	Path       []string              `json:"path"`
+	Attributes []AttributeDefinition `json:"attributes,omitempty"`
+}
+
+func (d categoryDocument) category(id string) Category {
+	return Category{ID: id, Name: d.Name, ParentID: d.ParentID, Path: d.Path, Attributes: d.Attributes}
+}
+
+// PutCategory implements Repository.
+func (r *elasticRepository) PutCategory(ctx context.Context, c Category) error {
+	start := time.Now()
+	body, err := json.Marshal(categoryDocument{Name: c.Name, ParentID: c.ParentID, Path: c.Path, Attributes: c.Attributes})
+	if err != nil {
+		return err
+	}
+	res, err := esapi.IndexRequest{
+		Index:      categoryIndex,
+		DocumentID: c.ID,
+		Body:       bytes.NewReader(body),
+		Refresh:    "true",
+	}.Do(ctx, r.client)
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("index", categoryIndex, time.Since(start))
+	}
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("error indexing category %s: %s", c.ID, res.String())
+	}
+	return nil
+}
+
+// GetCategory implements Repository.
+func (r *elasticRepository) GetCategory(ctx context.Context, id string) (*Category, error) {
+	start := time.Now()
+	res, err := esapi.GetRequest{
+		Index:      categoryIndex,
+		DocumentID: id,
+	}.Do(ctx, r.client)
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("get", categoryIndex, time.Since(start))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrCategoryNotFound, id)
+	}
+	if res.IsError() {
+		return nil, fmt.Errorf("error getting category %s: %s", id, res.String())
+	}
+	var doc struct {
+		Source categoryDocument `json:"_source"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&doc); err != nil {
+		return nil, err
+	}
+	c := doc.Source.category(id)
+	return &c, nil
+}
+
+// ListCategories implements Repository. Categories are ordered by name.
+func (r *elasticRepository) ListCategories(ctx context.Context) ([]Category, error) {
+	return r.searchCategories(ctx, map[string]interface{}{
+		"match_all": map[string]interface{}{},
+	})
+}
+
+// ListCategoriesWithIDs implements Repository.
+func (r *elasticRepository) ListCategoriesWithIDs(ctx context.Context, ids []string) ([]Category, error) {
+	return r.searchCategories(ctx, map[string]interface{}{
+		"ids": map[string]interface{}{"values": ids},
+	})
+}
+
+// ListCategorySubtree implements Repository. It returns the category id and
+// all of its descendants, or nothing if id does not exist.
+func (r *elasticRepository) ListCategorySubtree(ctx context.Context, id string) ([]Category, error) {
+	return r.searchCategories(ctx, map[string]interface{}{
+		"term": map[string]interface{}{"path.keyword": id},
+	})
+}
+
+func (r *elasticRepository) searchCategories(ctx context.Context, query map[string]interface{}) ([]Category, error) {
+	start := time.Now()
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(map[string]interface{}{
+		"size":  maxCategories,
+		"query": query,
+		"sort": []interface{}{map[string]interface{}{
+			"name.keyword": map[string]interface{}{"order": "asc", "unmapped_type": "keyword"},
+		}},
+	}); err != nil {
+		return nil, err
+	}
+	res, err := esapi.SearchRequest{
+		Index: []string{categoryIndex},
+		Body:  &buf,
+	}.Do(ctx, r.client)
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("search", categoryIndex, time.Since(start))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	// The service creates the index on startup, but it may have been deleted.
+	if res.StatusCode == http.StatusNotFound {
+		return []Category{}, nil
+	}
+	if res.IsError() {
+		return nil, fmt.Errorf("error searching categories: %s", res.String())
+	}
+
+	var esResp esSearchResponse
+	if err := json.NewDecoder(res.Body).Decode(&esResp); err != nil {
+		return nil, err
+	}
+	categories := make([]Category, 0, len(esResp.Hits.Hits))
+	for _, hit := range esResp.Hits.Hits {
+		var doc categoryDocument
+		if err := json.Unmarshal(hit.Source, &doc); err != nil {
+			return nil, err
+		}
+		categories = append(categories, doc.category(hit.ID))
+	}
+	return categories, nil
+}
+
+// MoveCategory implements Repository. It puts id below parentID, whose path
+// is prefix, and rewrites the paths of id and all its descendants.
+func (r *elasticRepository) MoveCategory(ctx context.Context, id, parentID string, prefix []string) error {
+	if prefix == nil {
+		prefix = []string{}
+	}
+	return r.updateByQuery(ctx, categoryIndex, map[string]interface{}{
+		"query": map[string]interface{}{
+			"term": map[string]interface{}{"path.keyword": id},
+		},
+		"script": map[string]interface{}{
+			"lang": "painless",
+			"source": `List path = new ArrayList(params.prefix);
+				List old = ctx._source.path;
+				path.addAll(old.subList(old.indexOf(params.id), old.size()));
+				ctx._source.path = path;
+				if (ctx._id == params.id) { ctx._source.parent_id = params.parent_id }`,
+			"params": map[string]interface{}{
+				"id":        id,
+				"parent_id": parentID,
+				"prefix":    prefix,
+			},
+		},
+	})
+}
+
+// DeleteCategory implements Repository. It first takes the category out of
+// the products still in it, which are the deleted ones once the service has
+// updated the others, so a failed delete can simply be retried.
+func (r *elasticRepository) DeleteCategory(ctx context.Context, id string) error {
+	err := r.updateByQuery(ctx, productWriteAlias, map[string]interface{}{
+		"query": map[string]interface{}{
+			"term": map[string]interface{}{"category_ids": id},
+		},
+		"script": map[string]interface{}{
+			"lang": "painless",
+			"source": `ctx._source.category_ids.removeIf(c -> c == params.id);
+				ctx._source.updated_at = params.updated_at`,
+			"params": map[string]interface{}{"id": id, "updated_at": time.Now().UTC()},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	res, err := esapi.DeleteRequest{
+		Index:      categoryIndex,
+		DocumentID: id,
+		Refresh:    "true",
+	}.Do(ctx, r.client)
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("delete", categoryIndex, time.Since(start))
+	}
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.IsError() && res.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("error deleting category %s: %s", id, res.String())
+	}
+	return nil
+}
+
+func (r *elasticRepository) updateByQuery(ctx context.Context, index string, body map[string]interface{}) error {
+	start := time.Now()
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return err
+	}
+	refresh := true
+	res, err := esapi.UpdateByQueryRequest{
+		Index:   []string{index},
+		Body:    &buf,
+		Refresh: &refresh,
+	}.Do(ctx, r.client)
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("update_by_query", index, time.Since(start))
+	}
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	// Nothing to update before the index was created.
+	if res.IsError() && res.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("error updating %s: %s", index, res.String())
+	}
+	return nil
+}
@@ -2,10 +2,16 @@ package catalog
 
 import (
 	"context"
+	"io"
+	"iter"
 
+	"github.com/master-wayne7/go-microservices/auth"
 	"github.com/master-wayne7/go-microservices/catalog/pb"
+	"github.com/master-wayne7/go-microservices/money"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
 type Client struct {
@@ -14,7 +20,13 @@ type Client struct {
 }
 
 func NewClient(url string) (*Client, error) {
-	conn, err := grpc.Dial(url, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	// Calls carry the caller's identity from the context to the service.
+	conn, err := grpc.Dial(
+		url,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(auth.UnaryClientInterceptor()),
+		grpc.WithStreamInterceptor(auth.StreamClientInterceptor()),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -27,24 +39,26 @@ func (c *Client) Close() {
 	c.conn.Close()
 }
 
-func (c *Client) PostProduct(ctx context.Context, name, description string, price float64) (*Product, error) {
+// PostProduct creates a product. A non-empty idempotencyKey makes retries
+// return the product created by the first attempt.
+func (c *Client) PostProduct(ctx context.Context, name, description string, price money.Money, categoryIDs []string, attributes map[string]string, variants []Variant, idempotencyKey string) (*Product, error) {
 	r, err := c.service.PostProduct(
 		ctx,
 		&pb.PostProductRequest{
-			Name:        name,
-			Description: description,
-			Price:       price,
+			Name:           name,
+			Description:    description,
+			PriceMoney:     &pb.Money{Amount: price.Amount, Currency: price.Currency},
+			IdempotencyKey: idempotencyKey,
+			CategoryIds:    categoryIDs,
+			Attributes:     attributes,
+			Variants:       variantsToProto(variants),
 		},
 	)
 	if err != nil {
 		return nil, err
 	}
-	return &Product{
-		ID:          r.Product.Id,
-		Name:        r.Product.Name,
-		Price:       r.Product.Price,
-		Description: r.Product.Description,
-	}, nil
+	p := productFromProto(r.Product)
+	return &p, nil
 }
 
 func (c *Client) GetProduct(ctx context.Context, id string) (*Product, error) {
@@ -57,22 +71,48 @@ func (c *Client) GetProduct(ctx context.Context, id string) (*Product, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Product{
-		ID:          r.Product.Id,
-		Name:        r.Product.Name,
-		Price:       r.Product.Price,
-		Description: r.Product.Description,
-	}, nil
+	p := productFromProto(r.Product)
+	return &p, nil
 }
 
-func (c *Client) GetProducts(ctx context.Context, skip, take uint64, query string, ids []string) ([]Product, error) {
+// GetProductBySKU returns the product with the variant sku, or the product
+// without variants whose ID is sku.
+func (c *Client) GetProductBySKU(ctx context.Context, sku string) (*Product, error) {
+	r, err := c.service.GetProduct(ctx, &pb.GetProductRequest{Sku: sku})
+	if err != nil {
+		return nil, err
+	}
+	p := productFromProto(r.Product)
+	return &p, nil
+}
+
+// GetProductsBySKUs returns the products having any of skus, deleted ones
+// included. Use Product.Variant to find the ordered variant.
+func (c *Client) GetProductsBySKUs(ctx context.Context, skus []string) ([]Product, error) {
+	r, err := c.service.GetProducts(ctx, &pb.GetProductsRequest{Skus: skus})
+	if err != nil {
+		return nil, err
+	}
+	products := make([]Product, 0, len(r.Products))
+	for _, p := range r.Products {
+		products = append(products, productFromProto(p))
+	}
+	return products, nil
+}
+
+// GetProducts returns the products ids, or lists or searches for query
+// when ids is empty. A non-empty category restricts listings and searches to
+// that category and its subcategories, and sort orders them.
+func (c *Client) GetProducts(ctx context.Context, skip, take uint64, query string, ids []string, category string, sort ProductSort) ([]Product, error) {
 	r, err := c.service.GetProducts(
 		ctx,
 		&pb.GetProductsRequest{
-			Take:  take,
-			Skip:  skip,
-			Query: query,
-			Ids:   ids,
+			Take:       take,
+			Skip:       skip,
+			Query:      query,
+			Ids:        ids,
+			CategoryId: category,
+			Sort:       string(sort),
 		},
 	)
 	if err != nil {
@@ -81,12 +121,349 @@ func (c *Client) GetProducts(ctx context.Context, skip, take uint64, query strin
 
 	products := make([]Product, 0)
 	for _, p := range r.Products {
-		products = append(products, Product{
-			ID:          p.Id,
-			Name:        p.Name,
-			Price:       p.Price,
-			Description: p.Description,
-		})
+		products = append(products, productFromProto(p))
 	}
 	return products, nil
 }
+
+// UpdateProduct replaces the name, description, price, categories and
+// attributes of a product. A zero version skips the concurrency check.
+func (c *Client) UpdateProduct(ctx context.Context, id, name, description string, price money.Money, categoryIDs []string, attributes map[string]string, version Version) (*Product, error) {
+	r, err := c.service.UpdateProduct(
+		ctx,
+		&pb.UpdateProductRequest{
+			Id:          id,
+			Name:        name,
+			Description: description,
+			PriceMoney:  &pb.Money{Amount: price.Amount, Currency: price.Currency},
+			Version:     versionToProto(version),
+			CategoryIds: categoryIDs,
+			Attributes:  attributes,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	p := productFromProto(r.Product)
+	return &p, nil
+}
+
+// PatchProduct sets fields (FieldName, FieldDescription, FieldPrice,
+// FieldCategories, FieldAttributes, FieldVariants) to their values in patch. A zero version
+// skips the concurrency check.
+func (c *Client) PatchProduct(ctx context.Context, id string, patch ProductPatch, fields []string, version Version) (*Product, error) {
+	paths := make([]string, 0, len(fields))
+	for _, field := range fields {
+		switch field {
+		case FieldPrice:
+			field = "priceMoney"
+		case FieldCategories:
+			field = "categoryIds"
+		}
+		paths = append(paths, field)
+	}
+	r, err := c.service.PatchProduct(
+		ctx,
+		&pb.PatchProductRequest{
+			Id: id,
+			Product: &pb.Product{
+				Name:        patch.Name,
+				Description: patch.Description,
+				PriceMoney:  &pb.Money{Amount: patch.Price.Amount, Currency: patch.Price.Currency},
+				CategoryIds: patch.CategoryIDs,
+				Attributes:  patch.Attributes,
+				Variants:    variantsToProto(patch.Variants),
+			},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: paths},
+			Version:    versionToProto(version),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	p := productFromProto(r.Product)
+	return &p, nil
+}
+
+// DeleteProduct soft-deletes a product. A zero version skips the
+// concurrency check.
+func (c *Client) DeleteProduct(ctx context.Context, id string, version Version) (*Product, error) {
+	r, err := c.service.DeleteProduct(
+		ctx,
+		&pb.DeleteProductRequest{
+			Id:      id,
+			Version: versionToProto(version),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	p := productFromProto(r.Product)
+	return &p, nil
+}
+
+// SearchProducts runs a structured search and returns a page of products
+// with the total number of matches and facets.
+func (c *Client) SearchProducts(ctx context.Context, q SearchQuery) (*SearchResult, error) {
+	req := &pb.SearchProductsRequest{
+		Query:      q.Text,
+		Currency:   q.Currency,
+		CategoryId: q.Category,
+		Sort:       string(q.Sort),
+		Skip:       q.Skip,
+		Take:       q.Take,
+	}
+	if q.MinPrice != nil {
+		req.MinPrice = &pb.Money{Amount: q.MinPrice.Amount, Currency: q.MinPrice.Currency}
+	}
+	if q.MaxPrice != nil {
+		req.MaxPrice = &pb.Money{Amount: q.MaxPrice.Amount, Currency: q.MaxPrice.Currency}
+	}
+	for name, values := range q.Attributes {
+		req.Attributes = append(req.Attributes, &pb.AttributeFilter{Name: name, Values: values})
+	}
+	for name, r := range q.AttributeRanges {
+		req.AttributeRanges = append(req.AttributeRanges, &pb.AttributeRange{Name: name, Min: r.Min, Max: r.Max})
+	}
+	r, err := c.service.SearchProducts(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SearchResult{
+		Products:   make([]Product, 0, len(r.Products)),
+		Total:      r.Total,
+		Highlights: map[string][]Highlight{},
+	}
+	for _, p := range r.Products {
+		result.Products = append(result.Products, productFromProto(p))
+	}
+	facets := r.GetFacets()
+	result.Facets.Categories = facetValuesFromProto(facets.GetCategories())
+	for _, f := range facets.GetPriceRanges() {
+		priceRange := PriceRangeFacet{Count: f.Count}
+		if f.Min != nil {
+			min := moneyFromProto(f.Min, 0)
+			priceRange.Min = &min
+		}
+		if f.Max != nil {
+			max := moneyFromProto(f.Max, 0)
+			priceRange.Max = &max
+		}
+		result.Facets.PriceRanges = append(result.Facets.PriceRanges, priceRange)
+	}
+	for _, f := range facets.GetAttributes() {
+		result.Facets.Attributes = append(result.Facets.Attributes, AttributeFacet{
+			Name:   f.Name,
+			Values: facetValuesFromProto(f.Values),
+		})
+	}
+	for _, h := range r.Highlights {
+		result.Highlights[h.ProductId] = append(result.Highlights[h.ProductId], Highlight{Field: h.Field, Fragments: h.Fragments})
+	}
+	return result, nil
+}
+
+// BulkImportProducts streams rows to the catalog, which imports them in
+// batches. Rows that fail are reported in the result; the others are
+// imported regardless. If a batch fails as a whole, the earlier batches stay
+// imported: the error then comes with the result up to that batch.
+func (c *Client) BulkImportProducts(ctx context.Context, rows iter.Seq[ImportRow]) (*ImportResult, error) {
+	stream, err := c.service.BulkImportProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for row := range rows {
+		err := stream.Send(&pb.BulkImportProductsRequest{Row: row.Row, Product: productToProto(row.Product)})
+		if err == io.EOF {
+			// The server failed; CloseAndRecv returns why.
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	r, err := stream.CloseAndRecv()
+	if err != nil {
+		for _, detail := range status.Convert(err).Details() {
+			if partial, ok := detail.(*pb.BulkImportProductsResponse); ok {
+				return importResultFromProto(partial), err
+			}
+		}
+		return nil, err
+	}
+	return importResultFromProto(r), nil
+}
+
+func importResultFromProto(r *pb.BulkImportProductsResponse) *ImportResult {
+	result := &ImportResult{Imported: r.Imported, Errors: make([]ImportError, 0, len(r.Errors))}
+	for _, e := range r.Errors {
+		result.Errors = append(result.Errors, ImportError{Row: e.Row, ProductID: e.ProductId, Message: e.Message})
+	}
+	return result
+}
+
+// ExportProducts passes all products that are not deleted to fn, page by
+// page. A non-empty category restricts them to that category and its
+// subcategories.
+func (c *Client) ExportProducts(ctx context.Context, category string, fn func([]Product) error) error {
+	stream, err := c.service.ExportProducts(ctx, &pb.ExportProductsRequest{CategoryId: category})
+	if err != nil {
+		return err
+	}
+	for {
+		r, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		products := make([]Product, 0, len(r.Products))
+		for _, p := range r.Products {
+			products = append(products, productFromProto(p))
+		}
+		if err := fn(products); err != nil {
+			return err
+		}
+	}
+}
+
+// Suggest returns up to size products whose names match the words typed so
+// far. A size of zero returns the default number of suggestions.
+func (c *Client) Suggest(ctx context.Context, prefix string, size int) ([]Suggestion, error) {
+	r, err := c.service.Suggest(ctx, &pb.SuggestRequest{Prefix: prefix, Size: uint32(size)})
+	if err != nil {
+		return nil, err
+	}
+	suggestions := make([]Suggestion, 0, len(r.Suggestions))
+	for _, s := range r.Suggestions {
+		suggestions = append(suggestions, Suggestion{ID: s.ProductId, Name: s.Name})
+	}
+	return suggestions, nil
+}
+
+func facetValuesFromProto(values []*pb.FacetValue) []FacetValue {
+	result := make([]FacetValue, 0, len(values))
+	for _, v := range values {
+		result = append(result, FacetValue{Value: v.Value, Count: v.Count})
+	}
+	return result
+}
+
+// CreateCategory creates a category below parentID, or a root category if
+// parentID is empty.
+func (c *Client) CreateCategory(ctx context.Context, name, parentID string) (*Category, error) {
+	r, err := c.service.CreateCategory(ctx, &pb.CreateCategoryRequest{Name: name, ParentId: parentID})
+	if err != nil {
+		return nil, err
+	}
+	cat := categoryFromProto(r.Category)
+	return &cat, nil
+}
+
+func (c *Client) RenameCategory(ctx context.Context, id, name string) (*Category, error) {
+	r, err := c.service.RenameCategory(ctx, &pb.RenameCategoryRequest{Id: id, Name: name})
+	if err != nil {
+		return nil, err
+	}
+	cat := categoryFromProto(r.Category)
+	return &cat, nil
+}
+
+// MoveCategory moves a category and its subcategories below parentID, or
+// to the root if parentID is empty.
+func (c *Client) MoveCategory(ctx context.Context, id, parentID string) (*Category, error) {
+	r, err := c.service.MoveCategory(ctx, &pb.MoveCategoryRequest{Id: id, ParentId: parentID})
+	if err != nil {
+		return nil, err
+	}
+	cat := categoryFromProto(r.Category)
+	return &cat, nil
+}
+
+func (c *Client) DeleteCategory(ctx context.Context, id string) (*Category, error) {
+	r, err := c.service.DeleteCategory(ctx, &pb.DeleteCategoryRequest{Id: id})
+	if err != nil {
+		return nil, err
+	}
+	cat := categoryFromProto(r.Category)
+	return &cat, nil
+}
+
+// SetCategoryAttributes replaces the attribute definitions of a category.
+func (c *Client) SetCategoryAttributes(ctx context.Context, id string, attributes []AttributeDefinition) (*Category, error) {
+	r, err := c.service.SetCategoryAttributes(ctx, &pb.SetCategoryAttributesRequest{Id: id, Attributes: attributeDefinitionsToProto(attributes)})
+	if err != nil {
+		return nil, err
+	}
+	cat := categoryFromProto(r.Category)
+	return &cat, nil
+}
+
+// GetCategories returns every category, ordered by name.
+func (c *Client) GetCategories(ctx context.Context) ([]Category, error) {
+	r, err := c.service.GetCategories(ctx, &pb.GetCategoriesRequest{})
+	if err != nil {
+		return nil, err
+	}
+	categories := make([]Category, 0, len(r.Categories))
+	for _, cat := range r.Categories {
+		categories = append(categories, categoryFromProto(cat))
+	}
+	return categories, nil
+}
+
+func categoryFromProto(c *pb.Category) Category {
+	category := Category{
+		ID:       c.Id,
+		Name:     c.Name,
+		ParentID: c.ParentId,
+		Path:     c.Path,
+	}
+	if len(c.Attributes) > 0 {
+		category.Attributes = attributeDefinitionsFromProto(c.Attributes)
+	}
+	return category
+}
+
+func productFromProto(p *pb.Product) Product {
+	product := Product{
+		ID:          p.Id,
+		Name:        p.Name,
+		Price:       moneyFromProto(p.PriceMoney, p.Price),
+		Description: p.Description,
+		Version:     versionFromProto(p.Version),
+		CategoryIDs: p.CategoryIds,
+		Attributes:  p.Attributes,
+		Variants:    variantsFromProto(p.Variants),
+	}
+	if len(p.CreatedAt) > 0 {
+		product.CreatedAt.UnmarshalBinary(p.CreatedAt)
+	}
+	if len(p.DeletedAt) > 0 {
+		product.DeletedAt.UnmarshalBinary(p.DeletedAt)
+	}
+	return product
+}
+
+// variantsToProto leaves out zero prices, which stand for the product's
+// price.
+func variantsToProto(variants []Variant) []*pb.Variant {
+	result := make([]*pb.Variant, 0, len(variants))
+	for _, v := range variants {
+		variant := &pb.Variant{Sku: v.SKU, Attributes: v.Attributes}
+		if !v.Price.IsZero() || v.Price.Currency != "" {
+			variant.Price = &pb.Money{Amount: v.Price.Amount, Currency: v.Price.Currency}
+		}
+		result = append(result, variant)
+	}
+	return result
+}
+
+func versionToProto(v Version) *pb.Version {
+	if v.IsZero() {
+		return nil
+	}
+	return &pb.Version{SeqNo: v.SeqNo, PrimaryTerm: v.PrimaryTerm}
+}
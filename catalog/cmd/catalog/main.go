@@ -6,13 +6,21 @@ import (
 	"time"
 
 	"github.com/kelseyhightower/envconfig"
+	"github.com/master-wayne7/go-microservices/auth"
 	"github.com/master-wayne7/go-microservices/catalog"
+	"github.com/master-wayne7/go-microservices/events"
 	"github.com/master-wayne7/go-microservices/monitoring"
 	"github.com/tinrab/retry"
 )
 
 type Config struct {
 	DatabaseURL string `envconfig:"DATABASE_URL"`
+	// Where outbox events are published, e.g. file:///var/lib/events/catalog.ndjson
+	EventBrokerURL string `envconfig:"EVENT_BROKER_URL"`
+	// How often the outbox is relayed to the broker
+	EventRelayInterval time.Duration `envconfig:"EVENT_RELAY_INTERVAL" default:"5s"`
+	// Verifies bearer tokens of callers and signs the service's own calls
+	TokenSecret string `envconfig:"AUTH_TOKEN_SECRET" required:"true"`
 }
 
 // Health check handler for container orchestration
@@ -58,8 +66,22 @@ func main() {
 	// Start system metrics collection (no DB handle for ES)
 	metrics.StartSystemMetricsCollection(nil)
 
+	// Relay outbox events to the configured broker
+	broker, err := events.NewBroker(cfg.EventBrokerURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer broker.Close()
+	events.StartRelay(r, broker, cfg.EventRelayInterval)
+
+	signer, err := auth.NewSigner(cfg.TokenSecret)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// Changed port from 8080 to 8083 to avoid conflicts
 	log.Println("Listening on port 8083...")
 	s := catalog.NewService(r)
-	log.Fatal(catalog.ListenGRPC(s, 8083, metrics))
+	// The repository also stores idempotency keys for PostProduct
+	log.Fatal(catalog.ListenGRPC(s, r, signer, 8083, metrics))
 }
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/master-wayne7/go-microservices/catalog"
+	"github.com/master-wayne7/go-microservices/money"
+)
+
+const (
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+
+	// maxLineLength bounds an NDJSON line, which holds one product.
+	maxLineLength = 1 << 20
+)
+
+// csvColumns is the header of exported CSV files. Imported files need name
+// and price columns; the others are optional and may come in any order.
+var csvColumns = []string{"id", "name", "description", "price", "currency", "category_ids", "attributes", "variants"}
+
+// record is a product as it is written to files. Prices are decimal strings
+// in their currency, like "19.99".
+type record struct {
+	ID          string            `json:"id,omitempty"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Price       string            `json:"price"`
+	Currency    string            `json:"currency"`
+	CategoryIDs []string          `json:"categoryIds,omitempty"`
+	Attributes  map[string]string `json:"attributes,omitempty"`
+	Variants    []variantRecord   `json:"variants,omitempty"`
+}
+
+// variantRecord is a variant as it is written to files. Its price is in the
+// currency of the product; an empty price means the product's price.
+type variantRecord struct {
+	SKU        string            `json:"sku"`
+	Attributes map[string]string `json:"attributes"`
+	Price      string            `json:"price,omitempty"`
+}
+
+func newRecord(p catalog.Product) record {
+	r := record{
+		ID:          p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		Price:       p.Price.String(),
+		Currency:    p.Price.Currency,
+		CategoryIDs: p.CategoryIDs,
+		Attributes:  p.Attributes,
+	}
+	for _, v := range p.Variants {
+		r.Variants = append(r.Variants, variantRecord{SKU: v.SKU, Attributes: v.Attributes, Price: v.Price.String()})
+	}
+	return r
+}
+
+func (r record) product() (catalog.Product, error) {
+	price, err := money.Parse(r.Price, r.Currency)
+	if err != nil {
+		return catalog.Product{}, err
+	}
+	p := catalog.Product{
+		ID:          r.ID,
+		Name:        r.Name,
+		Description: r.Description,
+		Price:       price,
+		CategoryIDs: r.CategoryIDs,
+		Attributes:  r.Attributes,
+	}
+	for _, v := range r.Variants {
+		variant := catalog.Variant{SKU: v.SKU, Attributes: v.Attributes}
+		if v.Price != "" {
+			if variant.Price, err = money.Parse(v.Price, price.Currency); err != nil {
+				return catalog.Product{}, fmt.Errorf("variant %s: %w", v.SKU, err)
+			}
+		}
+		p.Variants = append(p.Variants, variant)
+	}
+	return p, nil
+}
+
+// formatOf picks the format from the flag, or else from the file extension.
+// Standard input and output default to NDJSON.
+func formatOf(flagValue, path string) (string, error) {
+	format := strings.ToLower(flagValue)
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv":
+			format = formatCSV
+		default:
+			format = formatNDJSON
+		}
+	}
+	if format != formatCSV && format != formatNDJSON {
+		return "", fmt.Errorf("unknown format %q, use %s or %s", flagValue, formatCSV, formatNDJSON)
+	}
+	return format, nil
+}
+
+// reader reads records one by one. Errors of single records are returned
+// with their row, which is the line they start on; read errors with row 0.
+type reader interface {
+	next() (row uint64, r record, err error)
+}
+
+func newReader(format string, in io.Reader) (reader, error) {
+	if format == formatCSV {
+		return newCSVReader(in)
+	}
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(nil, maxLineLength)
+	return &ndjsonReader{scanner: scanner}, nil
+}
+
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+	line    uint64
+}
+
+func (n *ndjsonReader) next() (uint64, record, error) {
+	for n.scanner.Scan() {
+		n.line++
+		line := strings.TrimSpace(n.scanner.Text())
+		if line == "" {
+			continue
+		}
+		var r record
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			return n.line, record{}, err
+		}
+		return n.line, r, nil
+	}
+	if err := n.scanner.Err(); err != nil {
+		return 0, record{}, err
+	}
+	return 0, record{}, io.EOF
+}
+
+type csvReader struct {
+	csv     *csv.Reader
+	columns map[string]int
+}
+
+func newCSVReader(in io.Reader) (*csvReader, error) {
+	c := &csvReader{csv: csv.NewReader(in), columns: map[string]int{}}
+	c.csv.FieldsPerRecord = -1
+	header, err := c.csv.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading CSV header: %w", err)
+	}
+	for i, column := range header {
+		c.columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	for _, required := range []string{"name", "price"} {
+		if _, ok := c.columns[required]; !ok {
+			return nil, fmt.Errorf("CSV header has no %s column", required)
+		}
+	}
+	return c, nil
+}
+
+func (c *csvReader) next() (uint64, record, error) {
+	fields, err := c.csv.Read()
+	if err == io.EOF {
+		return 0, record{}, io.EOF
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return uint64(parseErr.StartLine), record{}, err
+	}
+	if err != nil {
+		return 0, record{}, err
+	}
+	line, _ := c.csv.FieldPos(0)
+
+	field := func(name string) string {
+		i, ok := c.columns[name]
+		if !ok || i >= len(fields) {
+			return ""
+		}
+		return fields[i]
+	}
+	r := record{
+		ID:          field("id"),
+		Name:        field("name"),
+		Description: field("description"),
+		Price:       field("price"),
+		Currency:    field("currency"),
+	}
+	if ids := field("category_ids"); ids != "" {
+		r.CategoryIDs = strings.Split(ids, "|")
+	}
+	if attributes := field("attributes"); attributes != "" {
+		if err := json.Unmarshal([]byte(attributes), &r.Attributes); err != nil {
+			return uint64(line), record{}, fmt.Errorf("attributes must be a JSON object: %w", err)
+		}
+	}
+	if variants := field("variants"); variants != "" {
+		if err := json.Unmarshal([]byte(variants), &r.Variants); err != nil {
+			return uint64(line), record{}, fmt.Errorf("variants must be a JSON array: %w", err)
+		}
+	}
+	return uint64(line), r, nil
+}
+
+// writer writes records and has to be flushed at the end.
+type writer interface {
+	write(r record) error
+	flush() error
+}
+
+func newWriter(format string, out io.Writer) (writer, error) {
+	if format == formatCSV {
+		c := csv.NewWriter(out)
+		return &csvWriter{csv: c}, c.Write(csvColumns)
+	}
+	buf := bufio.NewWriter(out)
+	return &ndjsonWriter{buf: buf, enc: json.NewEncoder(buf)}, nil
+}
+
+type ndjsonWriter struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func (n *ndjsonWriter) write(r record) error {
+	return n.enc.Encode(r)
+}
+
+func (n *ndjsonWriter) flush() error {
+	return n.buf.Flush()
+}
+
+type csvWriter struct {
+	csv *csv.Writer
+}
+
+// write puts category IDs into one cell separated by "|", attributes as a
+// JSON object and variants as a JSON array, as their values may contain any
+// character.
+func (c *csvWriter) write(r record) error {
+	attributes, variants := "", ""
+	if len(r.Attributes) > 0 {
+		b, err := json.Marshal(r.Attributes)
+		if err != nil {
+			return err
+		}
+		attributes = string(b)
+	}
+	if len(r.Variants) > 0 {
+		b, err := json.Marshal(r.Variants)
+		if err != nil {
+			return err
+		}
+		variants = string(b)
+	}
+	return c.csv.Write([]string{
+		r.ID,
+		r.Name,
+		r.Description,
+		r.Price,
+		r.Currency,
+		strings.Join(r.CategoryIDs, "|"),
+		attributes,
+		variants,
+	})
+}
+
+func (c *csvWriter) flush() error {
+	c.csv.Flush()
+	return c.csv.Error()
+}
@@ -0,0 +1,203 @@
+// Command products imports products into the catalog from CSV or NDJSON
+// files and exports them to such files.
+//
+//	products import [-format csv|ndjson] FILE
+//	products export [-format csv|ndjson] [-category ID] [FILE]
+//
+// FILE "-" or no FILE means standard input or output. Without -format the
+// format follows the file extension, defaulting to NDJSON.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/kelseyhightower/envconfig"
+	"github.com/master-wayne7/go-microservices/auth"
+	"github.com/master-wayne7/go-microservices/catalog"
+)
+
+type Config struct {
+	CatalogURL string `envconfig:"CATALOG_SERVICE_URL" default:"localhost:8083"`
+	// Signs the calls, which the catalog only accepts from staff and
+	// services
+	TokenSecret string `envconfig:"AUTH_TOKEN_SECRET" required:"true"`
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: products import [-format csv|ndjson] FILE")
+	fmt.Fprintln(os.Stderr, "       products export [-format csv|ndjson] [-category ID] [FILE]")
+	os.Exit(2)
+}
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		usage()
+	}
+	var cfg Config
+	if err := envconfig.Process("", &cfg); err != nil {
+		log.Fatal(err)
+	}
+	signer, err := auth.NewSigner(cfg.TokenSecret)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx, err := auth.NewServiceIdentity(signer, "products-cli").Context(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+	client, err := catalog.NewClient(cfg.CatalogURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	flags := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	format := flags.String("format", "", "csv or ndjson")
+	switch os.Args[1] {
+	case "import":
+		flags.Parse(os.Args[2:])
+		if flags.NArg() != 1 {
+			usage()
+		}
+		err = importProducts(ctx, client, flags.Arg(0), *format)
+	case "export":
+		category := flags.String("category", "", "only export products in this category and its subcategories")
+		flags.Parse(os.Args[2:])
+		if flags.NArg() > 1 {
+			usage()
+		}
+		err = exportProducts(ctx, client, flags.Arg(0), *format, *category)
+	default:
+		usage()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// importProducts streams the products of path to the catalog and reports
+// every row that could not be read or imported. It fails if any did.
+func importProducts(ctx context.Context, client *catalog.Client, path, formatFlag string) error {
+	format, err := formatOf(formatFlag, path)
+	if err != nil {
+		return err
+	}
+	in := io.Reader(os.Stdin)
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	r, err := newReader(format, in)
+	if err != nil {
+		return err
+	}
+
+	var rowErrors []catalog.ImportError
+	var readErr error
+	rows := func(yield func(catalog.ImportRow) bool) {
+		for {
+			row, rec, err := r.next()
+			if err == io.EOF {
+				return
+			}
+			if err != nil && row == 0 {
+				readErr = err
+				return
+			}
+			var p catalog.Product
+			if err == nil {
+				p, err = rec.product()
+			}
+			if err != nil {
+				rowErrors = append(rowErrors, catalog.ImportError{Row: row, ProductID: rec.ID, Message: err.Error()})
+				continue
+			}
+			if !yield(catalog.ImportRow{Row: row, Product: p}) {
+				return
+			}
+		}
+	}
+	result, err := client.BulkImportProducts(ctx, rows)
+	if err != nil {
+		if result == nil {
+			return err
+		}
+		// Earlier batches were imported before the failure.
+		logRowErrors(append(rowErrors, result.Errors...))
+		return fmt.Errorf("importing %s: %w; %d products were imported before", path, err, result.Imported)
+	}
+	if readErr != nil {
+		return fmt.Errorf("reading %s: %w; %d products were imported before", path, readErr, result.Imported)
+	}
+
+	rowErrors = append(rowErrors, result.Errors...)
+	logRowErrors(rowErrors)
+	log.Printf("imported %d products", result.Imported)
+	if len(rowErrors) > 0 {
+		return fmt.Errorf("%d rows failed", len(rowErrors))
+	}
+	return nil
+}
+
+// logRowErrors logs rowErrors in row order.
+func logRowErrors(rowErrors []catalog.ImportError) {
+	sort.SliceStable(rowErrors, func(i, j int) bool { return rowErrors[i].Row < rowErrors[j].Row })
+	for _, e := range rowErrors {
+		if e.ProductID != "" {
+			log.Printf("row %d (%s): %s", e.Row, e.ProductID, e.Message)
+		} else {
+			log.Printf("row %d: %s", e.Row, e.Message)
+		}
+	}
+}
+
+// exportProducts writes all products that are not deleted to path.
+func exportProducts(ctx context.Context, client *catalog.Client, path, formatFlag, category string) error {
+	format, err := formatOf(formatFlag, path)
+	if err != nil {
+		return err
+	}
+	out := io.Writer(os.Stdout)
+	if path != "" && path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	w, err := newWriter(format, out)
+	if err != nil {
+		return err
+	}
+
+	exported := 0
+	err = client.ExportProducts(ctx, category, func(products []catalog.Product) error {
+		for _, p := range products {
+			if err := w.write(newRecord(p)); err != nil {
+				return err
+			}
+		}
+		exported += len(products)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := w.flush(); err != nil {
+		return err
+	}
+	log.Printf("exported %d products", exported)
+	return nil
+}
@@ -0,0 +1,29 @@
+// Command reindex copies the products into a new index with the current
+// mapping and switches the catalog service over to it without downtime.
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/kelseyhightower/envconfig"
+	"github.com/master-wayne7/go-microservices/catalog"
+)
+
+type Config struct {
+	DatabaseURL string `envconfig:"DATABASE_URL"`
+}
+
+func main() {
+	var cfg Config
+	err := envconfig.Process("", &cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	index, err := catalog.Reindex(context.Background(), cfg.DatabaseURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("products are now served from %s", index)
+}
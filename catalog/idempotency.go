@@ -0,0 +1,176 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v9/esapi"
+	"github.com/master-wayne7/go-microservices/idempotency"
+)
+
+const idempotencyIndex = "idempotency_keys"
+
+type idempotencyDocument struct {
+	Scope       string    `json:"scope"`
+	Key         string    `json:"key"`
+	RequestHash string    `json:"request_hash"`
+	Response    []byte    `json:"response"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// idempotencyDocumentID hashes scope and key so arbitrary client keys are
+// safe to use as document IDs.
+func idempotencyDocumentID(scope, key string) string {
+	sum := sha256.Sum256([]byte(scope + "\x00" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Claim implements idempotency.Store. Elasticsearch has no upsert-if, so a
+// stale record is replaced with a write conditioned on its sequence number.
+func (r *elasticRepository) Claim(ctx context.Context, scope, key, requestHash string, now time.Time) (*idempotency.Record, bool, error) {
+	start := time.Now()
+	defer func() {
+		if r.metrics != nil {
+			r.metrics.RecordDBQuery("claim", idempotencyIndex, time.Since(start))
+		}
+	}()
+
+	id := idempotencyDocumentID(scope, key)
+	body, err := json.Marshal(idempotencyDocument{
+		Scope:       scope,
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	claimed := &idempotency.Record{Scope: scope, Key: key, RequestHash: requestHash, CreatedAt: now}
+
+	res, err := esapi.CreateRequest{
+		Index:      idempotencyIndex,
+		DocumentID: id,
+		Body:       bytes.NewReader(body),
+		Refresh:    "true",
+	}.Do(ctx, r.client)
+	if err != nil {
+		return nil, false, err
+	}
+	res.Body.Close()
+	if !res.IsError() {
+		return claimed, true, nil
+	}
+	if res.StatusCode != http.StatusConflict {
+		return nil, false, fmt.Errorf("error claiming idempotency key: %s", res.String())
+	}
+
+	res, err = esapi.GetRequest{
+		Index:      idempotencyIndex,
+		DocumentID: id,
+	}.Do(ctx, r.client)
+	if err != nil {
+		return nil, false, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		// Released in the meantime; report it as in flight so the client retries.
+		return claimed, false, nil
+	}
+	if res.IsError() {
+		return nil, false, fmt.Errorf("error getting idempotency key: %s", res.String())
+	}
+	var existing struct {
+		SeqNo       int                 `json:"_seq_no"`
+		PrimaryTerm int                 `json:"_primary_term"`
+		Source      idempotencyDocument `json:"_source"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&existing); err != nil {
+		return nil, false, err
+	}
+	rec := &idempotency.Record{
+		Scope:       scope,
+		Key:         key,
+		RequestHash: existing.Source.RequestHash,
+		Response:    existing.Source.Response,
+		CreatedAt:   existing.Source.CreatedAt,
+	}
+	if !rec.Expired(now) {
+		return rec, false, nil
+	}
+
+	replace, err := esapi.IndexRequest{
+		Index:         idempotencyIndex,
+		DocumentID:    id,
+		Body:          bytes.NewReader(body),
+		IfSeqNo:       &existing.SeqNo,
+		IfPrimaryTerm: &existing.PrimaryTerm,
+		Refresh:       "true",
+	}.Do(ctx, r.client)
+	if err != nil {
+		return nil, false, err
+	}
+	replace.Body.Close()
+	if replace.StatusCode == http.StatusConflict {
+		// Another request replaced it first.
+		return claimed, false, nil
+	}
+	if replace.IsError() {
+		return nil, false, fmt.Errorf("error claiming idempotency key: %s", replace.String())
+	}
+	return claimed, true, nil
+}
+
+// Complete implements idempotency.Store.
+func (r *elasticRepository) Complete(ctx context.Context, scope, key string, response []byte) error {
+	start := time.Now()
+	body, err := json.Marshal(map[string]interface{}{
+		"doc": map[string]interface{}{"response": response},
+	})
+	if err != nil {
+		return err
+	}
+	res, err := esapi.UpdateRequest{
+		Index:      idempotencyIndex,
+		DocumentID: idempotencyDocumentID(scope, key),
+		Body:       bytes.NewReader(body),
+		Refresh:    "true",
+	}.Do(ctx, r.client)
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("update", idempotencyIndex, time.Since(start))
+	}
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("error completing idempotency key: %s", res.String())
+	}
+	return nil
+}
+
+// Release implements idempotency.Store.
+func (r *elasticRepository) Release(ctx context.Context, scope, key string) error {
+	start := time.Now()
+	res, err := esapi.DeleteRequest{
+		Index:      idempotencyIndex,
+		DocumentID: idempotencyDocumentID(scope, key),
+		Refresh:    "true",
+	}.Do(ctx, r.client)
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("delete", idempotencyIndex, time.Since(start))
+	}
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.IsError() && res.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("error releasing idempotency key: %s", res.String())
+	}
+	return nil
+}
@@ -0,0 +1,495 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v9"
+	"github.com/elastic/go-elasticsearch/v9/esapi"
+	"github.com/master-wayne7/go-microservices/money"
+)
+
+// Products live in versioned indices named catalog_v1, catalog_v2 and so on.
+// Reads go through productIndex and writes through productWriteAlias, which
+// both point at the current version. Reindex builds the next version and
+// moves the aliases over to it.
+const (
+	productIndex         = "catalog"
+	productWriteAlias    = "catalog_write"
+	productIndexPrefix   = "catalog_v"
+	productIndexTemplate = "catalog"
+)
+
+var productIndexVersion = regexp.MustCompile(`^catalog_v(\d+)$`)
+
+// productMapping holds the settings and mapping of product indices. Name and
+// description are analyzed for full-text search, and names also split into
+// word prefixes for suggestions. IDs, currencies and attributes are keywords
+// for filters and facets, and variant SKUs for looking products up by SKU.
+// Numeric attribute values are nested name and value pairs, so a range
+// applies to the value of the attribute it names. Outbox payloads are stored
+// but not indexed.
+const productMapping = `{
+	"settings": {
+		"analysis": {
+			"filter": {
+				"english_stemmer": {"type": "stemmer", "language": "light_english"},
+				"prefixes": {"type": "edge_ngram", "min_gram": 1, "max_gram": 20}
+			},
+			"analyzer": {
+				"product_text": {
+					"type": "custom",
+					"tokenizer": "standard",
+					"filter": ["lowercase", "asciifolding", "english_stemmer"]
+				},
+				"prefixes": {
+					"type": "custom",
+					"tokenizer": "standard",
+					"filter": ["lowercase", "asciifolding", "prefixes"]
+				},
+				"folded_text": {
+					"type": "custom",
+					"tokenizer": "standard",
+					"filter": ["lowercase", "asciifolding"]
+				}
+			},
+			"normalizer": {
+				"folded": {"type": "custom", "filter": ["lowercase", "asciifolding"]}
+			}
+		}
+	},
+	"mappings": {
+		"dynamic": false,
+		"properties": {
+			"id": {"type": "keyword"},
+			"name": {
+				"type": "text",
+				"analyzer": "product_text",
+				"fields": {
+					"keyword": {"type": "keyword", "normalizer": "folded", "ignore_above": 256},
+					"prefixes": {"type": "text", "analyzer": "prefixes", "search_analyzer": "folded_text"}
+				}
+			},
+			"description": {"type": "text", "analyzer": "product_text"},
+			"price": {"type": "double", "index": false},
+			"price_amount": {"type": "long"},
+			"currency": {"type": "keyword"},
+			"category_ids": {"type": "keyword"},
+			"attributes": {"type": "keyword"},
+			"numeric_attributes": {
+				"type": "nested",
+				"properties": {
+					"name": {"type": "keyword"},
+					"value": {"type": "double"}
+				}
+			},
+			"variants": {
+				"properties": {
+					"sku": {"type": "keyword"},
+					"attributes": {"type": "keyword"},
+					"price_amount": {"type": "long"},
+					"currency": {"type": "keyword"}
+				}
+			},
+			"created_at": {"type": "date"},
+			"updated_at": {"type": "date"},
+			"deleted_at": {"type": "date"},
+			"outbox": {
+				"properties": {
+					"id": {"type": "keyword"},
+					"type": {"type": "keyword"},
+					"aggregate_type": {"type": "keyword"},
+					"aggregate_id": {"type": "keyword"},
+					"payload": {"type": "text", "index": false},
+					"occurred_at": {"type": "date"}
+				}
+			}
+		}
+	}
+}`
+
+// categoryMapping is the mapping of the categories index. Names and paths
+// keep the text field and keyword subfield that dynamic mapping gave them
+// before the mapping was defined, so queries work on indices from either
+// time. Attribute definitions are stored but not indexed.
+const categoryMapping = `{
+	"mappings": {
+		"dynamic": false,
+		"properties": {
+			"name": {
+				"type": "text",
+				"fields": {"keyword": {"type": "keyword", "ignore_above": 256}}
+			},
+			"parent_id": {
+				"type": "text",
+				"fields": {"keyword": {"type": "keyword", "ignore_above": 256}}
+			},
+			"path": {
+				"type": "text",
+				"fields": {"keyword": {"type": "keyword", "ignore_above": 256}}
+			},
+			"attributes": {"type": "object", "enabled": false}
+		}
+	}
+}`
+
+// setupIndex creates the categories index, installs the index template and
+// creates the first product index with its aliases. Before indices were
+// versioned, products were kept in a plain index named catalog; such an
+// index only gets the write alias until Reindex replaces it.
+func (r *elasticRepository) setupIndex(ctx context.Context) error {
+	if err := r.createCategoryIndex(ctx); err != nil {
+		return err
+	}
+	if err := r.putIndexTemplate(ctx); err != nil {
+		return err
+	}
+	indices, err := r.aliasIndices(ctx, productWriteAlias)
+	if err != nil || len(indices) > 0 {
+		return err
+	}
+
+	exists, err := esapi.IndicesExistsRequest{
+		Index: []string{productIndex},
+	}.Do(ctx, r.client)
+	if err != nil {
+		return err
+	}
+	exists.Body.Close()
+	if exists.StatusCode == http.StatusOK {
+		log.Printf("products are in the unversioned index %s, run the reindex command to move them", productIndex)
+		return r.updateAliases(ctx, map[string]interface{}{
+			"add": map[string]interface{}{"index": productIndex, "alias": productWriteAlias, "is_write_index": true},
+		})
+	}
+
+	start := time.Now()
+	body, err := json.Marshal(map[string]interface{}{
+		"aliases": map[string]interface{}{
+			productIndex:      map[string]interface{}{},
+			productWriteAlias: map[string]interface{}{"is_write_index": true},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	res, err := esapi.IndicesCreateRequest{
+		Index: productIndexPrefix + "1",
+		Body:  bytes.NewReader(body),
+	}.Do(ctx, r.client)
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("create_index", productIndex, time.Since(start))
+	}
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	// Another instance may have created it in the meantime.
+	if res.IsError() && !strings.Contains(res.String(), "resource_already_exists_exception") {
+		return fmt.Errorf("error creating product index: %s", res.String())
+	}
+	return nil
+}
+
+// createCategoryIndex creates the categories index with categoryMapping
+// unless it exists.
+func (r *elasticRepository) createCategoryIndex(ctx context.Context) error {
+	start := time.Now()
+	res, err := esapi.IndicesCreateRequest{
+		Index: categoryIndex,
+		Body:  strings.NewReader(categoryMapping),
+	}.Do(ctx, r.client)
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("create_index", categoryIndex, time.Since(start))
+	}
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.IsError() && !strings.Contains(res.String(), "resource_already_exists_exception") {
+		return fmt.Errorf("error creating category index: %s", res.String())
+	}
+	return nil
+}
+
+// putIndexTemplate applies productMapping to every product index created
+// from now on.
+func (r *elasticRepository) putIndexTemplate(ctx context.Context) error {
+	body := `{"index_patterns": ["` + productIndexPrefix + `*"], "priority": 100, "template": ` + productMapping + `}`
+	res, err := esapi.IndicesPutIndexTemplateRequest{
+		Name: productIndexTemplate,
+		Body: strings.NewReader(body),
+	}.Do(ctx, r.client)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("error putting product index template: %s", res.String())
+	}
+	return nil
+}
+
+// aliasIndices returns the indices alias points at, or none if it does not
+// exist.
+func (r *elasticRepository) aliasIndices(ctx context.Context, alias string) ([]string, error) {
+	res, err := esapi.IndicesGetAliasRequest{
+		Name: []string{alias},
+	}.Do(ctx, r.client)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if res.IsError() {
+		return nil, fmt.Errorf("error getting alias %s: %s", alias, res.String())
+	}
+	var byIndex map[string]json.RawMessage
+	if err := json.NewDecoder(res.Body).Decode(&byIndex); err != nil {
+		return nil, err
+	}
+	indices := make([]string, 0, len(byIndex))
+	for index := range byIndex {
+		indices = append(indices, index)
+	}
+	return indices, nil
+}
+
+// updateAliases applies all actions at once.
+func (r *elasticRepository) updateAliases(ctx context.Context, actions ...interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{"actions": actions})
+	if err != nil {
+		return err
+	}
+	res, err := esapi.IndicesUpdateAliasesRequest{
+		Body: bytes.NewReader(body),
+	}.Do(ctx, r.client)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("error updating aliases: %s", res.String())
+	}
+	return nil
+}
+
+// Reindex copies all products into a new index created with the current
+// mapping and switches both aliases to it. Reads and writes keep going to the
+// old index while documents are copied. Only for a final pass copying the
+// products changed in the meantime, writes to the old index are blocked and
+// fail. The old index is kept with writes blocked, unless it is the
+// unversioned catalog index, whose name the read alias takes over. It returns
+// the name of the new index.
+func Reindex(ctx context.Context, url string) (string, error) {
+	client, err := elasticsearch.NewClient(elasticsearch.Config{Addresses: []string{url}})
+	if err != nil {
+		return "", err
+	}
+	r := &elasticRepository{client: client}
+	if err := r.setupIndex(ctx); err != nil {
+		return "", err
+	}
+	indices, err := r.aliasIndices(ctx, productWriteAlias)
+	if err != nil {
+		return "", err
+	}
+	if len(indices) != 1 {
+		return "", fmt.Errorf("expected %s to point at one index, found %v", productWriteAlias, indices)
+	}
+	old := indices[0]
+	next := productIndexPrefix + "1"
+	if m := productIndexVersion.FindStringSubmatch(old); m != nil {
+		version, _ := strconv.Atoi(m[1])
+		next = productIndexPrefix + strconv.Itoa(version+1)
+	}
+
+	res, err := esapi.IndicesCreateRequest{Index: next}.Do(ctx, client)
+	if err != nil {
+		return "", err
+	}
+	res.Body.Close()
+	if res.IsError() {
+		return "", fmt.Errorf("error creating index %s: %s", next, res.String())
+	}
+
+	// Products changed after a copy started may be missing from it. Copying
+	// again everything changed since shortly before is harmless, as the old
+	// index stays the source of truth until the aliases move. Pending events
+	// are left out until the final pass: the relay keeps publishing them from
+	// the old index meanwhile, and copies of them would be published again.
+	since := time.Now().Add(-time.Minute)
+	if err := r.copyProducts(ctx, old, next, time.Time{}, false); err != nil {
+		return "", err
+	}
+	log.Printf("copied products from %s to %s", old, next)
+	catchUp := time.Now().Add(-time.Minute)
+	if err := r.copyProducts(ctx, old, next, since, false); err != nil {
+		return "", err
+	}
+
+	if err := r.blockWrites(ctx, old, true); err != nil {
+		return "", err
+	}
+	if err := r.copyProducts(ctx, old, next, catchUp, true); err != nil {
+		return "", errors.Join(err, r.blockWrites(ctx, old, false))
+	}
+	swap := []interface{}{
+		map[string]interface{}{"add": map[string]interface{}{"index": next, "alias": productIndex}},
+		map[string]interface{}{"add": map[string]interface{}{"index": next, "alias": productWriteAlias, "is_write_index": true}},
+	}
+	if old == productIndex {
+		swap = append(swap, map[string]interface{}{"remove_index": map[string]interface{}{"index": old}})
+	} else {
+		swap = append(swap,
+			map[string]interface{}{"remove": map[string]interface{}{"index": old, "alias": productIndex}},
+			map[string]interface{}{"remove": map[string]interface{}{"index": old, "alias": productWriteAlias}},
+		)
+	}
+	if err := r.updateAliases(ctx, swap...); err != nil {
+		return "", errors.Join(err, r.blockWrites(ctx, old, false))
+	}
+	return next, nil
+}
+
+// deriveNumericAttributes fills in the numeric attributes of documents
+// written before they were indexed, the way newNumericAttributeDocuments
+// would.
+const deriveNumericAttributes = `
+	if (ctx._source.numeric_attributes == null && ctx._source.attributes != null) {
+		List numbers = new ArrayList();
+		for (String attribute : ctx._source.attributes) {
+			int i = attribute.indexOf('=');
+			try {
+				double value = Double.parseDouble(attribute.substring(i + 1));
+				if (!Double.isNaN(value) && !Double.isInfinite(value)) {
+					numbers.add(['name': attribute.substring(0, i), 'value': value]);
+				}
+			} catch (NumberFormatException e) {}
+		}
+		ctx._source.numeric_attributes = numbers;
+	}`
+
+// fillLegacyFields fills in the fields documents indexed by older versions
+// lack: the id sorted on as a tiebreaker, and the price in minor units,
+// derived from the float price the way money.FromFloat does for
+// params.currency with params.exponent decimal places. Prices are never
+// negative, so Math.round rounding halves up matches math.Round.
+const fillLegacyFields = `
+	ctx._source.id = ctx._id;
+	if (ctx._source.price_amount == null && ctx._source.price != null) {
+		double price = ((Number) ctx._source.price).doubleValue();
+		ctx._source.price_amount = Math.round(price * Math.pow(10, params.exponent));
+		ctx._source.currency = params.currency;
+		ctx._source.remove('price');
+	}`
+
+// dropOutbox removes the pending events of a copy unless params.outbox is
+// set.
+const dropOutbox = `
+	if (!params.outbox) { ctx._source.remove('outbox') }`
+
+// copyParams are the script parameters of copyProducts.
+func copyParams(final bool) map[string]interface{} {
+	return map[string]interface{}{
+		"outbox":   final,
+		"currency": money.DefaultCurrency,
+		"exponent": money.Exponent(money.DefaultCurrency),
+	}
+}
+
+// copyProducts copies the products of source changed since the given time,
+// or all of them if it is zero, into dest. The copies have no pending events
+// unless final is set, in which case products with pending events are copied
+// too, whenever they changed.
+func (r *elasticRepository) copyProducts(ctx context.Context, source, dest string, since time.Time, final bool) error {
+	from := map[string]interface{}{"index": source}
+	if !since.IsZero() {
+		changed := map[string]interface{}{
+			"range": map[string]interface{}{"updated_at": map[string]interface{}{"gte": since}},
+		}
+		from["query"] = changed
+		if final {
+			from["query"] = map[string]interface{}{
+				"bool": map[string]interface{}{
+					"should": []interface{}{
+						changed,
+						map[string]interface{}{"exists": map[string]interface{}{"field": "outbox.id"}},
+					},
+					"minimum_should_match": 1,
+				},
+			}
+		}
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"source": from,
+		"dest":   map[string]interface{}{"index": dest},
+		"script": map[string]interface{}{
+			"lang":   "painless",
+			"source": fillLegacyFields + deriveNumericAttributes + dropOutbox,
+			"params": copyParams(final),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	refresh, wait := true, true
+	res, err := esapi.ReindexRequest{
+		Body:              bytes.NewReader(body),
+		Refresh:           &refresh,
+		WaitForCompletion: &wait,
+	}.Do(ctx, r.client)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("error copying products from %s to %s: %s", source, dest, res.String())
+	}
+	var result struct {
+		Failures []json.RawMessage `json:"failures"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return err
+	}
+	if len(result.Failures) > 0 {
+		return fmt.Errorf("error copying products from %s to %s: %d failures, first: %s", source, dest, len(result.Failures), result.Failures[0])
+	}
+	return nil
+}
+
+// blockWrites makes index read-only, or writable again, and refreshes it so
+// the last writes are visible to copyProducts.
+func (r *elasticRepository) blockWrites(ctx context.Context, index string, block bool) error {
+	res, err := esapi.IndicesPutSettingsRequest{
+		Index: []string{index},
+		Body:  strings.NewReader(fmt.Sprintf(`{"index.blocks.write": %t}`, block)),
+	}.Do(ctx, r.client)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("error setting write block on %s: %s", index, res.String())
+	}
+	res, err = esapi.IndicesRefreshRequest{Index: []string{index}}.Do(ctx, r.client)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("error refreshing %s: %s", index, res.String())
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package catalog
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/master-wayne7/go-microservices/money"
+)
+
+func TestLegacyProductPrice(t *testing.T) {
+	data, err := os.ReadFile("testdata/legacy_products.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var docs []productDocument
+	if err := json.Unmarshal(data, &docs); err != nil {
+		t.Fatal(err)
+	}
+	params := copyParams(true)
+	for _, d := range docs {
+		if d.ID != "" || d.Currency != "" || d.Price == nil {
+			t.Fatalf("%s is not a legacy document", d.Name)
+		}
+		want := money.FromFloat(*d.Price, money.DefaultCurrency)
+		if got := d.product("id").Price; got != want {
+			t.Errorf("%s read with price %+v, want %+v", d.Name, got, want)
+		}
+		// fillLegacyFields computes Math.round(price * Math.pow(10, exponent)).
+		amount := int64(math.Round(*d.Price * math.Pow(10, float64(params["exponent"].(int)))))
+		if got := money.New(amount, params["currency"].(string)); got != want {
+			t.Errorf("%s copied with price %+v, want %+v", d.Name, got, want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v9/esapi"
+	"github.com/master-wayne7/go-microservices/events"
+)
+
+// outboxEntry is an event stored in a product document. The payload is kept
+// as a string so its fields do not end up in the index mapping.
+type outboxEntry struct {
+	ID            string    `json:"id"`
+	Type          string    `json:"type"`
+	AggregateType string    `json:"aggregate_type"`
+	AggregateID   string    `json:"aggregate_id"`
+	Payload       string    `json:"payload"`
+	OccurredAt    time.Time `json:"occurred_at"`
+}
+
+func newOutboxEntry(e events.Event) outboxEntry {
+	return outboxEntry{
+		ID:            e.ID,
+		Type:          e.Type,
+		AggregateType: e.AggregateType,
+		AggregateID:   e.AggregateID,
+		Payload:       string(e.Payload),
+		OccurredAt:    e.OccurredAt,
+	}
+}
+
+func (o outboxEntry) event() events.Event {
+	return events.Event{
+		ID:            o.ID,
+		Type:          o.Type,
+		AggregateType: o.AggregateType,
+		AggregateID:   o.AggregateID,
+		Payload:       json.RawMessage(o.Payload),
+		OccurredAt:    o.OccurredAt,
+	}
+}
+
+// Pending implements events.Source. limit caps the number of documents read,
+// each of which may carry several events. Documents are read in the order of
+// their oldest pending event, so older events are relayed first whatever the
+// batch size.
+func (r *elasticRepository) Pending(ctx context.Context, limit int) ([]events.Event, error) {
+	start := time.Now()
+	body := map[string]interface{}{
+		"size":    limit,
+		"_source": []string{"outbox"},
+		"query": map[string]interface{}{
+			"exists": map[string]interface{}{"field": "outbox.id"},
+		},
+		"sort": []interface{}{
+			map[string]interface{}{"outbox.occurred_at": map[string]interface{}{"order": "asc", "mode": "min", "unmapped_type": "date"}},
+			map[string]interface{}{"id": map[string]interface{}{"order": "asc", "unmapped_type": "keyword"}},
+		},
+	}
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return nil, err
+	}
+
+	res, err := esapi.SearchRequest{
+		Index: []string{productIndex},
+		Body:  &buf,
+	}.Do(ctx, r.client)
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("search", "catalog_outbox", time.Since(start))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return nil, fmt.Errorf("error reading catalog outbox: %s", res.String())
+	}
+
+	var esResp esSearchResponse
+	if err := json.NewDecoder(res.Body).Decode(&esResp); err != nil {
+		return nil, err
+	}
+	pending := []events.Event{}
+	for _, hit := range esResp.Hits.Hits {
+		var doc struct {
+			Outbox []outboxEntry `json:"outbox"`
+		}
+		if err := json.Unmarshal(hit.Source, &doc); err != nil {
+			return nil, err
+		}
+		for _, entry := range doc.Outbox {
+			pending = append(pending, entry.event())
+		}
+	}
+	sort.SliceStable(pending, func(i, j int) bool {
+		if !pending[i].OccurredAt.Equal(pending[j].OccurredAt) {
+			return pending[i].OccurredAt.Before(pending[j].OccurredAt)
+		}
+		return pending[i].ID < pending[j].ID
+	})
+	return pending, nil
+}
+
+// MarkPublished implements events.Source. Entries are removed by a script so
+// events added to the document in the meantime are kept.
+func (r *elasticRepository) MarkPublished(ctx context.Context, published []events.Event) error {
+	byProduct := map[string][]string{}
+	for _, e := range published {
+		byProduct[e.AggregateID] = append(byProduct[e.AggregateID], e.ID)
+	}
+	for productID, ids := range byProduct {
+		start := time.Now()
+		body, err := json.Marshal(map[string]interface{}{
+			"script": map[string]interface{}{
+				"lang": "painless",
+				"source": `if (ctx._source.outbox != null) {
+					ctx._source.outbox.removeIf(e -> params.ids.contains(e.id));
+					if (ctx._source.outbox.isEmpty()) { ctx._source.remove('outbox') }
+					ctx._source.updated_at = params.updated_at;
+				}`,
+				"params": map[string]interface{}{"ids": ids, "updated_at": time.Now().UTC()},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		res, err := esapi.UpdateRequest{
+			Index:      productWriteAlias,
+			DocumentID: productID,
+			Body:       bytes.NewReader(body),
+			Refresh:    "true",
+		}.Do(ctx, r.client)
+		if r.metrics != nil {
+			r.metrics.RecordDBQuery("update", "catalog_outbox", time.Since(start))
+		}
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+		if res.IsError() {
+			return fmt.Errorf("error marking catalog events published: %s", res.String())
+		}
+	}
+	return nil
+}
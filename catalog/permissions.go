@@ -0,0 +1,28 @@
+package catalog
+
+import (
+	"github.com/master-wayne7/go-microservices/auth"
+	"github.com/master-wayne7/go-microservices/catalog/pb"
+)
+
+// permissions decides who may call each CatalogService method. Browsing is
+// public; changing the catalog is for staff.
+var permissions = auth.Rules{
+	pb.CatalogService_PostProduct_FullMethodName:        auth.RequireRole(auth.RoleStaff),
+	pb.CatalogService_UpdateProduct_FullMethodName:      auth.RequireRole(auth.RoleStaff),
+	pb.CatalogService_PatchProduct_FullMethodName:       auth.RequireRole(auth.RoleStaff),
+	pb.CatalogService_DeleteProduct_FullMethodName:      auth.RequireRole(auth.RoleStaff),
+	pb.CatalogService_BulkImportProducts_FullMethodName: auth.RequireRole(auth.RoleStaff),
+	pb.CatalogService_ExportProducts_FullMethodName:     auth.RequireRole(auth.RoleStaff),
+	pb.CatalogService_GetProduct_FullMethodName:         auth.Public,
+	pb.CatalogService_GetProducts_FullMethodName:        auth.Public,
+	pb.CatalogService_SearchProducts_FullMethodName:     auth.Public,
+	pb.CatalogService_Suggest_FullMethodName:            auth.Public,
+
+	pb.CatalogService_CreateCategory_FullMethodName:        auth.RequireRole(auth.RoleStaff),
+	pb.CatalogService_RenameCategory_FullMethodName:        auth.RequireRole(auth.RoleStaff),
+	pb.CatalogService_MoveCategory_FullMethodName:          auth.RequireRole(auth.RoleStaff),
+	pb.CatalogService_DeleteCategory_FullMethodName:        auth.RequireRole(auth.RoleStaff),
+	pb.CatalogService_SetCategoryAttributes_FullMethodName: auth.RequireRole(auth.RoleStaff),
+	pb.CatalogService_GetCategories_FullMethodName:         auth.Public,
+}
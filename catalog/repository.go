@@ -6,17 +6,22 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/elastic/go-elasticsearch/v9"
 	"github.com/elastic/go-elasticsearch/v9/esapi"
+	"github.com/master-wayne7/go-microservices/events"
+	"github.com/master-wayne7/go-microservices/idempotency"
+	"github.com/master-wayne7/go-microservices/money"
 	"github.com/master-wayne7/go-microservices/monitoring"
 )
 
 var (
-	ErrNotFound = errors.New("entity not found")
+	ErrNotFound        = errors.New("entity not found")
+	ErrVersionConflict = errors.New("product was changed concurrently")
 )
 
 type Repository interface {
@@ -24,10 +29,38 @@ type Repository interface {
 	// Wire metrics into repository
 	SetMetrics(mc *monitoring.MetricsCollector)
 	PutProduct(ctx context.Context, p Product) error
+	// PutProducts creates products in bulk and returns an error per product,
+	// nil for the ones created.
+	PutProducts(ctx context.Context, products []Product) ([]error, error)
+	// ExportProducts passes all products that are not deleted to fn, page by
+	// page. Unless categoryIDs is empty, only products in one of them.
+	ExportProducts(ctx context.Context, categoryIDs []string, fn func([]Product) error) error
 	GetProductById(ctx context.Context, id string) (*Product, error)
-	ListProducts(ctx context.Context, skip, take uint64) ([]Product, error)
+	// ListProducts and SearchProducts only return products in one of
+	// categoryIDs, unless it is empty. SearchProducts ignores q.Category.
+	ListProducts(ctx context.Context, skip, take uint64, categoryIDs []string, sort ProductSort) ([]Product, error)
 	ListProductsWithIDs(ctx context.Context, ids []string) ([]Product, error)
-	SearchProducts(ctx context.Context, query string, skip, take uint64) ([]Product, error)
+	// ListProductsWithSKUs returns the products that have one of skus as a
+	// variant SKU or as their ID, deleted ones included.
+	ListProductsWithSKUs(ctx context.Context, skus []string) ([]Product, error)
+	SearchProducts(ctx context.Context, q SearchQuery, categoryIDs []string) (*SearchResult, error)
+	SuggestProducts(ctx context.Context, prefix string, size int) ([]Suggestion, error)
+	// UpdateProduct saves p with an event of eventType if the stored document
+	// is still at p.Version, and returns the new version.
+	UpdateProduct(ctx context.Context, p Product, eventType string) (Version, error)
+
+	// Categories live in their own index.
+	PutCategory(ctx context.Context, c Category) error
+	GetCategory(ctx context.Context, id string) (*Category, error)
+	ListCategories(ctx context.Context) ([]Category, error)
+	ListCategoriesWithIDs(ctx context.Context, ids []string) ([]Category, error)
+	ListCategorySubtree(ctx context.Context, id string) ([]Category, error)
+	MoveCategory(ctx context.Context, id, parentID string, prefix []string) error
+	DeleteCategory(ctx context.Context, id string) error
+	// Idempotency keys for PostProduct live in their own index.
+	idempotency.Store
+	// Events are kept in the documents they belong to until relayed.
+	events.Source
 }
 
 type elasticRepository struct {
@@ -37,17 +70,141 @@ type elasticRepository struct {
 
 type esSearchResponse struct {
 	Hits struct {
+		Total struct {
+			Value uint64 `json:"value"`
+		} `json:"total"`
 		Hits []struct {
-			ID     string          `json:"_id"`
-			Source json.RawMessage `json:"_source"`
+			ID          string          `json:"_id"`
+			SeqNo       int64           `json:"_seq_no"`
+			PrimaryTerm int64           `json:"_primary_term"`
+			Source      json.RawMessage `json:"_source"`
+			// Highlight maps fields to fragments matching the search text.
+			Highlight map[string][]string `json:"highlight"`
+			// Sort holds the sort values, to search after the hit.
+			Sort json.RawMessage `json:"sort"`
 		} `json:"hits"`
 	} `json:"hits"`
 }
 
+// products converts the hits into products, skipping documents that do not
+// decode.
+func (r esSearchResponse) products() []Product {
+	products := make([]Product, 0, len(r.Hits.Hits))
+	for _, hit := range r.Hits.Hits {
+		var doc productDocument
+		if err := json.Unmarshal(hit.Source, &doc); err != nil {
+			continue
+		}
+		p := doc.product(hit.ID)
+		p.Version = Version{SeqNo: hit.SeqNo, PrimaryTerm: hit.PrimaryTerm}
+		products = append(products, p)
+	}
+	return products
+}
+
+// listed restricts a query to products that are not deleted and, unless
+// categoryIDs is empty, in one of categoryIDs.
+func listed(query map[string]interface{}, categoryIDs []string) map[string]interface{} {
+	filter := []interface{}{}
+	if len(categoryIDs) > 0 {
+		filter = append(filter, map[string]interface{}{
+			"terms": map[string]interface{}{"category_ids": categoryIDs},
+		})
+	}
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"must":   query,
+			"filter": filter,
+			"must_not": map[string]interface{}{
+				"exists": map[string]interface{}{"field": "deleted_at"},
+			},
+		},
+	}
+}
+
 type productDocument struct {
-	Name        string  `json:"name"`
-	Price       float64 `json:"price"`
-	Description string  `json:"description"`
+	// ID repeats the document ID so it can be sorted on.
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Price is the float price of documents indexed before prices were
+	// stored in minor units. It is only read, never written.
+	Price       *float64 `json:"price,omitempty"`
+	PriceAmount int64    `json:"price_amount"`
+	Currency    string   `json:"currency"`
+	Description string   `json:"description"`
+	CategoryIDs []string `json:"category_ids"`
+	// Attributes are "name=value" keywords, see attributeValue.
+	Attributes []string `json:"attributes"`
+	// NumericAttributes repeats the attributes whose values are numbers. Like
+	// Variants, it is written even when empty.
+	NumericAttributes []numericAttributeDocument `json:"numeric_attributes"`
+	// Variants is written even when empty, so updates remove old ones.
+	Variants  []variantDocument `json:"variants"`
+	CreatedAt *time.Time        `json:"created_at,omitempty"`
+	// UpdatedAt is when the document was last written, so Reindex can copy
+	// the products changed while it ran.
+	UpdatedAt time.Time `json:"updated_at"`
+	// DeletedAt marks soft-deleted products.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Outbox holds events not yet relayed. Keeping them inside the product
+	// document makes writing both a single atomic index operation.
+	Outbox []outboxEntry `json:"outbox,omitempty"`
+}
+
+func newProductDocument(p Product) productDocument {
+	doc := productDocument{
+		ID:          p.ID,
+		Name:        p.Name,
+		PriceAmount: p.Price.Amount,
+		Currency:    p.Price.Currency,
+		Description: p.Description,
+		CategoryIDs: p.CategoryIDs,
+		Variants:    newVariantDocuments(p.Variants),
+		UpdatedAt:   time.Now().UTC(),
+	}
+	for name, value := range p.Attributes {
+		doc.Attributes = append(doc.Attributes, attributeValue(name, value))
+	}
+	sort.Strings(doc.Attributes)
+	doc.NumericAttributes = newNumericAttributeDocuments(p.Attributes)
+	if !p.CreatedAt.IsZero() {
+		doc.CreatedAt = &p.CreatedAt
+	}
+	if p.IsDeleted() {
+		doc.DeletedAt = &p.DeletedAt
+	}
+	return doc
+}
+
+func (d productDocument) product(id string) Product {
+	price := money.New(d.PriceAmount, d.Currency)
+	if d.Currency == "" && d.Price != nil {
+		price = money.FromFloat(*d.Price, money.DefaultCurrency)
+	}
+	p := Product{
+		ID:          id,
+		Name:        d.Name,
+		Description: d.Description,
+		Price:       price,
+		CategoryIDs: d.CategoryIDs,
+	}
+	if len(d.Attributes) > 0 {
+		p.Attributes = make(map[string]string, len(d.Attributes))
+		for _, attribute := range d.Attributes {
+			name, value, _ := strings.Cut(attribute, "=")
+			p.Attributes[name] = value
+		}
+	}
+	for _, v := range d.Variants {
+		p.Variants = append(p.Variants, v.variant())
+	}
+	if d.CreatedAt != nil {
+		p.CreatedAt = *d.CreatedAt
+	}
+	if d.DeletedAt != nil {
+		p.DeletedAt = *d.DeletedAt
+	}
+	return p
 }
 
 var httpTr = &http.Transport{
@@ -88,7 +245,13 @@ func NewElasticRepository(url string) (Repository, error) {
 		return nil, err
 	}
 
-	return &elasticRepository{client: client}, nil
+	r := &elasticRepository{client: client}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := r.setupIndex(ctx); err != nil {
+		return nil, err
+	}
+	return r, nil
 }
 
 func (r *elasticRepository) Close() {
@@ -101,19 +264,20 @@ func (r *elasticRepository) SetMetrics(mc *monitoring.MetricsCollector) {
 }
 func (r *elasticRepository) PutProduct(ctx context.Context, p Product) error {
 	start := time.Now()
-	product := productDocument{
-		Name:        p.Name,
-		Price:       p.Price,
-		Description: p.Description,
+	event, err := events.NewEvent(events.ProductCreated, "product", p.ID, p)
+	if err != nil {
+		return err
 	}
-	body, err := json.Marshal(product)
+	doc := newProductDocument(p)
+	doc.Outbox = append(doc.Outbox, newOutboxEntry(event))
+	body, err := json.Marshal(doc)
 	if err != nil {
 		return err
 	}
 
 	// Index API request
 	req := esapi.IndexRequest{
-		Index:      "catalog",
+		Index:      productWriteAlias,
 		DocumentID: p.ID,
 		Body:       bytes.NewReader(body),
 		Refresh:    "true",
@@ -122,7 +286,7 @@ func (r *elasticRepository) PutProduct(ctx context.Context, p Product) error {
 	res, err := req.Do(ctx, r.client)
 	if err != nil {
 		if r.metrics != nil {
-			r.metrics.RecordDBQuery("index", "catalog", time.Since(start))
+			r.metrics.RecordDBQuery("index", productIndex, time.Since(start))
 		}
 		return err
 	}
@@ -130,72 +294,73 @@ func (r *elasticRepository) PutProduct(ctx context.Context, p Product) error {
 
 	if res.IsError() {
 		if r.metrics != nil {
-			r.metrics.RecordDBQuery("index", "catalog", time.Since(start))
+			r.metrics.RecordDBQuery("index", productIndex, time.Since(start))
 		}
 		return errors.New("error indexing document")
 	}
 	if r.metrics != nil {
-		r.metrics.RecordDBQuery("index", "catalog", time.Since(start))
+		r.metrics.RecordDBQuery("index", productIndex, time.Since(start))
 	}
 	return nil
 }
+
+// GetProductById also resolves deleted products.
 func (r *elasticRepository) GetProductById(ctx context.Context, id string) (*Product, error) {
 	start := time.Now()
 	req := esapi.GetRequest{
-		Index:      "catalog",
+		Index:      productIndex,
 		DocumentID: id,
 	}
 
 	res, err := req.Do(ctx, r.client.Transport)
 	if err != nil {
 		if r.metrics != nil {
-			r.metrics.RecordDBQuery("get", "catalog", time.Since(start))
+			r.metrics.RecordDBQuery("get", productIndex, time.Since(start))
 		}
 		return nil, err
 	}
 	defer res.Body.Close()
 
+	if res.StatusCode == http.StatusNotFound {
+		if r.metrics != nil {
+			r.metrics.RecordDBQuery("get", productIndex, time.Since(start))
+		}
+		return nil, fmt.Errorf("%w: product %s", ErrNotFound, id)
+	}
 	if res.IsError() {
 		if r.metrics != nil {
-			r.metrics.RecordDBQuery("get", "catalog", time.Since(start))
+			r.metrics.RecordDBQuery("get", productIndex, time.Since(start))
 		}
 		return nil, fmt.Errorf("error getting document ID=%s: %s", id, res.String())
 	}
 
-	// Read raw response body
-	data, err := io.ReadAll(res.Body)
-	if err != nil {
-		if r.metrics != nil {
-			r.metrics.RecordDBQuery("get", "catalog", time.Since(start))
-		}
-		return nil, err
+	// The document itself is in _source, next to its version
+	var doc struct {
+		SeqNo       int64           `json:"_seq_no"`
+		PrimaryTerm int64           `json:"_primary_term"`
+		Source      productDocument `json:"_source"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&doc)
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("get", productIndex, time.Since(start))
 	}
-	var p productDocument
-	err = json.Unmarshal(data, &p)
 	if err != nil {
-		if r.metrics != nil {
-			r.metrics.RecordDBQuery("get", "catalog", time.Since(start))
-		}
 		return nil, err
 	}
-	if r.metrics != nil {
-		r.metrics.RecordDBQuery("get", "catalog", time.Since(start))
-	}
-	return &Product{
-		ID:          id,
-		Name:        p.Name,
-		Description: p.Description,
-		Price:       p.Price,
-	}, err
+	product := doc.Source.product(id)
+	product.Version = Version{SeqNo: doc.SeqNo, PrimaryTerm: doc.PrimaryTerm}
+	return &product, nil
 }
-func (r *elasticRepository) ListProducts(ctx context.Context, skip, take uint64) ([]Product, error) {
+func (r *elasticRepository) ListProducts(ctx context.Context, skip, take uint64, categoryIDs []string, sort ProductSort) ([]Product, error) {
 	start := time.Now()
 	query := map[string]interface{}{
-		"from": skip,
-		"size": take,
-		"query": map[string]interface{}{
+		"from":                skip,
+		"size":                take,
+		"seq_no_primary_term": true,
+		"sort":                sortClause(sort),
+		"query": listed(map[string]interface{}{
 			"match_all": map[string]interface{}{},
-		},
+		}, categoryIDs),
 	}
 
 	// Encode query
@@ -206,14 +371,14 @@ func (r *elasticRepository) ListProducts(ctx context.Context, skip, take uint64)
 
 	// Search request
 	req := esapi.SearchRequest{
-		Index: []string{"catalog"},
+		Index: []string{productIndex},
 		Body:  &buf,
 	}
 
 	res, err := req.Do(ctx, r.client.Transport)
 	if err != nil {
 		if r.metrics != nil {
-			r.metrics.RecordDBQuery("search", "catalog", time.Since(start))
+			r.metrics.RecordDBQuery("search", productIndex, time.Since(start))
 		}
 		return nil, err
 	}
@@ -221,65 +386,29 @@ func (r *elasticRepository) ListProducts(ctx context.Context, skip, take uint64)
 
 	if res.IsError() {
 		if r.metrics != nil {
-			r.metrics.RecordDBQuery("search", "catalog", time.Since(start))
+			r.metrics.RecordDBQuery("search", productIndex, time.Since(start))
 		}
 		return nil, fmt.Errorf("error searching products: %s", res.String())
 	}
 
 	// Parse response
-	var p map[string]interface{}
-	if err := json.NewDecoder(res.Body).Decode(&p); err != nil {
+	var esResp esSearchResponse
+	if err := json.NewDecoder(res.Body).Decode(&esResp); err != nil {
 		return nil, err
 	}
-
-	// Extract hits
-	products := make([]Product, 0)
-	hitsMap, ok := p["hits"].(map[string]interface{})
-	if !ok {
-		if r.metrics != nil {
-			r.metrics.RecordDBQuery("search", "catalog", time.Since(start))
-		}
-		return products, nil // Return empty slice if no hits
-	}
-
-	hits, ok := hitsMap["hits"].([]interface{})
-	if !ok {
-		if r.metrics != nil {
-			r.metrics.RecordDBQuery("search", "catalog", time.Since(start))
-		}
-		return products, nil // Return empty slice if hits is not an array
-	}
-
-	for _, hit := range hits {
-		hitMap := hit.(map[string]interface{})
-
-		// Get the document ID
-		docID := hitMap["_id"].(string)
-
-		src := hit.(map[string]interface{})["_source"]
-		srcJSON, _ := json.Marshal(src)
-
-		var product productDocument
-		if err := json.Unmarshal(srcJSON, &product); err == nil {
-			products = append(products, Product{
-				ID:          docID,
-				Name:        product.Name,
-				Description: product.Description,
-				Price:       product.Price,
-			})
-		}
-	}
+	products := esResp.products()
 
 	if r.metrics != nil {
-		r.metrics.RecordDBQuery("search", "catalog", time.Since(start))
+		r.metrics.RecordDBQuery("search", productIndex, time.Since(start))
 	}
 	return products, nil
-
 }
 func (r *elasticRepository) ListProductsWithIDs(ctx context.Context, ids []string) ([]Product, error) {
 	start := time.Now()
 	// Build query
 	body := map[string]interface{}{
+		"size":                len(ids),
+		"seq_no_primary_term": true,
 		"query": map[string]interface{}{
 			"ids": map[string]interface{}{
 				"values": ids,
@@ -295,12 +424,12 @@ func (r *elasticRepository) ListProductsWithIDs(ctx context.Context, ids []strin
 	// Perform search
 	res, err := r.client.Search(
 		r.client.Search.WithContext(ctx),
-		r.client.Search.WithIndex("catalog"),
+		r.client.Search.WithIndex(productIndex),
 		r.client.Search.WithBody(&buf),
 	)
 	if err != nil {
 		if r.metrics != nil {
-			r.metrics.RecordDBQuery("mget", "catalog", time.Since(start))
+			r.metrics.RecordDBQuery("mget", productIndex, time.Since(start))
 		}
 		return nil, fmt.Errorf("search request failed: %w", err)
 	}
@@ -308,7 +437,7 @@ func (r *elasticRepository) ListProductsWithIDs(ctx context.Context, ids []strin
 
 	if res.IsError() {
 		if r.metrics != nil {
-			r.metrics.RecordDBQuery("mget", "catalog", time.Since(start))
+			r.metrics.RecordDBQuery("mget", productIndex, time.Since(start))
 		}
 		return nil, fmt.Errorf("error searching products: %s", res.String())
 	}
@@ -319,96 +448,73 @@ func (r *elasticRepository) ListProductsWithIDs(ctx context.Context, ids []strin
 		return nil, fmt.Errorf("failed to decode search response: %w", err)
 	}
 
-	products := make([]Product, 0, len(esResp.Hits.Hits))
-
-	// Convert ES docs into Product
-	for _, hit := range esResp.Hits.Hits {
-		var doc productDocument
-		if err := json.Unmarshal(hit.Source, &doc); err != nil {
-			continue // Skip invalid docs instead of failing everything
-		}
-
-		products = append(products, Product{
-			ID:          hit.ID,
-			Name:        doc.Name,
-			Description: doc.Description,
-			Price:       doc.Price,
-		})
-	}
+	// Convert ES docs into Product, skipping invalid docs instead of
+	// failing everything
+	products := esResp.products()
 
 	if r.metrics != nil {
-		r.metrics.RecordDBQuery("mget", "catalog", time.Since(start))
+		r.metrics.RecordDBQuery("mget", productIndex, time.Since(start))
 	}
 	return products, nil
 }
 
-func (r *elasticRepository) SearchProducts(ctx context.Context, query string, skip, take uint64) ([]Product, error) {
+// UpdateProduct writes the product fields with a script so events still in
+// the document's outbox are kept, and appends the new event to it.
+func (r *elasticRepository) UpdateProduct(ctx context.Context, p Product, eventType string) (Version, error) {
 	start := time.Now()
-	body := map[string]interface{}{
-		"from": skip,
-		"size": take,
-		"query": map[string]interface{}{
-			"query_string": map[string]interface{}{
-				"query":  "*" + query + "*",
-				"fields": []string{"name", "description"},
+	event, err := events.NewEvent(eventType, "product", p.ID, p)
+	if err != nil {
+		return Version{}, err
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"script": map[string]interface{}{
+			"lang": "painless",
+			"source": `ctx._source.putAll(params.doc);
+				ctx._source.remove('price');
+				if (ctx._source.outbox == null) { ctx._source.outbox = [] }
+				ctx._source.outbox.add(params.event);`,
+			"params": map[string]interface{}{
+				"doc":   newProductDocument(p),
+				"event": newOutboxEntry(event),
 			},
 		},
+	})
+	if err != nil {
+		return Version{}, err
+	}
+
+	seqNo, primaryTerm := int(p.Version.SeqNo), int(p.Version.PrimaryTerm)
+	res, err := esapi.UpdateRequest{
+		Index:         productWriteAlias,
+		DocumentID:    p.ID,
+		Body:          bytes.NewReader(body),
+		IfSeqNo:       &seqNo,
+		IfPrimaryTerm: &primaryTerm,
+		Refresh:       "true",
+	}.Do(ctx, r.client)
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("update", productIndex, time.Since(start))
 	}
-
-	var buf bytes.Buffer
-	if err := json.NewEncoder(&buf).Encode(body); err != nil {
-		return nil, err
-	}
-
-	req := esapi.SearchRequest{
-		Index: []string{"catalog"},
-		Body:  &buf,
-	}
-
-	res, err := req.Do(ctx, r.client.Transport)
 	if err != nil {
-		if r.metrics != nil {
-			r.metrics.RecordDBQuery("search", "catalog", time.Since(start))
-		}
-		return nil, err
+		return Version{}, err
 	}
 	defer res.Body.Close()
 
-	if res.IsError() {
-		if r.metrics != nil {
-			r.metrics.RecordDBQuery("search", "catalog", time.Since(start))
-		}
-		return nil, fmt.Errorf("error searching products: %s", res.String())
-	}
-
-	// Parse response
-	var p map[string]interface{}
-	if err := json.NewDecoder(res.Body).Decode(&p); err != nil {
-		return nil, err
+	switch {
+	case res.StatusCode == http.StatusConflict:
+		return Version{}, fmt.Errorf("%w: %s is no longer at version %s", ErrVersionConflict, p.ID, p.Version)
+	case res.StatusCode == http.StatusNotFound:
+		return Version{}, fmt.Errorf("%w: product %s", ErrNotFound, p.ID)
+	case res.IsError():
+		return Version{}, fmt.Errorf("error updating product %s: %s", p.ID, res.String())
 	}
 
-	products := make([]Product, 0)
-	hits := p["hits"].(map[string]interface{})["hits"].([]interface{})
-	for _, hit := range hits {
-		hitMap := hit.(map[string]interface{})
-		docID := hitMap["_id"].(string)
-
-		src := hitMap["_source"]
-		srcJSON, _ := json.Marshal(src)
-
-		var product productDocument
-		if err := json.Unmarshal(srcJSON, &product); err == nil {
-			products = append(products, Product{
-				ID:          docID,
-				Name:        product.Name,
-				Description: product.Description,
-				Price:       product.Price,
-			})
-		}
+	var updated struct {
+		SeqNo       int64 `json:"_seq_no"`
+		PrimaryTerm int64 `json:"_primary_term"`
 	}
-
-	if r.metrics != nil {
-		r.metrics.RecordDBQuery("search", "catalog", time.Since(start))
+	if err := json.NewDecoder(res.Body).Decode(&updated); err != nil {
+		return Version{}, err
 	}
-	return products, nil
+	return Version{SeqNo: updated.SeqNo, PrimaryTerm: updated.PrimaryTerm}, nil
 }
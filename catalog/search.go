@@ -0,0 +1,406 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/elastic/go-elasticsearch/v9/esapi"
+	"github.com/master-wayne7/go-microservices/money"
+)
+
+// SearchQuery is a structured product search. Products have to match every
+// criterion that is set.
+type SearchQuery struct {
+	// Text is matched against names and descriptions, tolerating typos.
+	// Matches are ranked by relevance, names counting more than
+	// descriptions. It is taken literally; no query syntax is interpreted.
+	Text string
+	// MinPrice and MaxPrice are inclusive. Setting either only matches
+	// products priced in Currency.
+	MinPrice *money.Money
+	MaxPrice *money.Money
+	// Currency of the price bounds and of the price range facets. It
+	// defaults to the currency of the bounds, or money.DefaultCurrency.
+	Currency string
+	// Category also matches products in its subcategories.
+	Category string
+	// Attributes maps attribute names to accepted values. A product has to
+	// have one of the values of every listed attribute.
+	Attributes map[string][]string
+	// AttributeRanges maps attribute names to the range their value has to
+	// be a number in.
+	AttributeRanges map[string]AttributeRange
+	Sort            ProductSort
+	Skip            uint64
+	Take            uint64
+}
+
+// SearchResult is one page of matching products, the total number of
+// matches and the facets of all matches.
+type SearchResult struct {
+	Products []Product
+	Total    uint64
+	Facets   Facets
+	// Highlights maps the IDs of the products on the page to the fields
+	// matching the search text. It is empty without search text.
+	Highlights map[string][]Highlight
+}
+
+// Highlight holds the snippets of a field that match the search text. The
+// snippets are HTML-escaped, with matching terms wrapped in <em> tags.
+type Highlight struct {
+	Field     string
+	Fragments []string
+}
+
+// Facets count the matching products per category, price range and
+// attribute value. Each facet ignores its own criterion, so it shows how
+// many products choosing another value would find.
+type Facets struct {
+	// Categories counts products directly in each category.
+	Categories  []FacetValue
+	PriceRanges []PriceRangeFacet
+	Attributes  []AttributeFacet
+}
+
+type FacetValue struct {
+	Value string
+	Count uint64
+}
+
+// PriceRangeFacet counts products priced from Min up to but excluding Max.
+// Either bound is nil for the open-ended first and last ranges.
+type PriceRangeFacet struct {
+	Min   *money.Money
+	Max   *money.Money
+	Count uint64
+}
+
+type AttributeFacet struct {
+	Name   string
+	Values []FacetValue
+}
+
+// priceRangeBounds split the price facet, in major units of the currency.
+var priceRangeBounds = []int64{10, 25, 50, 100, 250}
+
+// searchFields are matched by the search text. Names weigh three times as
+// much as descriptions.
+var searchFields = []string{"name^3", "description"}
+
+const (
+	facetCategory  = "category"
+	facetPrice     = "price"
+	facetAttribute = "attribute:"
+
+	maxCategoryFacets  = 100
+	maxAttributeFacets = 1000
+)
+
+// attributeValue is how attributes are indexed: one "name=value" keyword per
+// attribute. Arbitrary names then neither grow the index mapping nor need
+// nested queries.
+func attributeValue(name, value string) string {
+	return name + "=" + value
+}
+
+// facetAggregation is the response of an aggregation built by facet. Terms
+// buckets have a key, range buckets their bounds.
+type facetAggregation struct {
+	Values struct {
+		Buckets []struct {
+			Key      string   `json:"key"`
+			From     *float64 `json:"from"`
+			To       *float64 `json:"to"`
+			DocCount uint64   `json:"doc_count"`
+		} `json:"buckets"`
+	} `json:"values"`
+}
+
+func (a facetAggregation) values() []FacetValue {
+	values := make([]FacetValue, 0, len(a.Values.Buckets))
+	for _, b := range a.Values.Buckets {
+		values = append(values, FacetValue{Value: b.Key, Count: b.DocCount})
+	}
+	return values
+}
+
+type esFacetSearchResponse struct {
+	esSearchResponse
+	Aggregations map[string]facetAggregation `json:"aggregations"`
+}
+
+// SearchProducts implements Repository. Criteria are applied as a post
+// filter, and every facet aggregates over all criteria but its own.
+func (r *elasticRepository) SearchProducts(ctx context.Context, q SearchQuery, categoryIDs []string) (*SearchResult, error) {
+	start := time.Now()
+
+	filters := map[string]interface{}{}
+	if len(categoryIDs) > 0 {
+		filters[facetCategory] = map[string]interface{}{
+			"terms": map[string]interface{}{"category_ids": categoryIDs},
+		}
+	}
+	if q.MinPrice != nil || q.MaxPrice != nil {
+		bounds := map[string]interface{}{}
+		if q.MinPrice != nil {
+			bounds["gte"] = q.MinPrice.Amount
+		}
+		if q.MaxPrice != nil {
+			bounds["lte"] = q.MaxPrice.Amount
+		}
+		filters[facetPrice] = map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter": []interface{}{
+					map[string]interface{}{"term": map[string]interface{}{"currency": q.Currency}},
+					map[string]interface{}{"range": map[string]interface{}{"price_amount": bounds}},
+				},
+			},
+		}
+	}
+	// The value and range filters of an attribute form a single criterion,
+	// which its facet ignores.
+	attributeFilters := map[string][]interface{}{}
+	for name, values := range q.Attributes {
+		if len(values) == 0 {
+			continue
+		}
+		terms := make([]string, 0, len(values))
+		for _, v := range values {
+			terms = append(terms, attributeValue(name, v))
+		}
+		attributeFilters[name] = append(attributeFilters[name], map[string]interface{}{
+			"terms": map[string]interface{}{"attributes": terms},
+		})
+	}
+	for name, r := range q.AttributeRanges {
+		if r.Min == nil && r.Max == nil {
+			continue
+		}
+		attributeFilters[name] = append(attributeFilters[name], attributeRangeFilter(name, r))
+	}
+	var filteredAttributes []string
+	for name, clauses := range attributeFilters {
+		filters[facetAttribute+name] = map[string]interface{}{
+			"bool": map[string]interface{}{"filter": clauses},
+		}
+		filteredAttributes = append(filteredAttributes, name)
+	}
+	sort.Strings(filteredAttributes)
+
+	currency := map[string]interface{}{
+		"term": map[string]interface{}{"currency": q.Currency},
+	}
+	aggs := map[string]interface{}{
+		"categories": facet(filters, facetCategory, nil, map[string]interface{}{
+			"terms": map[string]interface{}{
+				"field": "category_ids",
+				"size":  maxCategoryFacets,
+			},
+		}),
+		"prices": facet(filters, facetPrice, currency, map[string]interface{}{
+			"range": priceRanges(q.Currency),
+		}),
+		"attributes": facet(filters, "", nil, map[string]interface{}{
+			"terms": map[string]interface{}{
+				"field": "attributes",
+				"size":  maxAttributeFacets,
+			},
+		}),
+	}
+	// Values of an attribute that is filtered on are counted without that
+	// filter, so the other values stay selectable.
+	for i, name := range filteredAttributes {
+		aggs[fmt.Sprintf("attribute_%d", i)] = facet(filters, facetAttribute+name, nil, map[string]interface{}{
+			"terms": map[string]interface{}{
+				"field":   "attributes",
+				"size":    maxAttributeFacets,
+				"include": quoteRegexp(attributeValue(name, "")) + ".*",
+			},
+		})
+	}
+
+	body := map[string]interface{}{
+		"from":                q.Skip,
+		"size":                q.Take,
+		"seq_no_primary_term": true,
+		"track_total_hits":    true,
+		"sort":                sortClause(q.Sort),
+		"query":               listed(textQuery(q.Text), nil),
+		"post_filter":         allFilters(filters, "", nil),
+		"aggs":                aggs,
+	}
+	if q.Text != "" {
+		body["highlight"] = map[string]interface{}{
+			"encoder":   "html",
+			"pre_tags":  []string{"<em>"},
+			"post_tags": []string{"</em>"},
+			"fields": map[string]interface{}{
+				"name":        map[string]interface{}{"number_of_fragments": 0},
+				"description": map[string]interface{}{"fragment_size": 150, "number_of_fragments": 3},
+			},
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return nil, err
+	}
+	res, err := esapi.SearchRequest{
+		Index: []string{productIndex},
+		Body:  &buf,
+	}.Do(ctx, r.client)
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("search", productIndex, time.Since(start))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return nil, fmt.Errorf("error searching products: %s", res.String())
+	}
+
+	var esResp esFacetSearchResponse
+	if err := json.NewDecoder(res.Body).Decode(&esResp); err != nil {
+		return nil, err
+	}
+	result := &SearchResult{
+		Products: esResp.products(),
+		Total:    esResp.Hits.Total.Value,
+		Facets: Facets{
+			Categories:  esResp.Aggregations["categories"].values(),
+			PriceRanges: []PriceRangeFacet{},
+		},
+		Highlights: map[string][]Highlight{},
+	}
+
+	for _, hit := range esResp.Hits.Hits {
+		for field, fragments := range hit.Highlight {
+			result.Highlights[hit.ID] = append(result.Highlights[hit.ID], Highlight{Field: field, Fragments: fragments})
+		}
+		sort.Slice(result.Highlights[hit.ID], func(i, j int) bool {
+			return result.Highlights[hit.ID][i].Field < result.Highlights[hit.ID][j].Field
+		})
+	}
+
+	for _, b := range esResp.Aggregations["prices"].Values.Buckets {
+		priceRange := PriceRangeFacet{Count: b.DocCount}
+		if b.From != nil {
+			min := money.New(int64(math.Round(*b.From)), q.Currency)
+			priceRange.Min = &min
+		}
+		if b.To != nil {
+			max := money.New(int64(math.Round(*b.To)), q.Currency)
+			priceRange.Max = &max
+		}
+		result.Facets.PriceRanges = append(result.Facets.PriceRanges, priceRange)
+	}
+
+	byName := map[string][]FacetValue{}
+	for _, v := range esResp.Aggregations["attributes"].values() {
+		name, value, _ := strings.Cut(v.Value, "=")
+		byName[name] = append(byName[name], FacetValue{Value: value, Count: v.Count})
+	}
+	for i, name := range filteredAttributes {
+		values := []FacetValue{}
+		for _, v := range esResp.Aggregations[fmt.Sprintf("attribute_%d", i)].values() {
+			_, value, _ := strings.Cut(v.Value, "=")
+			values = append(values, FacetValue{Value: value, Count: v.Count})
+		}
+		byName[name] = values
+	}
+	result.Facets.Attributes = make([]AttributeFacet, 0, len(byName))
+	for name, values := range byName {
+		result.Facets.Attributes = append(result.Facets.Attributes, AttributeFacet{Name: name, Values: values})
+	}
+	sort.Slice(result.Facets.Attributes, func(i, j int) bool {
+		return result.Facets.Attributes[i].Name < result.Facets.Attributes[j].Name
+	})
+	return result, nil
+}
+
+// textQuery matches text against searchFields, or everything if text is
+// empty. Texts of up to two terms have to match completely, longer ones in
+// three quarters of their terms. Terms of three or more characters may have
+// typos.
+func textQuery(text string) map[string]interface{} {
+	if text == "" {
+		return map[string]interface{}{"match_all": map[string]interface{}{}}
+	}
+	return map[string]interface{}{
+		"multi_match": map[string]interface{}{
+			"query":                text,
+			"fields":               searchFields,
+			"type":                 "best_fields",
+			"tie_breaker":          0.3,
+			"minimum_should_match": "2<75%",
+			"fuzziness":            "AUTO",
+			"prefix_length":        1,
+		},
+	}
+}
+
+// facet runs aggregation as "values" on the matches of every criterion
+// but except, restricted further by extra if it is set.
+func facet(filters map[string]interface{}, except string, extra, aggregation map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"filter": allFilters(filters, except, extra),
+		"aggs": map[string]interface{}{
+			"values": aggregation,
+		},
+	}
+}
+
+// allFilters combines every filter but except, and extra if it is set.
+func allFilters(filters map[string]interface{}, except string, extra map[string]interface{}) map[string]interface{} {
+	clauses := []interface{}{}
+	for key, f := range filters {
+		if key != except {
+			clauses = append(clauses, f)
+		}
+	}
+	if extra != nil {
+		clauses = append(clauses, extra)
+	}
+	return map[string]interface{}{
+		"bool": map[string]interface{}{"filter": clauses},
+	}
+}
+
+// priceRanges is the range aggregation over prices in currency.
+func priceRanges(currency string) map[string]interface{} {
+	unit := int64(math.Pow10(money.Exponent(currency)))
+	ranges := []interface{}{map[string]interface{}{"to": priceRangeBounds[0] * unit}}
+	for i := 1; i < len(priceRangeBounds); i++ {
+		ranges = append(ranges, map[string]interface{}{
+			"from": priceRangeBounds[i-1] * unit,
+			"to":   priceRangeBounds[i] * unit,
+		})
+	}
+	ranges = append(ranges, map[string]interface{}{"from": priceRangeBounds[len(priceRangeBounds)-1] * unit})
+	return map[string]interface{}{
+		"field":  "price_amount",
+		"ranges": ranges,
+	}
+}
+
+// quoteRegexp escapes every character of s that is not a letter or digit,
+// so it matches literally in an Elasticsearch regular expression.
+func quoteRegexp(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
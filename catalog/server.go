@@ -2,90 +2,499 @@ package catalog
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 
+	"github.com/master-wayne7/go-microservices/auth"
 	"github.com/master-wayne7/go-microservices/catalog/pb"
+	"github.com/master-wayne7/go-microservices/idempotency"
+	"github.com/master-wayne7/go-microservices/money"
 	"github.com/master-wayne7/go-microservices/monitoring"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 )
 
 type grpcServer struct {
 	pb.UnimplementedCatalogServiceServer
-	service Service
+	service     Service
+	idempotency idempotency.Store
 }
 
-func ListenGRPC(s Service, port int, metrics *monitoring.MetricsCollector) error {
+func ListenGRPC(s Service, store idempotency.Store, signer *auth.Signer, port int, metrics *monitoring.MetricsCollector) error {
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
 	if err != nil {
 		return err
 	}
 
-	// Add gRPC interceptors for metrics
+	// Add gRPC interceptors for metrics, then authentication and
+	// per-method permissions
 	serv := grpc.NewServer(
-		grpc.UnaryInterceptor(monitoring.GRPCUnaryServerInterceptor(metrics)),
-		grpc.StreamInterceptor(monitoring.GRPCStreamServerInterceptor(metrics)),
+		grpc.ChainUnaryInterceptor(
+			monitoring.GRPCUnaryServerInterceptor(metrics),
+			auth.UnaryServerInterceptor(signer, permissions),
+		),
+		grpc.ChainStreamInterceptor(
+			monitoring.GRPCStreamServerInterceptor(metrics),
+			auth.StreamServerInterceptor(signer, permissions),
+		),
 	)
-	pb.RegisterCatalogServiceServer(serv, &grpcServer{service: s, UnimplementedCatalogServiceServer: pb.UnimplementedCatalogServiceServer{}})
+	pb.RegisterCatalogServiceServer(serv, &grpcServer{service: s, idempotency: store, UnimplementedCatalogServiceServer: pb.UnimplementedCatalogServiceServer{}})
 	reflection.Register(serv)
 	return serv.Serve(lis)
 }
 
 func (s *grpcServer) PostProduct(ctx context.Context, r *pb.PostProductRequest) (*pb.PostProductResponse, error) {
-	p, err := s.service.PostProduct(ctx, r.Name, r.Description, r.Price)
+	return idempotency.Do(ctx, s.idempotency, "PostProduct", r.IdempotencyKey, r, func() (*pb.PostProductResponse, error) {
+		p, err := s.service.PostProduct(ctx, r.Name, r.Description, moneyFromProto(r.PriceMoney, r.Price), r.CategoryIds, r.Attributes, variantsFromProto(r.Variants))
+		if err != nil {
+			return nil, catalogError(err)
+		}
+		return &pb.PostProductResponse{
+			Product: productToProto(*p),
+		}, nil
+	})
+}
+
+func (s *grpcServer) GetProduct(ctx context.Context, r *pb.GetProductRequest) (*pb.GetProductResponse, error) {
+	var p *Product
+	var err error
+	if r.Sku != "" {
+		p, err = s.service.GetProductBySKU(ctx, r.Sku)
+	} else {
+		p, err = s.service.GetProduct(ctx, r.Id)
+	}
 	if err != nil {
-		return nil, err
+		return nil, catalogError(err)
 	}
-	return &pb.PostProductResponse{
-		Product: &pb.Product{
-			Id:          p.ID,
-			Name:        p.Name,
-			Price:       p.Price,
-			Description: p.Description,
-		},
+	return &pb.GetProductResponse{
+		Product: productToProto(*p),
 	}, nil
 }
 
-func (s *grpcServer) GetProduct(ctx context.Context, r *pb.GetProductRequest) (*pb.GetProductResponse, error) {
-	p, err := s.service.GetProduct(ctx, r.Id)
+func (s *grpcServer) UpdateProduct(ctx context.Context, r *pb.UpdateProductRequest) (*pb.UpdateProductResponse, error) {
+	if r.PriceMoney == nil {
+		return nil, status.Error(codes.InvalidArgument, "priceMoney is required")
+	}
+	p, err := s.service.UpdateProduct(ctx, r.Id, r.Name, r.Description, moneyFromProto(r.PriceMoney, 0), r.CategoryIds, r.Attributes, versionFromProto(r.Version))
 	if err != nil {
-		return nil, err
+		return nil, catalogError(err)
 	}
-	return &pb.GetProductResponse{
-		Product: &pb.Product{
-			Id:          p.ID,
-			Name:        p.Name,
-			Price:       p.Price,
-			Description: p.Description,
-		},
+	return &pb.UpdateProductResponse{
+		Product: productToProto(*p),
+	}, nil
+}
+
+func (s *grpcServer) PatchProduct(ctx context.Context, r *pb.PatchProductRequest) (*pb.PatchProductResponse, error) {
+	if r.Product == nil {
+		r.Product = &pb.Product{}
+	}
+	var fields []string
+	for _, path := range r.UpdateMask.GetPaths() {
+		switch path {
+		case "priceMoney", "price_money":
+			if r.Product.PriceMoney == nil {
+				return nil, status.Error(codes.InvalidArgument, "priceMoney is in updateMask but not set")
+			}
+			fields = append(fields, FieldPrice)
+		case "categoryIds", "category_ids":
+			fields = append(fields, FieldCategories)
+		default:
+			fields = append(fields, path)
+		}
+	}
+	patch := ProductPatch{
+		Name:        r.Product.Name,
+		Description: r.Product.Description,
+		Price:       moneyFromProto(r.Product.PriceMoney, r.Product.Price),
+		CategoryIDs: r.Product.CategoryIds,
+		Attributes:  r.Product.Attributes,
+		Variants:    variantsFromProto(r.Product.Variants),
+	}
+	p, err := s.service.PatchProduct(ctx, r.Id, patch, fields, versionFromProto(r.Version))
+	if err != nil {
+		return nil, catalogError(err)
+	}
+	return &pb.PatchProductResponse{
+		Product: productToProto(*p),
+	}, nil
+}
+
+func (s *grpcServer) DeleteProduct(ctx context.Context, r *pb.DeleteProductRequest) (*pb.DeleteProductResponse, error) {
+	p, err := s.service.DeleteProduct(ctx, r.Id, versionFromProto(r.Version))
+	if err != nil {
+		return nil, catalogError(err)
+	}
+	return &pb.DeleteProductResponse{
+		Product: productToProto(*p),
 	}, nil
 }
 func (s *grpcServer) GetProducts(ctx context.Context, r *pb.GetProductsRequest) (*pb.GetProductsResponse, error) {
+	sort, err := ParseProductSort(r.Sort)
+	if err != nil {
+		return nil, catalogError(err)
+	}
 	var res []Product
-	var err error
 
 	if r.Query != "" {
-		res, err = s.service.SearchProducts(ctx, r.Query, r.Skip, r.Take)
+		var result *SearchResult
+		result, err = s.service.SearchProducts(ctx, SearchQuery{Text: r.Query, Category: r.CategoryId, Sort: sort, Skip: r.Skip, Take: r.Take})
+		if result != nil {
+			res = result.Products
+		}
 	} else if len(r.Ids) != 0 {
 		res, err = s.service.GetProductsByIDs(ctx, r.Ids)
+	} else if len(r.Skus) != 0 {
+		res, err = s.service.GetProductsBySKUs(ctx, r.Skus)
 	} else {
-		res, err = s.service.GetProducts(ctx, r.Skip, r.Take)
+		res, err = s.service.GetProducts(ctx, r.Skip, r.Take, r.CategoryId, sort)
 	}
 	if err != nil {
-		return nil, err
+		return nil, catalogError(err)
 	}
 
 	products := []*pb.Product{}
 	for _, p := range res {
-		products = append(products, &pb.Product{
-			Id:          p.ID,
-			Name:        p.Name,
-			Price:       p.Price,
-			Description: p.Description,
-		})
+		products = append(products, productToProto(p))
 	}
 	return &pb.GetProductsResponse{
 		Products: products,
 	}, nil
 }
+
+func (s *grpcServer) SearchProducts(ctx context.Context, r *pb.SearchProductsRequest) (*pb.SearchProductsResponse, error) {
+	sort, err := ParseProductSort(r.Sort)
+	if err != nil {
+		return nil, catalogError(err)
+	}
+	q := SearchQuery{
+		Text:     r.Query,
+		Currency: r.Currency,
+		Category: r.CategoryId,
+		Sort:     sort,
+		Skip:     r.Skip,
+		Take:     r.Take,
+	}
+	if r.MinPrice != nil {
+		min := moneyFromProto(r.MinPrice, 0)
+		q.MinPrice = &min
+	}
+	if r.MaxPrice != nil {
+		max := moneyFromProto(r.MaxPrice, 0)
+		q.MaxPrice = &max
+	}
+	if len(r.Attributes) > 0 {
+		q.Attributes = map[string][]string{}
+		for _, a := range r.Attributes {
+			q.Attributes[a.Name] = append(q.Attributes[a.Name], a.Values...)
+		}
+	}
+	if len(r.AttributeRanges) > 0 {
+		q.AttributeRanges = map[string]AttributeRange{}
+		for _, a := range r.AttributeRanges {
+			q.AttributeRanges[a.Name] = AttributeRange{Min: a.Min, Max: a.Max}
+		}
+	}
+	result, err := s.service.SearchProducts(ctx, q)
+	if err != nil {
+		return nil, catalogError(err)
+	}
+
+	products := make([]*pb.Product, 0, len(result.Products))
+	for _, p := range result.Products {
+		products = append(products, productToProto(p))
+	}
+	facets := &pb.Facets{
+		Categories:  facetValuesToProto(result.Facets.Categories),
+		PriceRanges: make([]*pb.PriceRangeFacet, 0, len(result.Facets.PriceRanges)),
+		Attributes:  make([]*pb.AttributeFacet, 0, len(result.Facets.Attributes)),
+	}
+	for _, f := range result.Facets.PriceRanges {
+		priceRange := &pb.PriceRangeFacet{Count: f.Count}
+		if f.Min != nil {
+			priceRange.Min = &pb.Money{Amount: f.Min.Amount, Currency: f.Min.Currency}
+		}
+		if f.Max != nil {
+			priceRange.Max = &pb.Money{Amount: f.Max.Amount, Currency: f.Max.Currency}
+		}
+		facets.PriceRanges = append(facets.PriceRanges, priceRange)
+	}
+	for _, f := range result.Facets.Attributes {
+		facets.Attributes = append(facets.Attributes, &pb.AttributeFacet{
+			Name:   f.Name,
+			Values: facetValuesToProto(f.Values),
+		})
+	}
+	highlights := []*pb.Highlight{}
+	for _, p := range result.Products {
+		for _, h := range result.Highlights[p.ID] {
+			highlights = append(highlights, &pb.Highlight{
+				ProductId: p.ID,
+				Field:     h.Field,
+				Fragments: h.Fragments,
+			})
+		}
+	}
+	return &pb.SearchProductsResponse{
+		Products:   products,
+		Total:      result.Total,
+		Facets:     facets,
+		Highlights: highlights,
+	}, nil
+}
+
+func (s *grpcServer) BulkImportProducts(stream grpc.ClientStreamingServer[pb.BulkImportProductsRequest, pb.BulkImportProductsResponse]) error {
+	res := &pb.BulkImportProductsResponse{Errors: []*pb.ImportError{}}
+	batch := make([]Product, 0, ImportBatchSize)
+	rows := make([]uint64, 0, ImportBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		errs, err := s.service.ImportProducts(stream.Context(), batch)
+		if err != nil {
+			return importFailure(err, res)
+		}
+		for i, err := range errs {
+			if err != nil {
+				res.Errors = append(res.Errors, &pb.ImportError{Row: rows[i], ProductId: batch[i].ID, Message: err.Error()})
+			} else {
+				res.Imported++
+			}
+		}
+		batch, rows = batch[:0], rows[:0]
+		return nil
+	}
+
+	var position uint64
+	for {
+		r, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		position++
+		row := r.Row
+		if row == 0 {
+			row = position
+		}
+		if r.Product == nil {
+			res.Errors = append(res.Errors, &pb.ImportError{Row: row, Message: ErrInvalidProduct.Error()})
+			continue
+		}
+		batch = append(batch, productFromProto(r.Product))
+		rows = append(rows, row)
+		if len(batch) == ImportBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	return stream.SendAndClose(res)
+}
+
+// importFailure reports a failed batch. Earlier batches stay imported, so
+// the result so far goes along as a detail of the status.
+func importFailure(err error, res *pb.BulkImportProductsResponse) error {
+	st := status.Convert(catalogError(err))
+	if detailed, detailErr := st.WithDetails(res); detailErr == nil {
+		return detailed.Err()
+	}
+	return st.Err()
+}
+
+func (s *grpcServer) ExportProducts(r *pb.ExportProductsRequest, stream grpc.ServerStreamingServer[pb.ExportProductsResponse]) error {
+	err := s.service.ExportProducts(stream.Context(), r.CategoryId, func(products []Product) error {
+		res := &pb.ExportProductsResponse{Products: make([]*pb.Product, 0, len(products))}
+		for _, p := range products {
+			res.Products = append(res.Products, productToProto(p))
+		}
+		return stream.Send(res)
+	})
+	if err != nil {
+		return catalogError(err)
+	}
+	return nil
+}
+
+func (s *grpcServer) Suggest(ctx context.Context, r *pb.SuggestRequest) (*pb.SuggestResponse, error) {
+	suggestions, err := s.service.Suggest(ctx, r.Prefix, int(r.Size))
+	if err != nil {
+		return nil, catalogError(err)
+	}
+	res := &pb.SuggestResponse{Suggestions: make([]*pb.Suggestion, 0, len(suggestions))}
+	for _, suggestion := range suggestions {
+		res.Suggestions = append(res.Suggestions, &pb.Suggestion{ProductId: suggestion.ID, Name: suggestion.Name})
+	}
+	return res, nil
+}
+
+func facetValuesToProto(values []FacetValue) []*pb.FacetValue {
+	result := make([]*pb.FacetValue, 0, len(values))
+	for _, v := range values {
+		result = append(result, &pb.FacetValue{Value: v.Value, Count: v.Count})
+	}
+	return result
+}
+
+func (s *grpcServer) CreateCategory(ctx context.Context, r *pb.CreateCategoryRequest) (*pb.CreateCategoryResponse, error) {
+	c, err := s.service.CreateCategory(ctx, r.Name, r.ParentId)
+	if err != nil {
+		return nil, catalogError(err)
+	}
+	return &pb.CreateCategoryResponse{Category: categoryToProto(*c)}, nil
+}
+
+func (s *grpcServer) RenameCategory(ctx context.Context, r *pb.RenameCategoryRequest) (*pb.RenameCategoryResponse, error) {
+	c, err := s.service.RenameCategory(ctx, r.Id, r.Name)
+	if err != nil {
+		return nil, catalogError(err)
+	}
+	return &pb.RenameCategoryResponse{Category: categoryToProto(*c)}, nil
+}
+
+func (s *grpcServer) MoveCategory(ctx context.Context, r *pb.MoveCategoryRequest) (*pb.MoveCategoryResponse, error) {
+	c, err := s.service.MoveCategory(ctx, r.Id, r.ParentId)
+	if err != nil {
+		return nil, catalogError(err)
+	}
+	return &pb.MoveCategoryResponse{Category: categoryToProto(*c)}, nil
+}
+
+func (s *grpcServer) DeleteCategory(ctx context.Context, r *pb.DeleteCategoryRequest) (*pb.DeleteCategoryResponse, error) {
+	c, err := s.service.DeleteCategory(ctx, r.Id)
+	if err != nil {
+		return nil, catalogError(err)
+	}
+	return &pb.DeleteCategoryResponse{Category: categoryToProto(*c)}, nil
+}
+
+func (s *grpcServer) SetCategoryAttributes(ctx context.Context, r *pb.SetCategoryAttributesRequest) (*pb.SetCategoryAttributesResponse, error) {
+	c, err := s.service.SetCategoryAttributes(ctx, r.Id, attributeDefinitionsFromProto(r.Attributes))
+	if err != nil {
+		return nil, catalogError(err)
+	}
+	return &pb.SetCategoryAttributesResponse{Category: categoryToProto(*c)}, nil
+}
+
+func (s *grpcServer) GetCategories(ctx context.Context, r *pb.GetCategoriesRequest) (*pb.GetCategoriesResponse, error) {
+	res, err := s.service.GetCategories(ctx)
+	if err != nil {
+		return nil, catalogError(err)
+	}
+	categories := make([]*pb.Category, 0, len(res))
+	for _, c := range res {
+		categories = append(categories, categoryToProto(c))
+	}
+	return &pb.GetCategoriesResponse{Categories: categories}, nil
+}
+
+func categoryToProto(c Category) *pb.Category {
+	return &pb.Category{
+		Id:         c.ID,
+		Name:       c.Name,
+		ParentId:   c.ParentID,
+		Path:       c.Path,
+		Attributes: attributeDefinitionsToProto(c.Attributes),
+	}
+}
+
+func attributeDefinitionsToProto(definitions []AttributeDefinition) []*pb.AttributeDefinition {
+	result := make([]*pb.AttributeDefinition, 0, len(definitions))
+	for _, d := range definitions {
+		result = append(result, &pb.AttributeDefinition{
+			Name:     d.Name,
+			Type:     string(d.Type),
+			Values:   d.Values,
+			Required: d.Required,
+		})
+	}
+	return result
+}
+
+func attributeDefinitionsFromProto(definitions []*pb.AttributeDefinition) []AttributeDefinition {
+	result := make([]AttributeDefinition, 0, len(definitions))
+	for _, d := range definitions {
+		result = append(result, AttributeDefinition{
+			Name:     d.Name,
+			Type:     AttributeType(d.Type),
+			Values:   d.Values,
+			Required: d.Required,
+		})
+	}
+	return result
+}
+
+func productToProto(p Product) *pb.Product {
+	product := &pb.Product{
+		Id:          p.ID,
+		Name:        p.Name,
+		Price:       p.Price.Float64(),
+		PriceMoney:  &pb.Money{Amount: p.Price.Amount, Currency: p.Price.Currency},
+		Description: p.Description,
+		Version:     &pb.Version{SeqNo: p.Version.SeqNo, PrimaryTerm: p.Version.PrimaryTerm},
+		CategoryIds: p.CategoryIDs,
+		Attributes:  p.Attributes,
+		Variants:    variantsToProto(p.Variants),
+	}
+	if !p.CreatedAt.IsZero() {
+		product.CreatedAt, _ = p.CreatedAt.MarshalBinary()
+	}
+	if p.IsDeleted() {
+		product.DeletedAt, _ = p.DeletedAt.MarshalBinary()
+	}
+	return product
+}
+
+// variantsFromProto leaves the price of variants without one zero, so they
+// get the product's price.
+func variantsFromProto(variants []*pb.Variant) []Variant {
+	result := make([]Variant, 0, len(variants))
+	for _, v := range variants {
+		variant := Variant{SKU: v.Sku, Attributes: v.Attributes}
+		if v.Price != nil {
+			variant.Price = money.New(v.Price.Amount, v.Price.Currency)
+		}
+		result = append(result, variant)
+	}
+	return result
+}
+
+func versionFromProto(v *pb.Version) Version {
+	return Version{SeqNo: v.GetSeqNo(), PrimaryTerm: v.GetPrimaryTerm()}
+}
+
+// catalogError maps service errors onto gRPC status codes.
+func catalogError(err error) error {
+	switch {
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrCategoryNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, ErrInvalidProduct), errors.Is(err, ErrInvalidCategory), errors.Is(err, ErrInvalidSort),
+		errors.Is(err, money.ErrInvalidCurrency), errors.Is(err, money.ErrCurrencyMismatch):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, ErrProductExists), errors.Is(err, ErrSKUExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, ErrDeleted), errors.Is(err, ErrCategoryNotEmpty):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, ErrVersionConflict):
+		return status.Error(codes.Aborted, err.Error())
+	}
+	return auth.StatusError(err)
+}
+
+// moneyFromProto prefers the exact price and falls back to the legacy float
+// field for clients that do not send one.
+func moneyFromProto(m *pb.Money, legacy float64) money.Money {
+	if m == nil {
+		return money.FromFloat(legacy, money.DefaultCurrency)
+	}
+	return money.New(m.Amount, m.Currency)
+}
@@ -2,65 +2,402 @@ package catalog
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/master-wayne7/go-microservices/events"
+	"github.com/master-wayne7/go-microservices/money"
 	"github.com/segmentio/ksuid"
 )
 
+const (
+	MaxNameLength = 200
+	// Limits on the free-form attributes of a product.
+	MaxAttributes           = 50
+	MaxAttributeNameLength  = 100
+	MaxAttributeValueLength = 200
+)
+
+var (
+	ErrInvalidProduct = errors.New("invalid product")
+	ErrDeleted        = errors.New("product is deleted")
+)
+
+// Fields PatchProduct can change.
+const (
+	FieldName        = "name"
+	FieldDescription = "description"
+	FieldPrice       = "price"
+	FieldCategories  = "categories"
+	FieldAttributes  = "attributes"
+	FieldVariants    = "variants"
+)
+
 type Service interface {
-	PostProduct(ctx context.Context, name, description string, price float64) (*Product, error)
+	PostProduct(ctx context.Context, name, description string, price money.Money, categoryIDs []string, attributes map[string]string, variants []Variant) (*Product, error)
 	GetProduct(ctx context.Context, id string) (*Product, error)
-	GetProducts(ctx context.Context, skip, take uint64) ([]Product, error)
+	// GetProductBySKU returns the product with the variant sku, or the
+	// product without variants whose ID is sku.
+	GetProductBySKU(ctx context.Context, sku string) (*Product, error)
+	// GetProducts only returns products in category or its subcategories
+	// unless category is empty.
+	GetProducts(ctx context.Context, skip, take uint64, category string, sort ProductSort) ([]Product, error)
 	GetProductsByIDs(ctx context.Context, ids []string) ([]Product, error)
-	SearchProducts(ctx context.Context, query string, skip, take uint64) ([]Product, error)
+	// GetProductsBySKUs returns the products having any of skus, each once.
+	GetProductsBySKUs(ctx context.Context, skus []string) ([]Product, error)
+	SearchProducts(ctx context.Context, q SearchQuery) (*SearchResult, error)
+	// Suggest returns up to size products whose names match the words typed
+	// so far, for search-as-you-type.
+	Suggest(ctx context.Context, prefix string, size int) ([]Suggestion, error)
+	// UpdateProduct replaces the name, description, price, categories and
+	// attributes of a product. Its variants are kept.
+	UpdateProduct(ctx context.Context, id, name, description string, price money.Money, categoryIDs []string, attributes map[string]string, version Version) (*Product, error)
+	// PatchProduct sets only the fields named in fields to their values in
+	// patch.
+	PatchProduct(ctx context.Context, id string, patch ProductPatch, fields []string, version Version) (*Product, error)
+	DeleteProduct(ctx context.Context, id string, version Version) (*Product, error)
+	// ImportProducts creates products in bulk. It returns an error per
+	// product, nil for the ones created.
+	ImportProducts(ctx context.Context, products []Product) ([]error, error)
+	// ExportProducts passes all products that are not deleted to fn, page by
+	// page, optionally only those in category or its subcategories.
+	ExportProducts(ctx context.Context, category string, fn func([]Product) error) error
+
+	CreateCategory(ctx context.Context, name, parentID string) (*Category, error)
+	RenameCategory(ctx context.Context, id, name string) (*Category, error)
+	MoveCategory(ctx context.Context, id, parentID string) (*Category, error)
+	DeleteCategory(ctx context.Context, id string) (*Category, error)
+	// SetCategoryAttributes replaces the attribute definitions of a
+	// category. Its subcategories inherit them.
+	SetCategoryAttributes(ctx context.Context, id string, attributes []AttributeDefinition) (*Category, error)
+	GetCategories(ctx context.Context) ([]Category, error)
 }
 
 type Product struct {
-	ID          string  `json:"id"`
-	Name        string  `json:"name"`
-	Price       float64 `json:"price"`
-	Description string  `json:"description"`
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Price       money.Money `json:"price"`
+	Description string      `json:"description"`
+	CategoryIDs []string    `json:"categoryIds,omitempty"`
+	// Attributes are properties such as "color": "red", used to filter and
+	// facet searches. Those defined by the product's categories are checked
+	// against their definitions; the others are free-form.
+	Attributes map[string]string `json:"attributes,omitempty"`
+	// Variants are the options the product is sold in. Without variants the
+	// product itself is sold, at Price.
+	Variants []Variant `json:"variants,omitempty"`
+	// CreatedAt is zero for products created before it was recorded.
+	CreatedAt time.Time `json:"createdAt,omitzero"`
+	// DeletedAt is set on deleted products. They are left out of listings
+	// and searches but still resolve by ID, so orders keep showing them.
+	DeletedAt time.Time `json:"deletedAt,omitzero"`
+	// Version is the revision the product was read at.
+	Version Version `json:"-"`
+}
+
+func (p Product) IsDeleted() bool {
+	return !p.DeletedAt.IsZero()
+}
+
+// ProductPatch holds the new values for PatchProduct.
+type ProductPatch struct {
+	Name        string
+	Description string
+	Price       money.Money
+	CategoryIDs []string
+	Attributes  map[string]string
+	Variants    []Variant
+}
+
+// Version is the Elasticsearch sequence number and primary term of a
+// product document. Writes conditioned on it fail with ErrVersionConflict
+// once anybody else changed the product. The zero Version skips the check.
+type Version struct {
+	SeqNo       int64
+	PrimaryTerm int64
+}
+
+func (v Version) IsZero() bool {
+	return v.PrimaryTerm == 0
+}
+
+// String formats v as "<seqNo>:<primaryTerm>", e.g. for GraphQL clients.
+func (v Version) String() string {
+	return fmt.Sprintf("%d:%d", v.SeqNo, v.PrimaryTerm)
+}
+
+// ParseVersion reads a Version formatted by String.
+func ParseVersion(s string) (Version, error) {
+	seqNo, primaryTerm, ok := strings.Cut(s, ":")
+	if !ok {
+		return Version{}, fmt.Errorf("%w: malformed version %q", ErrInvalidProduct, s)
+	}
+	var v Version
+	var err error
+	if v.SeqNo, err = strconv.ParseInt(seqNo, 10, 64); err != nil || v.SeqNo < 0 {
+		return Version{}, fmt.Errorf("%w: malformed version %q", ErrInvalidProduct, s)
+	}
+	if v.PrimaryTerm, err = strconv.ParseInt(primaryTerm, 10, 64); err != nil || v.PrimaryTerm <= 0 {
+		return Version{}, fmt.Errorf("%w: malformed version %q", ErrInvalidProduct, s)
+	}
+	return v, nil
 }
 
 type catalogService struct {
 	repository Repository
 }
 
-// GetProduct implements Service.
+// GetProduct implements Service. Deleted products are returned as well.
 func (c *catalogService) GetProduct(ctx context.Context, id string) (*Product, error) {
 	return c.repository.GetProductById(ctx, id)
 }
 
 // GetProducts implements Service.
-func (c *catalogService) GetProducts(ctx context.Context, skip uint64, take uint64) ([]Product, error) {
+func (c *catalogService) GetProducts(ctx context.Context, skip uint64, take uint64, category string, sort ProductSort) ([]Product, error) {
 	if take > 100 || (skip == 0 && take == 0) {
 		take = 100
 	}
-	return c.repository.ListProducts(ctx, skip, take)
+	categoryIDs, err := c.categoryFilter(ctx, category)
+	if err != nil {
+		return nil, err
+	}
+	return c.repository.ListProducts(ctx, skip, take, categoryIDs, sort)
 }
 
-// GetProductsByIDs implements Service.
+// GetProductsByIDs implements Service. Deleted products are returned as
+// well.
 func (c *catalogService) GetProductsByIDs(ctx context.Context, ids []string) ([]Product, error) {
 	return c.repository.ListProductsWithIDs(ctx, ids)
 }
 
 // PostProduct implements Service.
-func (c *catalogService) PostProduct(ctx context.Context, name string, description string, price float64) (*Product, error) {
+func (c *catalogService) PostProduct(ctx context.Context, name string, description string, price money.Money, categoryIDs []string, attributes map[string]string, variants []Variant) (*Product, error) {
 	p := Product{
 		ID:          ksuid.New().String(),
 		Name:        name,
 		Description: description,
 		Price:       price,
+		CategoryIDs: categoryIDs,
+		Attributes:  attributes,
+		Variants:    variants,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if err := validateProduct(&p); err != nil {
+		return nil, err
+	}
+	if err := c.checkCategories(ctx, &p); err != nil {
+		return nil, err
+	}
+	if err := c.checkSKUs(ctx, &p); err != nil {
+		return nil, err
 	}
 	err := c.repository.PutProduct(ctx, p)
 	return &p, err
 }
 
 // SearchProducts implements Service.
-func (c *catalogService) SearchProducts(ctx context.Context, query string, skip uint64, take uint64) ([]Product, error) {
-	if take > 100 || (skip == 0 && take == 0) {
-		take = 100
+func (c *catalogService) SearchProducts(ctx context.Context, q SearchQuery) (*SearchResult, error) {
+	if q.Take > 100 || (q.Skip == 0 && q.Take == 0) {
+		q.Take = 100
+	}
+	q.Text = strings.TrimSpace(q.Text)
+	for _, bound := range []*money.Money{q.MinPrice, q.MaxPrice} {
+		if bound == nil {
+			continue
+		}
+		if q.Currency == "" {
+			q.Currency = bound.Currency
+		}
+		if bound.Currency != q.Currency {
+			return nil, fmt.Errorf("%w: price bounds must be in %s", money.ErrCurrencyMismatch, q.Currency)
+		}
+	}
+	if q.Currency == "" {
+		q.Currency = money.DefaultCurrency
+	}
+	categoryIDs, err := c.categoryFilter(ctx, q.Category)
+	if err != nil {
+		return nil, err
+	}
+	if q.Attributes, err = c.canonicalFilters(ctx, q.Category, q.Attributes); err != nil {
+		return nil, err
+	}
+	return c.repository.SearchProducts(ctx, q, categoryIDs)
+}
+
+// canonicalFilters puts the values of attribute filters in the canonical
+// form products store, by the definitions of category and its ancestors, so
+// e.g. "10.0" finds products whose value is "10". Attributes they do not
+// define are matched as given.
+func (c *catalogService) canonicalFilters(ctx context.Context, category string, filters map[string][]string) (map[string][]string, error) {
+	if category == "" || len(filters) == 0 {
+		return filters, nil
+	}
+	byID, err := c.categoriesWithAncestors(ctx, []string{category})
+	if err != nil {
+		return nil, err
+	}
+	schema, err := attributeSchema([]string{category}, byID)
+	if err != nil || len(schema) == 0 {
+		return filters, err
+	}
+	canonical := make(map[string][]string, len(filters))
+	for name, values := range filters {
+		d, ok := schema[name]
+		if !ok {
+			canonical[name] = values
+			continue
+		}
+		for _, value := range values {
+			v, err := d.value(value)
+			if err != nil {
+				return nil, err
+			}
+			canonical[name] = append(canonical[name], v)
+		}
+	}
+	return canonical, nil
+}
+
+// UpdateProduct implements Service.
+func (c *catalogService) UpdateProduct(ctx context.Context, id, name, description string, price money.Money, categoryIDs []string, attributes map[string]string, version Version) (*Product, error) {
+	return c.change(ctx, id, version, events.ProductUpdated, func(p *Product) error {
+		p.Name = name
+		p.Description = description
+		p.Price = price
+		p.CategoryIDs = categoryIDs
+		p.Attributes = attributes
+		return nil
+	})
+}
+
+// PatchProduct implements Service.
+func (c *catalogService) PatchProduct(ctx context.Context, id string, patch ProductPatch, fields []string, version Version) (*Product, error) {
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%w: no fields to patch", ErrInvalidProduct)
+	}
+	return c.change(ctx, id, version, events.ProductUpdated, func(p *Product) error {
+		for _, field := range fields {
+			switch field {
+			case FieldName:
+				p.Name = patch.Name
+			case FieldDescription:
+				p.Description = patch.Description
+			case FieldPrice:
+				p.Price = patch.Price
+			case FieldCategories:
+				p.CategoryIDs = patch.CategoryIDs
+			case FieldAttributes:
+				p.Attributes = patch.Attributes
+			case FieldVariants:
+				p.Variants = patch.Variants
+			default:
+				return fmt.Errorf("%w: unknown field %q", ErrInvalidProduct, field)
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteProduct implements Service. Deletion is soft: the document stays so
+// orders can still resolve the product.
+func (c *catalogService) DeleteProduct(ctx context.Context, id string, version Version) (*Product, error) {
+	return c.change(ctx, id, version, events.ProductDeleted, func(p *Product) error {
+		p.DeletedAt = time.Now().UTC()
+		return nil
+	})
+}
+
+// change applies mutate to the stored product and saves it with an event of
+// eventType if nobody else changed it in the meantime. version must match
+// the stored version unless it is zero. Deleted products cannot be changed.
+func (c *catalogService) change(ctx context.Context, id string, version Version, eventType string, mutate func(*Product) error) (*Product, error) {
+	p, err := c.repository.GetProductById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !version.IsZero() && version != p.Version {
+		return nil, fmt.Errorf("%w: expected version %s, found %s", ErrVersionConflict, version, p.Version)
+	}
+	if p.IsDeleted() {
+		return nil, fmt.Errorf("%w: %s", ErrDeleted, p.ID)
+	}
+	if err := mutate(p); err != nil {
+		return nil, err
+	}
+	if err := validateProduct(p); err != nil {
+		return nil, err
+	}
+	if err := c.checkCategories(ctx, p); err != nil {
+		return nil, err
+	}
+	if err := c.checkSKUs(ctx, p); err != nil {
+		return nil, err
+	}
+	if p.Version, err = c.repository.UpdateProduct(ctx, *p, eventType); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// validateProduct trims the name, attributes and variants and checks them
+// and the price.
+func validateProduct(p *Product) error {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" || utf8.RuneCountInString(p.Name) > MaxNameLength {
+		return fmt.Errorf("%w: name must have 1 to %d characters", ErrInvalidProduct, MaxNameLength)
+	}
+	if p.Price.Amount < 0 {
+		return fmt.Errorf("%w: negative price", ErrInvalidProduct)
+	}
+	if !money.ValidCurrency(p.Price.Currency) {
+		return fmt.Errorf("%w: %q", money.ErrInvalidCurrency, p.Price.Currency)
+	}
+	if err := normalizeAttributes(p); err != nil {
+		return err
+	}
+	return normalizeVariants(p)
+}
+
+// normalizeAttributes trims attribute names and values. Names cannot contain
+// "=", which separates them from values in the index.
+func normalizeAttributes(p *Product) error {
+	attributes, err := normalizeAttributeMap(p.Attributes)
+	if err != nil {
+		return err
+	}
+	p.Attributes = attributes
+	return nil
+}
+
+// normalizeAttributeMap checks and trims the attributes of a product or
+// variant. No attributes are returned as nil.
+func normalizeAttributeMap(in map[string]string) (map[string]string, error) {
+	if len(in) == 0 {
+		return nil, nil
+	}
+	if len(in) > MaxAttributes {
+		return nil, fmt.Errorf("%w: more than %d attributes", ErrInvalidProduct, MaxAttributes)
+	}
+	attributes := make(map[string]string, len(in))
+	for name, value := range in {
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if name == "" || utf8.RuneCountInString(name) > MaxAttributeNameLength || strings.Contains(name, "=") {
+			return nil, fmt.Errorf("%w: attribute name %q", ErrInvalidProduct, name)
+		}
+		if value == "" || utf8.RuneCountInString(value) > MaxAttributeValueLength {
+			return nil, fmt.Errorf("%w: value of attribute %q", ErrInvalidProduct, name)
+		}
+		if _, ok := attributes[name]; ok {
+			return nil, fmt.Errorf("%w: duplicate attribute %q", ErrInvalidProduct, name)
+		}
+		attributes[name] = value
 	}
-	return c.repository.SearchProducts(ctx, query, skip, take)
+	return attributes, nil
 }
 
 func NewService(r Repository) Service {
@@ -0,0 +1,62 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidSort = errors.New("invalid product sort")
+
+// ProductSort orders listings and searches. Products that sort equally are
+// ordered by ID, so pages stay consistent.
+type ProductSort string
+
+const (
+	// SortRelevance puts the best matches of the search text first. Without
+	// search text all products are equally relevant.
+	SortRelevance ProductSort = "relevance"
+	// SortPriceAsc and SortPriceDesc compare amounts in minor units,
+	// whatever their currency.
+	SortPriceAsc  ProductSort = "price_asc"
+	SortPriceDesc ProductSort = "price_desc"
+	// SortName orders by name, ignoring case and accents.
+	SortName ProductSort = "name"
+	// SortNewest puts recently created products first.
+	SortNewest ProductSort = "newest"
+)
+
+// ParseProductSort reads a sort option; the empty string means relevance.
+func ParseProductSort(s string) (ProductSort, error) {
+	switch sort := ProductSort(s); sort {
+	case "":
+		return SortRelevance, nil
+	case SortRelevance, SortPriceAsc, SortPriceDesc, SortName, SortNewest:
+		return sort, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidSort, s)
+}
+
+// sortClause is the Elasticsearch sort for sort, ending with the ID as
+// tiebreaker. Documents indexed before IDs and creation times were stored
+// come last.
+func sortClause(sort ProductSort) []interface{} {
+	field := func(name, order, fieldType string) map[string]interface{} {
+		return map[string]interface{}{
+			name: map[string]interface{}{"order": order, "missing": "_last", "unmapped_type": fieldType},
+		}
+	}
+	var clause []interface{}
+	switch sort {
+	case SortPriceAsc:
+		clause = append(clause, field("price_amount", "asc", "long"))
+	case SortPriceDesc:
+		clause = append(clause, field("price_amount", "desc", "long"))
+	case SortName:
+		clause = append(clause, field("name.keyword", "asc", "keyword"))
+	case SortNewest:
+		clause = append(clause, field("created_at", "desc", "date"))
+	default:
+		clause = append(clause, map[string]interface{}{"_score": "desc"})
+	}
+	return append(clause, field("id", "asc", "keyword"))
+}
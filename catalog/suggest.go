@@ -0,0 +1,99 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/elastic/go-elasticsearch/v9/esapi"
+)
+
+const (
+	DefaultSuggestions = 10
+	MaxSuggestions     = 20
+)
+
+// Suggestion is a product whose name starts with the words typed so far.
+type Suggestion struct {
+	ID   string
+	Name string
+}
+
+// Suggest implements Service. An empty prefix suggests nothing.
+func (c *catalogService) Suggest(ctx context.Context, prefix string, size int) ([]Suggestion, error) {
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		return []Suggestion{}, nil
+	}
+	if utf8.RuneCountInString(prefix) > MaxNameLength {
+		return nil, fmt.Errorf("%w: prefix longer than %d characters", ErrInvalidProduct, MaxNameLength)
+	}
+	if size <= 0 {
+		size = DefaultSuggestions
+	}
+	if size > MaxSuggestions {
+		size = MaxSuggestions
+	}
+	return c.repository.SuggestProducts(ctx, prefix, size)
+}
+
+// SuggestProducts implements Repository. Every word of prefix has to start a
+// word of the name; names containing prefix as a phrase rank first.
+func (r *elasticRepository) SuggestProducts(ctx context.Context, prefix string, size int) ([]Suggestion, error) {
+	start := time.Now()
+	body := map[string]interface{}{
+		"size":    size,
+		"_source": []string{"name"},
+		"query": listed(map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must": map[string]interface{}{
+					"match": map[string]interface{}{
+						"name.prefixes": map[string]interface{}{"query": prefix, "operator": "and"},
+					},
+				},
+				"should": map[string]interface{}{
+					"match_phrase_prefix": map[string]interface{}{"name": prefix},
+				},
+			},
+		}, nil),
+		"sort": sortClause(SortRelevance),
+	}
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return nil, err
+	}
+	res, err := esapi.SearchRequest{
+		Index: []string{productIndex},
+		Body:  &buf,
+	}.Do(ctx, r.client)
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("suggest", productIndex, time.Since(start))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return nil, fmt.Errorf("error suggesting products: %s", res.String())
+	}
+
+	var esResp esSearchResponse
+	if err := json.NewDecoder(res.Body).Decode(&esResp); err != nil {
+		return nil, err
+	}
+	suggestions := make([]Suggestion, 0, len(esResp.Hits.Hits))
+	for _, hit := range esResp.Hits.Hits {
+		var doc struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(hit.Source, &doc); err != nil {
+			continue
+		}
+		suggestions = append(suggestions, Suggestion{ID: hit.ID, Name: doc.Name})
+	}
+	return suggestions, nil
+}
@@ -0,0 +1,293 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v9/esapi"
+	"github.com/master-wayne7/go-microservices/money"
+)
+
+const (
+	MaxVariants  = 100
+	MaxSKULength = 64
+	// maxSKULookup is how many SKUs are looked up per search.
+	maxSKULookup = 1000
+)
+
+var ErrSKUExists = errors.New("SKU already exists")
+
+// skuPattern keeps SKUs printable and free of separators, so they can be
+// used in files, URLs and the stock table alike.
+var skuPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Variant is one purchasable option of a product, such as a size and colour.
+// Orders and stock refer to variants by SKU, which is unique across the
+// catalog.
+type Variant struct {
+	SKU string `json:"sku"`
+	// Attributes tell variants apart, e.g. "size": "M", "color": "red".
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Price      money.Money       `json:"price"`
+}
+
+// SKUs returns the SKUs of the variants of p. A product without variants
+// is sold as is, under its ID.
+func (p Product) SKUs() []string {
+	if len(p.Variants) == 0 {
+		return []string{p.ID}
+	}
+	skus := make([]string, 0, len(p.Variants))
+	for _, v := range p.Variants {
+		skus = append(skus, v.SKU)
+	}
+	return skus
+}
+
+// Variant returns the variant of p with sku. A product without variants has
+// a single one, whose SKU is the product ID.
+func (p Product) Variant(sku string) (Variant, bool) {
+	if len(p.Variants) == 0 {
+		return Variant{SKU: p.ID, Price: p.Price}, sku == p.ID
+	}
+	for _, v := range p.Variants {
+		if v.SKU == sku {
+			return v, true
+		}
+	}
+	return Variant{}, false
+}
+
+// normalizeVariants checks the variants of p and trims their SKUs and
+// attributes. A variant without a price costs as much as the product; any
+// other price has to be in the product's currency. No two variants may have
+// the same SKU or the same attributes.
+func normalizeVariants(p *Product) error {
+	if len(p.Variants) == 0 {
+		p.Variants = nil
+		return nil
+	}
+	if len(p.Variants) > MaxVariants {
+		return fmt.Errorf("%w: more than %d variants", ErrInvalidProduct, MaxVariants)
+	}
+	skus := map[string]bool{}
+	combinations := map[string]bool{}
+	for i := range p.Variants {
+		v := &p.Variants[i]
+		v.SKU = strings.TrimSpace(v.SKU)
+		if len(v.SKU) > MaxSKULength || !skuPattern.MatchString(v.SKU) {
+			return fmt.Errorf("%w: SKU %q must have 1 to %d letters, digits, '.', '_' or '-'", ErrInvalidProduct, v.SKU, MaxSKULength)
+		}
+		if skus[v.SKU] {
+			return fmt.Errorf("%w: duplicate SKU %q", ErrInvalidProduct, v.SKU)
+		}
+		skus[v.SKU] = true
+
+		attributes, err := normalizeAttributeMap(v.Attributes)
+		if err != nil {
+			return fmt.Errorf("variant %s: %w", v.SKU, err)
+		}
+		if len(attributes) == 0 {
+			return fmt.Errorf("%w: variant %s has no attributes", ErrInvalidProduct, v.SKU)
+		}
+		v.Attributes = attributes
+		key := variantKey(attributes)
+		if combinations[key] {
+			return fmt.Errorf("%w: variant %s has the same attributes as another", ErrInvalidProduct, v.SKU)
+		}
+		combinations[key] = true
+
+		if v.Price.IsZero() && v.Price.Currency == "" {
+			v.Price = p.Price
+		}
+		if v.Price.Amount < 0 {
+			return fmt.Errorf("%w: negative price for variant %s", ErrInvalidProduct, v.SKU)
+		}
+		if v.Price.Currency != p.Price.Currency {
+			return fmt.Errorf("%w: variant %s must be priced in %s", money.ErrCurrencyMismatch, v.SKU, p.Price.Currency)
+		}
+	}
+	return nil
+}
+
+// variantKey identifies a combination of attributes.
+func variantKey(attributes map[string]string) string {
+	values := make([]string, 0, len(attributes))
+	for name, value := range attributes {
+		values = append(values, attributeValue(name, value))
+	}
+	sort.Strings(values)
+	return strings.Join(values, "\x00")
+}
+
+// checkSKUs fails with ErrSKUExists if another product, deleted or not, has
+// one of the SKUs of p as a variant SKU or as its ID. Elasticsearch cannot
+// enforce this, so two products created at the same moment may still share
+// a SKU.
+func (c *catalogService) checkSKUs(ctx context.Context, p *Product) error {
+	owners, err := c.skuOwners(ctx, p.SKUs())
+	if err != nil {
+		return err
+	}
+	return skuTaken(p, owners)
+}
+
+// skuOwners maps each of skus to the IDs of the products having it.
+func (c *catalogService) skuOwners(ctx context.Context, skus []string) (map[string][]string, error) {
+	owners := map[string][]string{}
+	for chunk := range slices.Chunk(skus, maxSKULookup) {
+		products, err := c.repository.ListProductsWithSKUs(ctx, chunk)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range products {
+			for _, sku := range chunk {
+				if _, ok := p.Variant(sku); ok || p.ID == sku {
+					owners[sku] = append(owners[sku], p.ID)
+				}
+			}
+		}
+	}
+	return owners, nil
+}
+
+// skuTaken fails if a SKU of p belongs to another product.
+func skuTaken(p *Product, owners map[string][]string) error {
+	for _, sku := range p.SKUs() {
+		for _, id := range owners[sku] {
+			if id != p.ID {
+				return fmt.Errorf("%w: %s is taken by product %s", ErrSKUExists, sku, id)
+			}
+		}
+	}
+	return nil
+}
+
+// GetProductBySKU implements Service.
+func (c *catalogService) GetProductBySKU(ctx context.Context, sku string) (*Product, error) {
+	products, err := c.repository.ListProductsWithSKUs(ctx, []string{sku})
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range products {
+		if _, ok := p.Variant(sku); ok {
+			return &p, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: SKU %s", ErrNotFound, sku)
+}
+
+// GetProductsBySKUs implements Service. Deleted products are returned as
+// well.
+func (c *catalogService) GetProductsBySKUs(ctx context.Context, skus []string) ([]Product, error) {
+	if len(skus) == 0 {
+		return []Product{}, nil
+	}
+	products, err := c.repository.ListProductsWithSKUs(ctx, slices.Compact(slices.Sorted(slices.Values(skus))))
+	if err != nil {
+		return nil, err
+	}
+	// The lookup also matches products with variants by their ID, which is
+	// not a SKU of theirs.
+	return slices.DeleteFunc(products, func(p Product) bool {
+		return !slices.ContainsFunc(skus, func(sku string) bool {
+			_, ok := p.Variant(sku)
+			return ok
+		})
+	}), nil
+}
+
+// ListProductsWithSKUs implements Repository. It matches the SKUs against
+// both variant SKUs and product IDs.
+func (r *elasticRepository) ListProductsWithSKUs(ctx context.Context, skus []string) ([]Product, error) {
+	start := time.Now()
+	body := map[string]interface{}{
+		// Room for every SKU to be taken twice, so checkSKUs sees clashes.
+		"size":                2 * len(skus),
+		"seq_no_primary_term": true,
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"should": []interface{}{
+					map[string]interface{}{"terms": map[string]interface{}{"variants.sku": skus}},
+					map[string]interface{}{"ids": map[string]interface{}{"values": skus}},
+				},
+				"minimum_should_match": 1,
+			},
+		},
+	}
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return nil, err
+	}
+	res, err := esapi.SearchRequest{
+		Index: []string{productIndex},
+		Body:  &buf,
+	}.Do(ctx, r.client)
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("search", productIndex, time.Since(start))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return nil, fmt.Errorf("error looking up SKUs: %s", res.String())
+	}
+
+	var esResp esSearchResponse
+	if err := json.NewDecoder(res.Body).Decode(&esResp); err != nil {
+		return nil, err
+	}
+	return esResp.products(), nil
+}
+
+type variantDocument struct {
+	SKU string `json:"sku"`
+	// Attributes are "name=value" keywords like those of the product.
+	Attributes  []string `json:"attributes"`
+	PriceAmount int64    `json:"price_amount"`
+	Currency    string   `json:"currency"`
+}
+
+func newVariantDocuments(variants []Variant) []variantDocument {
+	if len(variants) == 0 {
+		return nil
+	}
+	docs := make([]variantDocument, 0, len(variants))
+	for _, v := range variants {
+		doc := variantDocument{
+			SKU:         v.SKU,
+			PriceAmount: v.Price.Amount,
+			Currency:    v.Price.Currency,
+		}
+		for name, value := range v.Attributes {
+			doc.Attributes = append(doc.Attributes, attributeValue(name, value))
+		}
+		sort.Strings(doc.Attributes)
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+func (d variantDocument) variant() Variant {
+	v := Variant{
+		SKU:   d.SKU,
+		Price: money.New(d.PriceAmount, d.Currency),
+	}
+	if len(d.Attributes) > 0 {
+		v.Attributes = make(map[string]string, len(d.Attributes))
+		for _, attribute := range d.Attributes {
+			name, value, _ := strings.Cut(attribute, "=")
+			v.Attributes[name] = value
+		}
+	}
+	return v
+}
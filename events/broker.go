@@ -0,0 +1,148 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// NewBroker picks a broker from a URL:
+//
+//	"" or memory://      in-process MemoryBroker, keeping only recent events
+//	file:///path/to/file FileBroker appending to the file
+func NewBroker(rawURL string) (Broker, error) {
+	if rawURL == "" {
+		return NewMemoryBroker(), nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "memory":
+		return NewMemoryBroker(), nil
+	case "file":
+		return NewFileBroker(u.Path)
+	}
+	return nil, fmt.Errorf("unsupported event broker %q", rawURL)
+}
+
+// MemoryBrokerCapacity is how many of the latest events a MemoryBroker
+// keeps for Events.
+const MemoryBrokerCapacity = 1000
+
+// MemoryBroker hands published events to subscribers synchronously and
+// keeps the latest MemoryBrokerCapacity of them in a ring buffer, so a
+// long-running process does not accumulate every event. It is meant for
+// tests and single-process setups.
+type MemoryBroker struct {
+	mu sync.Mutex
+	// events holds up to MemoryBrokerCapacity events; once full, next is
+	// the oldest, which the next event overwrites.
+	events      []Event
+	next        int
+	subscribers []func(Event)
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{}
+}
+
+// Subscribe registers handler for every event published from now on.
+func (b *MemoryBroker) Subscribe(handler func(Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, handler)
+}
+
+func (b *MemoryBroker) Publish(ctx context.Context, events ...Event) error {
+	b.mu.Lock()
+	for _, e := range events {
+		if len(b.events) < MemoryBrokerCapacity {
+			b.events = append(b.events, e)
+			continue
+		}
+		b.events[b.next] = e
+		b.next = (b.next + 1) % MemoryBrokerCapacity
+	}
+	subscribers := append([]func(Event){}, b.subscribers...)
+	b.mu.Unlock()
+
+	for _, e := range events {
+		for _, handler := range subscribers {
+			handler(e)
+		}
+	}
+	return nil
+}
+
+// Events returns the latest MemoryBrokerCapacity events published, oldest
+// first.
+func (b *MemoryBroker) Events() []Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append(append([]Event{}, b.events[b.next:]...), b.events[:b.next]...)
+}
+
+func (b *MemoryBroker) Close() error {
+	return nil
+}
+
+// FileBroker appends events to a file as newline-delimited JSON.
+type FileBroker struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFileBroker(path string) (*FileBroker, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileBroker{file: f}, nil
+}
+
+func (b *FileBroker) Publish(ctx context.Context, events ...Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	enc := json.NewEncoder(b.file)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return b.file.Sync()
+}
+
+func (b *FileBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.file.Close()
+}
+
+// ReadFile returns the events a FileBroker wrote to path.
+func ReadFile(path string) ([]Event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	events := []Event{}
+	dec := json.NewDecoder(f)
+	for dec.More() {
+		e := Event{}
+		if err := dec.Decode(&e); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
@@ -0,0 +1,73 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/segmentio/ksuid"
+)
+
+// Event types published by the services.
+const (
+	AccountCreated      = "AccountCreated"
+	AccountUpdated      = "AccountUpdated"
+	AccountDeactivated  = "AccountDeactivated"
+	AccountDeleted      = "AccountDeleted"
+	AccountRolesChanged = "AccountRolesChanged"
+
+	ProductCreated = "ProductCreated"
+	ProductUpdated = "ProductUpdated"
+	ProductDeleted = "ProductDeleted"
+
+	OrderPlaced        = "OrderPlaced"
+	OrderStatusChanged = "OrderStatusChanged"
+
+	RefundCreated       = "RefundCreated"
+	RefundStatusChanged = "RefundStatusChanged"
+
+	StockUpdated         = "StockUpdated"
+	ReservationCreated   = "ReservationCreated"
+	ReservationCommitted = "ReservationCommitted"
+	ReservationReleased  = "ReservationReleased"
+)
+
+// Event is a domain event. It is written to an outbox together with the
+// change it describes and published afterwards, at least once, so consumers
+// should deduplicate on ID.
+type Event struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	Payload       json.RawMessage `json:"payload"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+}
+
+func NewEvent(eventType, aggregateType, aggregateID string, payload interface{}) (Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{
+		ID:            ksuid.New().String(),
+		Type:          eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Payload:       data,
+		OccurredAt:    time.Now().UTC(),
+	}, nil
+}
+
+// Broker delivers published events to consumers.
+type Broker interface {
+	Publish(ctx context.Context, events ...Event) error
+	Close() error
+}
+
+// Source is an outbox the relay drains.
+type Source interface {
+	// Pending returns up to limit unpublished events, oldest first.
+	Pending(ctx context.Context, limit int) ([]Event, error)
+	MarkPublished(ctx context.Context, events []Event) error
+}
@@ -0,0 +1,114 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/master-wayne7/go-microservices/monitoring"
+)
+
+// The outbox table each Postgres-backed service creates in its up.sql:
+//
+//	CREATE TABLE IF NOT EXISTS outbox (
+//	    id CHAR(27) PRIMARY KEY,
+//	    type VARCHAR(64) NOT NULL,
+//	    aggregate_type VARCHAR(32) NOT NULL,
+//	    aggregate_id VARCHAR(64) NOT NULL,
+//	    payload JSONB NOT NULL,
+//	    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+//	    published_at TIMESTAMP WITH TIME ZONE
+//	);
+
+// WriteOutbox stores events in tx, so they are published if and only if the
+// change they describe is committed.
+func WriteOutbox(ctx context.Context, tx *sql.Tx, events ...Event) error {
+	for _, e := range events {
+		_, err := tx.ExecContext(
+			ctx,
+			"INSERT INTO outbox(id, type, aggregate_type, aggregate_id, payload, occurred_at) VALUES($1, $2, $3, $4, $5, $6)",
+			e.ID,
+			e.Type,
+			e.AggregateType,
+			e.AggregateID,
+			[]byte(e.Payload),
+			e.OccurredAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PostgresSource reads the outbox table for the relay.
+type PostgresSource struct {
+	db      *sql.DB
+	metrics *monitoring.MetricsCollector
+}
+
+func NewPostgresSource(db *sql.DB) *PostgresSource {
+	return &PostgresSource{db: db}
+}
+
+// Allow wiring metrics into the source
+func (s *PostgresSource) SetMetrics(mc *monitoring.MetricsCollector) {
+	s.metrics = mc
+}
+
+func (s *PostgresSource) Pending(ctx context.Context, limit int) ([]Event, error) {
+	start := time.Now()
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT id, type, aggregate_type, aggregate_id, payload, occurred_at
+		FROM outbox
+		WHERE published_at IS NULL
+		ORDER BY occurred_at, id
+		LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		if s.metrics != nil {
+			s.metrics.RecordDBQuery("select", "outbox", time.Since(start))
+		}
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []Event{}
+	for rows.Next() {
+		e := Event{}
+		var payload []byte
+		if err := rows.Scan(&e.ID, &e.Type, &e.AggregateType, &e.AggregateID, &payload, &e.OccurredAt); err != nil {
+			return nil, err
+		}
+		e.Payload = payload
+		events = append(events, e)
+	}
+	if s.metrics != nil {
+		s.metrics.RecordDBQuery("select", "outbox", time.Since(start))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (s *PostgresSource) MarkPublished(ctx context.Context, events []Event) error {
+	start := time.Now()
+	ids := make([]string, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+	_, err := s.db.ExecContext(
+		ctx,
+		"UPDATE outbox SET published_at = $2 WHERE id = ANY($1)",
+		pq.Array(ids),
+		time.Now().UTC(),
+	)
+	if s.metrics != nil {
+		s.metrics.RecordDBQuery("update", "outbox", time.Since(start))
+	}
+	return err
+}
@@ -0,0 +1,60 @@
+package events
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const relayBatchSize = 100
+
+// Relay moves events from an outbox to a broker. An event is marked
+// published only after the broker accepted it, so a crash in between
+// publishes it again.
+type Relay struct {
+	source Source
+	broker Broker
+}
+
+func NewRelay(source Source, broker Broker) *Relay {
+	return &Relay{source: source, broker: broker}
+}
+
+// Flush publishes pending events until the outbox is drained and returns how
+// many were published.
+func (r *Relay) Flush(ctx context.Context) (int, error) {
+	published := 0
+	for {
+		pending, err := r.source.Pending(ctx, relayBatchSize)
+		if err != nil {
+			return published, err
+		}
+		if len(pending) == 0 {
+			return published, nil
+		}
+		if err := r.broker.Publish(ctx, pending...); err != nil {
+			return published, err
+		}
+		if err := r.source.MarkPublished(ctx, pending); err != nil {
+			return published, err
+		}
+		published += len(pending)
+		if len(pending) < relayBatchSize {
+			return published, nil
+		}
+	}
+}
+
+// StartRelay flushes source into broker every interval.
+func StartRelay(source Source, broker Broker, interval time.Duration) {
+	r := NewRelay(source, broker)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := r.Flush(context.Background()); err != nil {
+				log.Println("Error relaying events: ", err)
+			}
+		}
+	}()
+}
@@ -5,10 +5,12 @@ go 1.25.0
 require (
 	github.com/99designs/gqlgen v0.17.78
 	github.com/elastic/go-elasticsearch/v9 v9.1.0
+	github.com/golang-jwt/jwt/v5 v5.3.1
 	github.com/prometheus/client_golang v1.20.5
 	github.com/shirou/gopsutil/v3 v3.24.5
 	github.com/tinrab/retry v1.0.0
 	github.com/vektah/gqlparser/v2 v2.5.30
+	golang.org/x/crypto v0.40.0
 	google.golang.org/protobuf v1.36.6
 )
 
@@ -3,7 +3,11 @@ package main
 import (
 	"context"
 	"log"
+	"strings"
 	"time"
+
+	"github.com/master-wayne7/go-microservices/account"
+	"github.com/master-wayne7/go-microservices/auth"
 )
 
 type accountResolver struct {
@@ -12,6 +16,9 @@ type accountResolver struct {
 
 // Orders implements AccountResolver.
 func (a *accountResolver) Orders(ctx context.Context, obj *Account) ([]*Order, error) {
+	if err := authorizeAccount(ctx, obj.ID); err != nil {
+		return nil, err
+	}
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
@@ -22,22 +29,31 @@ func (a *accountResolver) Orders(ctx context.Context, obj *Account) ([]*Order, e
 	}
 	var orders []*Order
 	for _, o := range orderList {
-		var products []*OrderedProducts
-		for _, p := range o.Products {
-			products = append(products, &OrderedProducts{
-				ID:          p.ID,
-				Description: p.Description,
-				Name:        p.Name,
-				Price:       p.Price,
-				Quantity:    int(p.Quantity),
-			})
-		}
-		orders = append(orders, &Order{
-			Products:   products,
-			ID:         o.ID,
-			CreatedAt:  o.CreatedAt,
-			TotalPrice: o.TotalPrice,
-		})
+		orders = append(orders, toOrder(&o))
 	}
 	return orders, nil
 }
+
+func toAccount(a *account.Account) *Account {
+	acc := &Account{
+		ID:        a.ID,
+		Name:      a.Name,
+		Status:    AccountStatus(strings.ToUpper(string(a.Status))),
+		Version:   int(a.Version),
+		UpdatedAt: a.UpdatedAt,
+		Roles:     toRoles(a.Roles),
+	}
+	if a.Email != "" {
+		acc.Email = &a.Email
+	}
+	return acc
+}
+
+func toAuthTokens(t *auth.Tokens) *AuthTokens {
+	return &AuthTokens{
+		AccessToken:           t.AccessToken,
+		AccessTokenExpiresAt:  t.AccessExpiresAt,
+		RefreshToken:          t.RefreshToken,
+		RefreshTokenExpiresAt: t.RefreshExpiresAt,
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/master-wayne7/go-microservices/auth"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// authenticate verifies the bearer token of a request and puts its principal
+// into the request context. Requests without a token continue anonymously
+// and are turned away by @auth; requests with a bad token are rejected.
+func authenticate(signer *auth.Signer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			if header == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			token, ok := auth.BearerToken(header)
+			if !ok {
+				writeAuthError(w, "malformed Authorization header")
+				return
+			}
+			principal, err := signer.Authenticate(token)
+			if err != nil {
+				writeAuthError(w, err.Error())
+				return
+			}
+			ctx := auth.NewContext(r.Context(), principal)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func writeAuthError(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(graphql.Response{
+		Errors: gqlerror.List{unauthenticated(message)},
+	})
+}
+
+// authDirective implements @auth.
+func authDirective(ctx context.Context, obj interface{}, next graphql.Resolver, requires Role) (interface{}, error) {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, unauthenticated(auth.ErrUnauthenticated.Error())
+	}
+	if !hasRole(p, requires) {
+		return nil, forbidden("requires role " + string(requires))
+	}
+	return next(ctx)
+}
+
+// hasRole reports whether p holds role or a role that includes it.
+func hasRole(p *auth.Principal, role Role) bool {
+	return p.HasRole(fromRole(role))
+}
+
+// authorizeAccount fails unless the caller is the account accountID or
+// staff, so customers can only see and change their own data.
+func authorizeAccount(ctx context.Context, accountID string) error {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return unauthenticated(auth.ErrUnauthenticated.Error())
+	}
+	if !p.ActsFor(accountID) {
+		return forbidden("not allowed to access account " + accountID)
+	}
+	return nil
+}
+
+// requireRole is @auth for resolvers whose requirement depends on their
+// arguments.
+func requireRole(ctx context.Context, role Role) error {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return unauthenticated(auth.ErrUnauthenticated.Error())
+	}
+	if !hasRole(p, role) {
+		return forbidden("requires role " + string(role))
+	}
+	return nil
+}
+
+// isStaff reports whether the caller may act for every account.
+func isStaff(ctx context.Context) bool {
+	p, ok := auth.FromContext(ctx)
+	return ok && p.HasRole(auth.RoleStaff)
+}
+
+func toRoles(roles []auth.Role) []Role {
+	result := make([]Role, 0, len(roles))
+	for _, r := range roles {
+		result = append(result, Role(strings.ToUpper(string(r))))
+	}
+	return result
+}
+
+func fromRole(r Role) auth.Role {
+	return auth.Role(strings.ToLower(string(r)))
+}
+
+// principalID is the account ID of the caller, empty for services and
+// anonymous callers.
+func principalID(ctx context.Context) string {
+	if p, ok := auth.FromContext(ctx); ok {
+		return p.AccountID
+	}
+	return ""
+}
+
+func unauthenticated(message string) *gqlerror.Error {
+	return &gqlerror.Error{
+		Message:    message,
+		Extensions: map[string]interface{}{"code": "UNAUTHENTICATED"},
+	}
+}
+
+func forbidden(message string) *gqlerror.Error {
+	return &gqlerror.Error{
+		Message:    message,
+		Extensions: map[string]interface{}{"code": "FORBIDDEN"},
+	}
+}
@@ -62,10 +62,19 @@ func (s *Server) Account() AccountResolver {
 	}
 }
 
+func (s *Server) Order() OrderResolver {
+	return &orderResolver{
+		server: s,
+	}
+}
+
 func (s *Server) ToExecutableSchema() graphql.ExecutableSchema {
 	return NewExecutableSchema(
 		Config{
 			Resolvers: s,
+			Directives: DirectiveRoot{
+				Auth: authDirective,
+			},
 		},
 	)
 }
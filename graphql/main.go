@@ -7,6 +7,7 @@ import (
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/kelseyhightower/envconfig"
+	"github.com/master-wayne7/go-microservices/auth"
 	"github.com/master-wayne7/go-microservices/monitoring"
 )
 
@@ -14,6 +15,9 @@ type AppConfig struct {
 	AccountUrl string `envconfig:"ACCOUNT_SERVICE_URL"`
 	CatalogUrl string `envconfig:"CATALOG_SERVICE_URL"`
 	OrderUrl   string `envconfig:"ORDER_SERVICE_URL"`
+	// Verifies bearer tokens issued by the account service
+	TokenSecret       string `envconfig:"AUTH_TOKEN_SECRET" required:"true"`
+	PlaygroundEnabled bool   `envconfig:"PLAYGROUND_ENABLED" default:"true"`
 }
 
 // ### CHANGE THIS ####
@@ -61,11 +65,17 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	signer, err := auth.NewSigner(cfg.TokenSecret)
+	if err != nil {
+		log.Fatal(err)
+	}
 	graphqlHandler := handler.NewDefaultServer(s.ToExecutableSchema())
 
 	// Add GraphQL metrics + HTTP metrics middleware
-	http.Handle("/graphql", monitoring.HTTPMiddleware(metrics)(monitoring.GraphQLMiddleware(metrics)(enforceJSONContentType(graphqlHandler))))
-	http.Handle("/playground", monitoring.HTTPMiddleware(metrics)(playground.Handler("playground", "/graphql")))
+	http.Handle("/graphql", monitoring.HTTPMiddleware(metrics)(monitoring.GraphQLMiddleware(metrics)(authenticate(signer)(enforceJSONContentType(graphqlHandler)))))
+	if cfg.PlaygroundEnabled {
+		http.Handle("/playground", monitoring.HTTPMiddleware(metrics)(playground.Handler("playground", "/graphql")))
+	}
 
 	// Start system metrics collection
 	metrics.StartSystemMetricsCollection(nil)
@@ -1,7 +1,25 @@
 package main
 
+import "time"
+
 type Account struct {
-	ID     string  `json:"id"`
-	Name   string  `json:"name"`
-	Orders []Order `json:"orders"`
+	ID        string        `json:"id"`
+	Name      string        `json:"name"`
+	Status    AccountStatus `json:"status"`
+	Version   int           `json:"version"`
+	UpdatedAt time.Time     `json:"updatedAt"`
+	Email     *string       `json:"email"`
+	Roles     []Role        `json:"roles"`
+	Orders    []Order       `json:"orders"`
+}
+
+type Order struct {
+	ID                 string             `json:"id"`
+	AccountID          string             `json:"-"`
+	CreatedAt          time.Time          `json:"createdAt"`
+	TotalPrice         float64            `json:"totalPrice"`
+	TotalPriceMoney    *Money             `json:"totalPriceMoney"`
+	Status             OrderStatus        `json:"status"`
+	CancellationReason *string            `json:"cancellationReason"`
+	Products           []*OrderedProducts `json:"products"`
 }
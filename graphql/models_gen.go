@@ -3,6 +3,10 @@
 package main
 
 import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
 	"time"
 )
 
@@ -10,14 +14,137 @@ type AccountInput struct {
 	Name string `json:"name"`
 }
 
+type AccountUpdateInput struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// The version last read. Omit to overwrite regardless of concurrent changes.
+	Version *int `json:"version,omitempty"`
+}
+
+// An attribute of the products in a category and its subcategories. Products are checked against it when they are written.
+type AttributeDefinition struct {
+	Name string        `json:"name"`
+	Type AttributeType `json:"type"`
+	// The values an enum accepts. Empty for other types.
+	Values []string `json:"values"`
+	// Has to be set on the product, or else on every one of its variants.
+	Required bool `json:"required"`
+}
+
+type AttributeDefinitionInput struct {
+	// Cannot contain "=".
+	Name string        `json:"name"`
+	Type AttributeType `json:"type"`
+	// Required for enums, not allowed for other types.
+	Values   []string `json:"values,omitempty"`
+	Required *bool    `json:"required,omitempty"`
+}
+
+type AttributeFacet struct {
+	Name   string        `json:"name"`
+	Values []*FacetValue `json:"values"`
+}
+
+type AttributeFilterInput struct {
+	Name string `json:"name"`
+	// Products with any of these values match.
+	Values []string `json:"values"`
+}
+
+// Products whose value of the attribute is a number between min and max, inclusive, match. Omit a bound to leave it open.
+type AttributeRangeInput struct {
+	Name string   `json:"name"`
+	Min  *float64 `json:"min,omitempty"`
+	Max  *float64 `json:"max,omitempty"`
+}
+
+type AuthPayload struct {
+	Account *Account    `json:"account"`
+	Tokens  *AuthTokens `json:"tokens"`
+}
+
+// Signed tokens. Send accessToken as "Authorization: Bearer <token>" and use
+// refreshTokens to get a new pair before it expires.
+type AuthTokens struct {
+	AccessToken           string    `json:"accessToken"`
+	AccessTokenExpiresAt  time.Time `json:"accessTokenExpiresAt"`
+	RefreshToken          string    `json:"refreshToken"`
+	RefreshTokenExpiresAt time.Time `json:"refreshTokenExpiresAt"`
+}
+
+type Category struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Null for root categories.
+	ParentID *string `json:"parentId,omitempty"`
+	// Category ids from the root down to this category.
+	Path     []string    `json:"path"`
+	Children []*Category `json:"children"`
+	// Attributes of the products in this category, on top of those its ancestors define.
+	Attributes []*AttributeDefinition `json:"attributes"`
+}
+
+type ChangePasswordInput struct {
+	AccountID       string `json:"accountId"`
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
+}
+
+type FacetValue struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+type LoginInput struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// An exact amount of money. amount is a decimal string (e.g. "19.99") so no
+// precision is lost in transit; currency is an ISO 4217 code.
+type Money struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type MoneyInput struct {
+	Amount   string  `json:"amount"`
+	Currency *string `json:"currency,omitempty"`
+}
+
 type Mutation struct {
 }
 
-type Order struct {
-	ID         string             `json:"id"`
-	CreatedAt  time.Time          `json:"createdAt"`
-	TotalPrice float64            `json:"totalPrice"`
-	Products   []*OrderedProducts `json:"products"`
+type OrderCancellation struct {
+	Order *Order `json:"order"`
+	// Null when the order was never paid.
+	Refund *Refund `json:"refund,omitempty"`
+}
+
+type OrderCancellationInput struct {
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
+	// Lines to refund. Omit to refund the whole order.
+	RefundLines []*RefundLineInput `json:"refundLines,omitempty"`
+}
+
+// One page of orders. Pass nextCursor as after to fetch the next page.
+type OrderConnection struct {
+	Orders     []*Order `json:"orders"`
+	NextCursor *string  `json:"nextCursor,omitempty"`
+	HasMore    bool     `json:"hasMore"`
+}
+
+type OrderFilterInput struct {
+	AccountID     *string       `json:"accountId,omitempty"`
+	CreatedAfter  *time.Time    `json:"createdAfter,omitempty"`
+	CreatedBefore *time.Time    `json:"createdBefore,omitempty"`
+	Statuses      []OrderStatus `json:"statuses,omitempty"`
+	// minTotal and maxTotal also restrict orders to their currency.
+	MinTotal *MoneyInput `json:"minTotal,omitempty"`
+	MaxTotal *MoneyInput `json:"maxTotal,omitempty"`
+	// Only orders containing this product.
+	ProductID *string `json:"productId,omitempty"`
 }
 
 type OrderInput struct {
@@ -26,16 +153,39 @@ type OrderInput struct {
 }
 
 type OrderProductInput struct {
-	ID       string `json:"id"`
-	Quantity *int   `json:"quantity,omitempty"`
+	// Deprecated: use sku. Only orders products without variants.
+	ID *string `json:"id,omitempty"`
+	// The variant to order, or the id of a product without variants.
+	Sku *string `json:"sku,omitempty"`
+	// Required and positive. Orders without it fail as invalid.
+	Quantity *int `json:"quantity,omitempty"`
+}
+
+type OrderStatusChange struct {
+	FromStatus *OrderStatus `json:"fromStatus,omitempty"`
+	ToStatus   OrderStatus  `json:"toStatus"`
+	ChangedBy  string       `json:"changedBy"`
+	Reason     string       `json:"reason"`
+	ChangedAt  time.Time    `json:"changedAt"`
+}
+
+type OrderTransitionInput struct {
+	ID     string      `json:"id"`
+	Status OrderStatus `json:"status"`
+	Reason *string     `json:"reason,omitempty"`
 }
 
 type OrderedProducts struct {
-	ID          string  `json:"id"`
+	ID string `json:"id"`
+	// The ordered variant, or the product id for products without variants.
+	Sku         string  `json:"sku"`
 	Name        string  `json:"name"`
 	Description string  `json:"description"`
 	Price       float64 `json:"price"`
-	Quantity    int     `json:"quantity"`
+	PriceMoney  *Money  `json:"priceMoney"`
+	// Attributes of the ordered variant, ordered by name.
+	Attributes []*ProductAttribute `json:"attributes"`
+	Quantity   int                 `json:"quantity"`
 }
 
 type PaginationInput struct {
@@ -43,18 +193,604 @@ type PaginationInput struct {
 	Take *int `json:"take,omitempty"`
 }
 
+// Products priced from min up to but excluding max. The first range has no min, the last no max.
+type PriceRangeFacet struct {
+	Min   *Money `json:"min,omitempty"`
+	Max   *Money `json:"max,omitempty"`
+	Count int    `json:"count"`
+}
+
 type Product struct {
 	ID          string  `json:"id"`
 	Name        string  `json:"name"`
 	Description string  `json:"description"`
 	Price       float64 `json:"price"`
+	PriceMoney  *Money  `json:"priceMoney"`
+	// Pass back to updateProduct, patchProduct or deleteProduct to detect concurrent changes.
+	Version string `json:"version"`
+	// Unset on products created before creation times were recorded.
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	// Set on deleted products, which no longer show up in listings and searches.
+	DeletedAt   *time.Time `json:"deletedAt,omitempty"`
+	CategoryIds []string   `json:"categoryIds"`
+	// Free-form properties such as color or size, ordered by name.
+	Attributes []*ProductAttribute `json:"attributes"`
+	// Options the product is sold in. Without variants the product itself is sold, with its id as SKU.
+	Variants []*ProductVariant `json:"variants"`
+}
+
+type ProductAttribute struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type ProductAttributeInput struct {
+	// Cannot contain "=".
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Counts over all matches. Each facet ignores its own criterion, so it shows
+// how many products choosing another value would find.
+type ProductFacets struct {
+	// Products directly in each category, keyed by category id.
+	Categories  []*FacetValue      `json:"categories"`
+	PriceRanges []*PriceRangeFacet `json:"priceRanges"`
+	Attributes  []*AttributeFacet  `json:"attributes"`
 }
 
 type ProductInput struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Deprecated: use priceMoney. Only read when priceMoney is not given.
+	Price       *float64                 `json:"price,omitempty"`
+	PriceMoney  *MoneyInput              `json:"priceMoney,omitempty"`
+	CategoryIds []string                 `json:"categoryIds,omitempty"`
+	Attributes  []*ProductAttributeInput `json:"attributes,omitempty"`
+	Variants    []*ProductVariantInput   `json:"variants,omitempty"`
+}
+
+// Fields that are omitted or null keep their value.
+type ProductPatchInput struct {
+	ID          string      `json:"id"`
+	Name        *string     `json:"name,omitempty"`
+	Description *string     `json:"description,omitempty"`
+	PriceMoney  *MoneyInput `json:"priceMoney,omitempty"`
+	CategoryIds []string    `json:"categoryIds,omitempty"`
+	// Replaces all attributes.
+	Attributes []*ProductAttributeInput `json:"attributes,omitempty"`
+	// Replaces all variants.
+	Variants []*ProductVariantInput `json:"variants,omitempty"`
+	// The version last read. Omit to overwrite regardless of concurrent changes.
+	Version *string `json:"version,omitempty"`
+}
+
+// Products have to match every criterion that is set.
+type ProductSearchInput struct {
+	Query *string `json:"query,omitempty"`
+	// Inclusive. Setting minPrice or maxPrice only matches products priced in their currency.
+	MinPrice *MoneyInput `json:"minPrice,omitempty"`
+	MaxPrice *MoneyInput `json:"maxPrice,omitempty"`
+	// Currency of the price range facets when no price bound is set. Defaults to USD.
+	Currency *string `json:"currency,omitempty"`
+	// Also matches products in subcategories.
+	Category *string `json:"category,omitempty"`
+	// Products need one of the values of every listed attribute.
+	Attributes []*AttributeFilterInput `json:"attributes,omitempty"`
+	// Products need a number within every listed range.
+	AttributeRanges []*AttributeRangeInput `json:"attributeRanges,omitempty"`
+	Sort            *ProductSort           `json:"sort,omitempty"`
+}
+
+type ProductSearchResult struct {
+	Products []*Product `json:"products"`
+	// Number of matches across all pages.
+	Total  int            `json:"total"`
+	Facets *ProductFacets `json:"facets"`
+	// Highlights of the products on this page. Empty without a query.
+	Highlights []*SearchHighlight `json:"highlights"`
+}
+
+type ProductSuggestion struct {
+	ProductID string `json:"productId"`
+	Name      string `json:"name"`
+}
+
+type ProductUpdateInput struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	PriceMoney  *MoneyInput `json:"priceMoney"`
+	// Omitted or empty takes the product out of all categories.
+	CategoryIds []string `json:"categoryIds,omitempty"`
+	// Omitted or empty removes all attributes.
+	Attributes []*ProductAttributeInput `json:"attributes,omitempty"`
+	// The version last read. Omit to overwrite regardless of concurrent changes.
+	Version *string `json:"version,omitempty"`
+}
+
+// One purchasable option of a product, such as a size and colour.
+type ProductVariant struct {
+	// Unique across the catalog. Orders and stock refer to variants by SKU.
+	Sku string `json:"sku"`
+	// Ordered by name.
+	Attributes []*ProductAttribute `json:"attributes"`
+	PriceMoney *Money              `json:"priceMoney"`
+}
+
+type ProductVariantInput struct {
+	// Letters, digits, ".", "_" and "-", at most 64.
+	Sku string `json:"sku"`
+	// At least one. No two variants of a product may have the same attributes.
+	Attributes []*ProductAttributeInput `json:"attributes"`
+	// In the currency of the product. Defaults to the product's price.
+	PriceMoney *MoneyInput `json:"priceMoney,omitempty"`
 }
 
 type Query struct {
 }
+
+type Refund struct {
+	ID        string        `json:"id"`
+	OrderID   string        `json:"orderId"`
+	Amount    *Money        `json:"amount"`
+	Status    RefundStatus  `json:"status"`
+	Reason    string        `json:"reason"`
+	Lines     []*RefundLine `json:"lines"`
+	CreatedAt time.Time     `json:"createdAt"`
+	UpdatedAt time.Time     `json:"updatedAt"`
+}
+
+type RefundLine struct {
+	ProductID string `json:"productId"`
+	Sku       string `json:"sku"`
+	Quantity  int    `json:"quantity"`
+	Amount    *Money `json:"amount"`
+}
+
+type RefundLineInput struct {
+	// Identifies the line when the order has a single variant of the product; otherwise use sku.
+	ProductID *string `json:"productId,omitempty"`
+	Sku       *string `json:"sku,omitempty"`
+	Quantity  int     `json:"quantity"`
+}
+
+type RegisterInput struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	// 8 to 128 characters.
+	Password string `json:"password"`
+}
+
+// Snippets of a product field matching the search query. They are HTML-escaped, with matching terms wrapped in <em> tags.
+type SearchHighlight struct {
+	ProductID string   `json:"productId"`
+	Field     string   `json:"field"`
+	Fragments []string `json:"fragments"`
+}
+
+type AccountStatus string
+
+const (
+	AccountStatusActive      AccountStatus = "ACTIVE"
+	AccountStatusDeactivated AccountStatus = "DEACTIVATED"
+	AccountStatusDeleted     AccountStatus = "DELETED"
+)
+
+var AllAccountStatus = []AccountStatus{
+	AccountStatusActive,
+	AccountStatusDeactivated,
+	AccountStatusDeleted,
+}
+
+func (e AccountStatus) IsValid() bool {
+	switch e {
+	case AccountStatusActive, AccountStatusDeactivated, AccountStatusDeleted:
+		return true
+	}
+	return false
+}
+
+func (e AccountStatus) String() string {
+	return string(e)
+}
+
+func (e *AccountStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = AccountStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid AccountStatus", str)
+	}
+	return nil
+}
+
+func (e AccountStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *AccountStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e AccountStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type AttributeType string
+
+const (
+	AttributeTypeString AttributeType = "STRING"
+	// Decimal numbers such as 42 or -1.5. They can be filtered by range.
+	AttributeTypeNumber AttributeType = "NUMBER"
+	// One of the values of the definition, matched ignoring case.
+	AttributeTypeEnum AttributeType = "ENUM"
+	// true or false.
+	AttributeTypeBoolean AttributeType = "BOOLEAN"
+)
+
+var AllAttributeType = []AttributeType{
+	AttributeTypeString,
+	AttributeTypeNumber,
+	AttributeTypeEnum,
+	AttributeTypeBoolean,
+}
+
+func (e AttributeType) IsValid() bool {
+	switch e {
+	case AttributeTypeString, AttributeTypeNumber, AttributeTypeEnum, AttributeTypeBoolean:
+		return true
+	}
+	return false
+}
+
+func (e AttributeType) String() string {
+	return string(e)
+}
+
+func (e *AttributeType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = AttributeType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid AttributeType", str)
+	}
+	return nil
+}
+
+func (e AttributeType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *AttributeType) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e AttributeType) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type OrderSort string
+
+const (
+	OrderSortCreatedAtDesc OrderSort = "CREATED_AT_DESC"
+	OrderSortCreatedAtAsc  OrderSort = "CREATED_AT_ASC"
+	OrderSortTotalDesc     OrderSort = "TOTAL_DESC"
+	OrderSortTotalAsc      OrderSort = "TOTAL_ASC"
+)
+
+var AllOrderSort = []OrderSort{
+	OrderSortCreatedAtDesc,
+	OrderSortCreatedAtAsc,
+	OrderSortTotalDesc,
+	OrderSortTotalAsc,
+}
+
+func (e OrderSort) IsValid() bool {
+	switch e {
+	case OrderSortCreatedAtDesc, OrderSortCreatedAtAsc, OrderSortTotalDesc, OrderSortTotalAsc:
+		return true
+	}
+	return false
+}
+
+func (e OrderSort) String() string {
+	return string(e)
+}
+
+func (e *OrderSort) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = OrderSort(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid OrderSort", str)
+	}
+	return nil
+}
+
+func (e OrderSort) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *OrderSort) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e OrderSort) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type OrderStatus string
+
+const (
+	OrderStatusPending   OrderStatus = "PENDING"
+	OrderStatusPaid      OrderStatus = "PAID"
+	OrderStatusFulfilled OrderStatus = "FULFILLED"
+	OrderStatusShipped   OrderStatus = "SHIPPED"
+	OrderStatusDelivered OrderStatus = "DELIVERED"
+	OrderStatusCancelled OrderStatus = "CANCELLED"
+	OrderStatusRefunded  OrderStatus = "REFUNDED"
+)
+
+var AllOrderStatus = []OrderStatus{
+	OrderStatusPending,
+	OrderStatusPaid,
+	OrderStatusFulfilled,
+	OrderStatusShipped,
+	OrderStatusDelivered,
+	OrderStatusCancelled,
+	OrderStatusRefunded,
+}
+
+func (e OrderStatus) IsValid() bool {
+	switch e {
+	case OrderStatusPending, OrderStatusPaid, OrderStatusFulfilled, OrderStatusShipped, OrderStatusDelivered, OrderStatusCancelled, OrderStatusRefunded:
+		return true
+	}
+	return false
+}
+
+func (e OrderStatus) String() string {
+	return string(e)
+}
+
+func (e *OrderStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = OrderStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid OrderStatus", str)
+	}
+	return nil
+}
+
+func (e OrderStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *OrderStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e OrderStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+// Products that sort equally are ordered by id, so pages stay consistent.
+type ProductSort string
+
+const (
+	// Best matches of the query first. The default.
+	ProductSortRelevance ProductSort = "RELEVANCE"
+	// Compares amounts in minor units, whatever their currency.
+	ProductSortPriceAsc  ProductSort = "PRICE_ASC"
+	ProductSortPriceDesc ProductSort = "PRICE_DESC"
+	ProductSortName      ProductSort = "NAME"
+	ProductSortNewest    ProductSort = "NEWEST"
+)
+
+var AllProductSort = []ProductSort{
+	ProductSortRelevance,
+	ProductSortPriceAsc,
+	ProductSortPriceDesc,
+	ProductSortName,
+	ProductSortNewest,
+}
+
+func (e ProductSort) IsValid() bool {
+	switch e {
+	case ProductSortRelevance, ProductSortPriceAsc, ProductSortPriceDesc, ProductSortName, ProductSortNewest:
+		return true
+	}
+	return false
+}
+
+func (e ProductSort) String() string {
+	return string(e)
+}
+
+func (e *ProductSort) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ProductSort(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ProductSort", str)
+	}
+	return nil
+}
+
+func (e ProductSort) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *ProductSort) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e ProductSort) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type RefundStatus string
+
+const (
+	RefundStatusPending   RefundStatus = "PENDING"
+	RefundStatusCompleted RefundStatus = "COMPLETED"
+	RefundStatusFailed    RefundStatus = "FAILED"
+)
+
+var AllRefundStatus = []RefundStatus{
+	RefundStatusPending,
+	RefundStatusCompleted,
+	RefundStatusFailed,
+}
+
+func (e RefundStatus) IsValid() bool {
+	switch e {
+	case RefundStatusPending, RefundStatusCompleted, RefundStatusFailed:
+		return true
+	}
+	return false
+}
+
+func (e RefundStatus) String() string {
+	return string(e)
+}
+
+func (e *RefundStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = RefundStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid RefundStatus", str)
+	}
+	return nil
+}
+
+func (e RefundStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *RefundStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e RefundStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+// Roles an account can hold. Each role includes the ones before it: STAFF can
+// do everything a CUSTOMER can, ADMIN everything STAFF can.
+type Role string
+
+const (
+	// Shops and sees only their own account and orders.
+	RoleCustomer Role = "CUSTOMER"
+	// Manages products, stock, every account and every order.
+	RoleStaff Role = "STAFF"
+	// Also assigns roles.
+	RoleAdmin Role = "ADMIN"
+)
+
+var AllRole = []Role{
+	RoleCustomer,
+	RoleStaff,
+	RoleAdmin,
+}
+
+func (e Role) IsValid() bool {
+	switch e {
+	case RoleCustomer, RoleStaff, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+func (e Role) String() string {
+	return string(e)
+}
+
+func (e *Role) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Role(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Role", str)
+	}
+	return nil
+}
+
+func (e Role) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *Role) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e Role) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
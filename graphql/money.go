@@ -0,0 +1,20 @@
+package main
+
+import (
+	"github.com/master-wayne7/go-microservices/money"
+)
+
+func toMoney(m money.Money) *Money {
+	return &Money{
+		Amount:   m.String(),
+		Currency: m.Currency,
+	}
+}
+
+func (m *MoneyInput) money() (money.Money, error) {
+	currency := ""
+	if m.Currency != nil {
+		currency = *m.Currency
+	}
+	return money.Parse(m.Amount, currency)
+}
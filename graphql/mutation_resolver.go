@@ -3,9 +3,13 @@ package main
 import (
 	"context"
 	"errors"
-	"github.com/master-wayne7/go-microservices/order"
 	"log"
 	"time"
+
+	"github.com/master-wayne7/go-microservices/auth"
+	"github.com/master-wayne7/go-microservices/catalog"
+	"github.com/master-wayne7/go-microservices/money"
+	"github.com/master-wayne7/go-microservices/order"
 )
 
 var (
@@ -17,79 +21,531 @@ type mutationResolver struct {
 }
 
 // CreateAccount implements MutationResolver.
-func (r *mutationResolver) CreateAccount(ctx context.Context, account *AccountInput) (*Account, error) {
+func (r *mutationResolver) CreateAccount(ctx context.Context, account *AccountInput, idempotencyKey *string) (*Account, error) {
+	if account == nil {
+		return nil, ErrInvalidParameter
+	}
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	a, err := r.server.accountClient.PostAccount(ctx, account.Name, stringValue(idempotencyKey))
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return toAccount(a), nil
+}
+
+// UpdateAccount implements MutationResolver.
+func (r *mutationResolver) UpdateAccount(ctx context.Context, in AccountUpdateInput) (*Account, error) {
+	if err := authorizeAccount(ctx, in.ID); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	version, err := versionValue(in.Version)
+	if err != nil {
+		return nil, err
+	}
+	a, err := r.server.accountClient.UpdateAccount(ctx, in.ID, in.Name, version)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return toAccount(a), nil
+}
+
+// DeactivateAccount implements MutationResolver.
+func (r *mutationResolver) DeactivateAccount(ctx context.Context, id string, version *int) (*Account, error) {
+	if err := authorizeAccount(ctx, id); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	v, err := versionValue(version)
+	if err != nil {
+		return nil, err
+	}
+	a, err := r.server.accountClient.DeactivateAccount(ctx, id, v)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return toAccount(a), nil
+}
+
+// DeleteAccount implements MutationResolver.
+func (r *mutationResolver) DeleteAccount(ctx context.Context, id string, version *int) (*Account, error) {
+	if err := authorizeAccount(ctx, id); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	v, err := versionValue(version)
+	if err != nil {
+		return nil, err
+	}
+	a, err := r.server.accountClient.DeleteAccount(ctx, id, v)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return toAccount(a), nil
+}
+
+// AssignRoles implements MutationResolver.
+func (r *mutationResolver) AssignRoles(ctx context.Context, accountID string, roles []Role, version *int) (*Account, error) {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	a, err := r.server.accountClient.PostAccount(ctx, account.Name)
+	v, err := versionValue(version)
+	if err != nil {
+		return nil, err
+	}
+	assigned := make([]auth.Role, 0, len(roles))
+	for _, role := range roles {
+		assigned = append(assigned, fromRole(role))
+	}
+	a, err := r.server.accountClient.AssignRoles(ctx, accountID, assigned, v)
 	if err != nil {
 		log.Println(err)
 		return nil, err
 	}
+	return toAccount(a), nil
+}
+
+// Register implements MutationResolver.
+func (r *mutationResolver) Register(ctx context.Context, in RegisterInput) (*AuthPayload, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
 
-	return &Account{
-		ID:   a.ID,
-		Name: a.Name,
-	}, nil
+	a, tokens, err := r.server.accountClient.Register(ctx, in.Name, in.Email, in.Password)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return &AuthPayload{Account: toAccount(a), Tokens: toAuthTokens(tokens)}, nil
+}
+
+// Login implements MutationResolver.
+func (r *mutationResolver) Login(ctx context.Context, in LoginInput) (*AuthPayload, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	a, tokens, err := r.server.accountClient.Login(ctx, in.Email, in.Password)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return &AuthPayload{Account: toAccount(a), Tokens: toAuthTokens(tokens)}, nil
+}
+
+// RefreshTokens implements MutationResolver.
+func (r *mutationResolver) RefreshTokens(ctx context.Context, refreshToken string) (*AuthTokens, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	tokens, err := r.server.accountClient.RefreshTokens(ctx, refreshToken)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return toAuthTokens(tokens), nil
+}
+
+// ChangePassword implements MutationResolver.
+func (r *mutationResolver) ChangePassword(ctx context.Context, in ChangePasswordInput) (*AuthTokens, error) {
+	if err := authorizeAccount(ctx, in.AccountID); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	tokens, err := r.server.accountClient.ChangePassword(ctx, in.AccountID, in.CurrentPassword, in.NewPassword)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return toAuthTokens(tokens), nil
 }
 
 // CreateOrder implements MutationResolver.
-func (r *mutationResolver) CreateOrder(ctx context.Context, in *OrderInput) (*Order, error) {
+func (r *mutationResolver) CreateOrder(ctx context.Context, in *OrderInput, idempotencyKey *string) (*Order, error) {
+	if in == nil {
+		return nil, ErrInvalidParameter
+	}
+	if err := authorizeAccount(ctx, in.AccountID); err != nil {
+		return nil, err
+	}
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
 	var products []order.OrderedProduct
 	for _, p := range in.Products {
-		if *p.Quantity <= 0 {
+		if p.Quantity == nil || *p.Quantity <= 0 {
+			return nil, ErrInvalidParameter
+		}
+		// Products without variants are sold under their ID.
+		sku := stringValue(p.Sku)
+		if sku == "" {
+			sku = stringValue(p.ID)
+		}
+		if sku == "" {
 			return nil, ErrInvalidParameter
 		}
 		products = append(products, order.OrderedProduct{
-			ID:       p.ID,
+			SKU:      sku,
 			Quantity: uint32(*p.Quantity),
 		})
 	}
 
-	o, err := r.server.orderClient.PostOrder(ctx, in.AccountID, products)
+	o, err := r.server.orderClient.PostOrder(ctx, in.AccountID, products, stringValue(idempotencyKey))
 	if err != nil {
 		log.Println(err)
 		return nil, err
 	}
 
-	// Convert order.OrderedProduct to []*OrderedProducts
-	var newProducts []*OrderedProducts
-	for _, p := range o.Products {
-		newProducts = append(newProducts, &OrderedProducts{
-			ID:          p.ID,
-			Name:        p.Name,
-			Description: p.Description,
-			Price:       p.Price,
-			Quantity:    int(p.Quantity),
+	return toOrder(o), nil
+}
+
+// CreateProduct implements MutationResolver.
+func (r *mutationResolver) CreateProduct(ctx context.Context, product *ProductInput, idempotencyKey *string) (*Product, error) {
+	if product == nil {
+		return nil, ErrInvalidParameter
+	}
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var price money.Money
+	switch {
+	case product.PriceMoney != nil:
+		var err error
+		if price, err = product.PriceMoney.money(); err != nil {
+			return nil, err
+		}
+	case product.Price != nil:
+		price = money.FromFloat(*product.Price, money.DefaultCurrency)
+	default:
+		return nil, ErrInvalidParameter
+	}
+
+	attributes, err := fromAttributes(product.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	variants, err := fromVariants(product.Variants)
+	if err != nil {
+		return nil, err
+	}
+	p, err := r.server.catalogClient.PostProduct(ctx, product.Name, product.Description, price, product.CategoryIds, attributes, variants, stringValue(idempotencyKey))
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return toProduct(p), nil
+}
+
+// UpdateProduct implements MutationResolver.
+func (r *mutationResolver) UpdateProduct(ctx context.Context, in ProductUpdateInput) (*Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	version, err := productVersionValue(in.Version)
+	if err != nil {
+		return nil, err
+	}
+	price, err := in.PriceMoney.money()
+	if err != nil {
+		return nil, err
+	}
+	attributes, err := fromAttributes(in.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	p, err := r.server.catalogClient.UpdateProduct(ctx, in.ID, in.Name, in.Description, price, in.CategoryIds, attributes, version)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return toProduct(p), nil
+}
+
+// PatchProduct implements MutationResolver.
+func (r *mutationResolver) PatchProduct(ctx context.Context, in ProductPatchInput) (*Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	version, err := productVersionValue(in.Version)
+	if err != nil {
+		return nil, err
+	}
+	var patch catalog.ProductPatch
+	var fields []string
+	if in.Name != nil {
+		patch.Name = *in.Name
+		fields = append(fields, catalog.FieldName)
+	}
+	if in.Description != nil {
+		patch.Description = *in.Description
+		fields = append(fields, catalog.FieldDescription)
+	}
+	if in.PriceMoney != nil {
+		if patch.Price, err = in.PriceMoney.money(); err != nil {
+			return nil, err
+		}
+		fields = append(fields, catalog.FieldPrice)
+	}
+	if in.CategoryIds != nil {
+		patch.CategoryIDs = in.CategoryIds
+		fields = append(fields, catalog.FieldCategories)
+	}
+	if in.Attributes != nil {
+		if patch.Attributes, err = fromAttributes(in.Attributes); err != nil {
+			return nil, err
+		}
+		fields = append(fields, catalog.FieldAttributes)
+	}
+	if in.Variants != nil {
+		if patch.Variants, err = fromVariants(in.Variants); err != nil {
+			return nil, err
+		}
+		fields = append(fields, catalog.FieldVariants)
+	}
+	if len(fields) == 0 {
+		return nil, ErrInvalidParameter
+	}
+	p, err := r.server.catalogClient.PatchProduct(ctx, in.ID, patch, fields, version)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return toProduct(p), nil
+}
+
+// DeleteProduct implements MutationResolver.
+func (r *mutationResolver) DeleteProduct(ctx context.Context, id string, version *string) (*Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	v, err := productVersionValue(version)
+	if err != nil {
+		return nil, err
+	}
+	p, err := r.server.catalogClient.DeleteProduct(ctx, id, v)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return toProduct(p), nil
+}
+
+// CreateCategory implements MutationResolver.
+func (r *mutationResolver) CreateCategory(ctx context.Context, name string, parentID *string) (*Category, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	c, err := r.server.catalogClient.CreateCategory(ctx, name, stringValue(parentID))
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return toCategory(c), nil
+}
+
+// RenameCategory implements MutationResolver.
+func (r *mutationResolver) RenameCategory(ctx context.Context, id string, name string) (*Category, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	c, err := r.server.catalogClient.RenameCategory(ctx, id, name)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return r.withChildren(ctx, c)
+}
+
+// MoveCategory implements MutationResolver.
+func (r *mutationResolver) MoveCategory(ctx context.Context, id string, parentID *string) (*Category, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	c, err := r.server.catalogClient.MoveCategory(ctx, id, stringValue(parentID))
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return r.withChildren(ctx, c)
+}
+
+// DeleteCategory implements MutationResolver.
+func (r *mutationResolver) DeleteCategory(ctx context.Context, id string) (*Category, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	c, err := r.server.catalogClient.DeleteCategory(ctx, id)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return toCategory(c), nil
+}
+
+// SetCategoryAttributes implements MutationResolver.
+func (r *mutationResolver) SetCategoryAttributes(ctx context.Context, id string, attributes []*AttributeDefinitionInput) (*Category, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	definitions := make([]catalog.AttributeDefinition, 0, len(attributes))
+	for _, a := range attributes {
+		definitions = append(definitions, catalog.AttributeDefinition{
+			Name:     a.Name,
+			Type:     fromAttributeType(a.Type),
+			Values:   a.Values,
+			Required: a.Required != nil && *a.Required,
 		})
 	}
+	c, err := r.server.catalogClient.SetCategoryAttributes(ctx, id, definitions)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return r.withChildren(ctx, c)
+}
 
-	return &Order{
-		ID:         o.ID,
-		CreatedAt:  o.CreatedAt,
-		TotalPrice: o.TotalPrice,
-		Products:   newProducts,
-	}, nil
+// withChildren converts c and loads its subcategories.
+func (r *mutationResolver) withChildren(ctx context.Context, c *catalog.Category) (*Category, error) {
+	categories, err := r.server.catalogClient.GetCategories(ctx)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	category := toCategory(c)
+	category.Children = categoryTree(categories, c.ID)
+	return category, nil
 }
 
-// CreateProduct implements MutationResolver.
-func (r *mutationResolver) CreateProduct(ctx context.Context, product *ProductInput) (*Product, error) {
+// TransitionOrder implements MutationResolver.
+func (r *mutationResolver) TransitionOrder(ctx context.Context, in OrderTransitionInput) (*Order, error) {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	p, err := r.server.catalogClient.PostProduct(ctx, product.Name, product.Description, product.Price)
+	reason := ""
+	if in.Reason != nil {
+		reason = *in.Reason
+	}
+	o, err := r.server.orderClient.TransitionOrder(ctx, in.ID, fromOrderStatus(in.Status), reason)
 	if err != nil {
 		log.Println(err)
 		return nil, err
 	}
+	return toOrder(o), nil
+}
+
+// CancelOrder implements MutationResolver.
+func (r *mutationResolver) CancelOrder(ctx context.Context, in OrderCancellationInput) (*OrderCancellation, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
 
-	return &Product{
-		ID:          p.ID,
-		Name:        p.Name,
-		Description: p.Description,
-		Price:       product.Price,
-	}, nil
+	if err := r.authorizeOrder(ctx, in.ID); err != nil {
+		return nil, err
+	}
+
+	lines := make([]order.RefundLine, 0, len(in.RefundLines))
+	for _, l := range in.RefundLines {
+		if l.Quantity <= 0 || (l.ProductID == nil && l.Sku == nil) {
+			return nil, ErrInvalidParameter
+		}
+		lines = append(lines, order.RefundLine{
+			ProductID: stringValue(l.ProductID),
+			SKU:       stringValue(l.Sku),
+			Quantity:  uint32(l.Quantity),
+		})
+	}
+	o, refund, err := r.server.orderClient.CancelOrder(ctx, in.ID, in.Reason, lines)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	result := &OrderCancellation{Order: toOrder(o)}
+	if refund != nil {
+		result.Refund = toRefund(refund)
+	}
+	return result, nil
+}
+
+// authorizeOrder checks that the caller owns the order id.
+func (r *mutationResolver) authorizeOrder(ctx context.Context, id string) error {
+	o, err := r.server.orderClient.GetOrder(ctx, id)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	return authorizeAccount(ctx, o.AccountID)
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// versionValue reads an optional version; a missing one skips the
+// concurrency check.
+func versionValue(v *int) (uint64, error) {
+	if v == nil {
+		return 0, nil
+	}
+	if *v <= 0 {
+		return 0, ErrInvalidParameter
+	}
+	return uint64(*v), nil
+}
+
+// productVersionValue reads an optional product version; a missing one
+// skips the concurrency check.
+func productVersionValue(v *string) (catalog.Version, error) {
+	if v == nil {
+		return catalog.Version{}, nil
+	}
+	return catalog.ParseVersion(*v)
+}
+
+// fromAttributes turns attribute inputs into a map, rejecting names given
+// twice.
+func fromAttributes(in []*ProductAttributeInput) (map[string]string, error) {
+	attributes := make(map[string]string, len(in))
+	for _, a := range in {
+		if _, ok := attributes[a.Name]; ok {
+			return nil, ErrInvalidParameter
+		}
+		attributes[a.Name] = a.Value
+	}
+	return attributes, nil
+}
+
+// fromVariants turns variant inputs into variants. Variants without a price
+// are left at zero, which gives them the product's price.
+func fromVariants(in []*ProductVariantInput) ([]catalog.Variant, error) {
+	variants := make([]catalog.Variant, 0, len(in))
+	for _, v := range in {
+		attributes, err := fromAttributes(v.Attributes)
+		if err != nil {
+			return nil, err
+		}
+		variant := catalog.Variant{SKU: v.Sku, Attributes: attributes}
+		if v.PriceMoney != nil {
+			if variant.Price, err = v.PriceMoney.money(); err != nil {
+				return nil, err
+			}
+		}
+		variants = append(variants, variant)
+	}
+	return variants, nil
 }
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/master-wayne7/go-microservices/order"
+)
+
+type orderResolver struct {
+	server *Server
+}
+
+// StatusHistory implements OrderResolver.
+func (r *orderResolver) StatusHistory(ctx context.Context, obj *Order) ([]*OrderStatusChange, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	history, err := r.server.orderClient.GetOrderStatusHistory(ctx, obj.ID)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	changes := make([]*OrderStatusChange, 0, len(history))
+	for _, c := range history {
+		change := &OrderStatusChange{
+			ToStatus:  toOrderStatus(c.To),
+			ChangedBy: c.ChangedBy,
+			Reason:    c.Reason,
+			ChangedAt: c.ChangedAt,
+		}
+		if c.From != "" {
+			from := toOrderStatus(c.From)
+			change.FromStatus = &from
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// Refunds implements OrderResolver.
+func (r *orderResolver) Refunds(ctx context.Context, obj *Order) ([]*Refund, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	refunds, err := r.server.orderClient.GetRefunds(ctx, obj.ID)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	result := make([]*Refund, 0, len(refunds))
+	for i := range refunds {
+		result = append(result, toRefund(&refunds[i]))
+	}
+	return result, nil
+}
+
+func toOrder(o *order.Order) *Order {
+	var products []*OrderedProducts
+	for _, p := range o.Products {
+		products = append(products, &OrderedProducts{
+			ID:          p.ID,
+			Sku:         p.SKU,
+			Name:        p.Name,
+			Description: p.Description,
+			Price:       p.Price.Float64(),
+			PriceMoney:  toMoney(p.Price),
+			Attributes:  toAttributes(p.Attributes),
+			Quantity:    int(p.Quantity),
+		})
+	}
+	var cancellationReason *string
+	if o.CancellationReason != "" {
+		cancellationReason = &o.CancellationReason
+	}
+	return &Order{
+		ID:                 o.ID,
+		AccountID:          o.AccountID,
+		CreatedAt:          o.CreatedAt,
+		TotalPrice:         o.TotalPrice.Float64(),
+		TotalPriceMoney:    toMoney(o.TotalPrice),
+		Status:             toOrderStatus(o.Status),
+		Products:           products,
+		CancellationReason: cancellationReason,
+	}
+}
+
+func toRefund(r *order.Refund) *Refund {
+	lines := make([]*RefundLine, 0, len(r.Lines))
+	for _, l := range r.Lines {
+		lines = append(lines, &RefundLine{
+			ProductID: l.ProductID,
+			Sku:       l.SKU,
+			Quantity:  int(l.Quantity),
+			Amount:    toMoney(l.Amount),
+		})
+	}
+	return &Refund{
+		ID:        r.ID,
+		OrderID:   r.OrderID,
+		Amount:    toMoney(r.Amount),
+		Status:    RefundStatus(strings.ToUpper(string(r.Status))),
+		Reason:    r.Reason,
+		Lines:     lines,
+		CreatedAt: r.CreatedAt,
+		UpdatedAt: r.UpdatedAt,
+	}
+}
+
+func toOrderStatus(s order.Status) OrderStatus {
+	return OrderStatus(strings.ToUpper(string(s)))
+}
+
+func fromOrderStatus(s OrderStatus) order.Status {
+	return order.Status(strings.ToLower(string(s)))
+}
@@ -2,8 +2,14 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"sort"
+	"strings"
 	"time"
+
+	"github.com/master-wayne7/go-microservices/catalog"
+	"github.com/master-wayne7/go-microservices/order"
 )
 
 type queryResolver struct {
@@ -15,15 +21,18 @@ func (q *queryResolver) Accounts(ctx context.Context, pagination *PaginationInpu
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 	if id != nil {
+		if err := authorizeAccount(ctx, *id); err != nil {
+			return nil, err
+		}
 		r, err := q.server.accountClient.GetAccount(ctx, *id)
 		if err != nil {
 			log.Println(err)
 			return nil, err
 		}
-		return []*Account{{
-			ID:   r.ID,
-			Name: r.Name,
-		}}, nil
+		return []*Account{toAccount(r)}, nil
+	}
+	if err := requireRole(ctx, RoleStaff); err != nil {
+		return nil, err
 	}
 	skip, take := uint64(0), uint64(0)
 	if pagination != nil {
@@ -35,33 +44,40 @@ func (q *queryResolver) Accounts(ctx context.Context, pagination *PaginationInpu
 		return nil, err
 	}
 	var accounts []*Account
-	for _, a := range accountList {
-		account := &Account{
-			ID:   a.ID,
-			Name: a.Name,
-		}
-		accounts = append(accounts, account)
+	for i := range accountList {
+		accounts = append(accounts, toAccount(&accountList[i]))
 	}
 	return accounts, nil
 }
 
 // Products implements QueryResolver.
-func (q *queryResolver) Products(ctx context.Context, pagination *PaginationInput, query *string, id []*string) ([]*Product, error) {
+func (q *queryResolver) Products(ctx context.Context, pagination *PaginationInput, query *string, id []*string, sku []string, category *string, sort *ProductSort) ([]*Product, error) {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
+	if len(sku) > 0 {
+		if len(id) > 0 || pagination != nil || query != nil || category != nil || sort != nil {
+			return nil, fmt.Errorf("%w: sku cannot be combined with id, query, category, sort or pagination", ErrInvalidParameter)
+		}
+		productsList, err := q.server.catalogClient.GetProductsBySKUs(ctx, sku)
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+		products := make([]*Product, 0, len(productsList))
+		for _, p := range productsList {
+			products = append(products, toProduct(&p))
+		}
+		return products, nil
+	}
+
 	if len(id) == 1 && id[0] != nil {
 		r, err := q.server.catalogClient.GetProduct(ctx, *id[0])
 		if err != nil {
 			log.Println(err)
 			return nil, err
 		}
-		return []*Product{{
-			ID:          r.ID,
-			Name:        r.Name,
-			Description: r.Description,
-			Price:       r.Price,
-		}}, nil
+		return []*Product{toProduct(r)}, nil
 	}
 	skip, take := uint64(0), uint64(0)
 	if pagination != nil {
@@ -78,24 +94,254 @@ func (q *queryResolver) Products(ctx context.Context, pagination *PaginationInpu
 	if query != nil {
 		queryStr = *query
 	}
-	productsList, err := q.server.catalogClient.GetProducts(ctx, skip, take, queryStr, stringIds)
+	productsList, err := q.server.catalogClient.GetProducts(ctx, skip, take, queryStr, stringIds, stringValue(category), fromProductSort(sort))
 	if err != nil {
 		log.Println(err)
 		return nil, err
 	}
 	var products []*Product
 	for _, p := range productsList {
-		product := &Product{
-			ID:          p.ID,
-			Name:        p.Name,
-			Description: p.Description,
-			Price:       p.Price,
-		}
-		products = append(products, product)
+		products = append(products, toProduct(&p))
 	}
 	return products, nil
 }
 
+// SearchProducts implements QueryResolver.
+func (q *queryResolver) SearchProducts(ctx context.Context, search *ProductSearchInput, pagination *PaginationInput) (*ProductSearchResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	var query catalog.SearchQuery
+	if pagination != nil {
+		query.Skip, query.Take = pagination.bounds()
+	}
+	if search != nil {
+		query.Text = stringValue(search.Query)
+		query.Currency = stringValue(search.Currency)
+		query.Category = stringValue(search.Category)
+		query.Sort = fromProductSort(search.Sort)
+		if search.MinPrice != nil {
+			minPrice, err := search.MinPrice.money()
+			if err != nil {
+				return nil, err
+			}
+			query.MinPrice = &minPrice
+		}
+		if search.MaxPrice != nil {
+			maxPrice, err := search.MaxPrice.money()
+			if err != nil {
+				return nil, err
+			}
+			query.MaxPrice = &maxPrice
+		}
+		if len(search.Attributes) > 0 {
+			query.Attributes = map[string][]string{}
+			for _, a := range search.Attributes {
+				query.Attributes[a.Name] = append(query.Attributes[a.Name], a.Values...)
+			}
+		}
+		if len(search.AttributeRanges) > 0 {
+			query.AttributeRanges = map[string]catalog.AttributeRange{}
+			for _, a := range search.AttributeRanges {
+				query.AttributeRanges[a.Name] = catalog.AttributeRange{Min: a.Min, Max: a.Max}
+			}
+		}
+	}
+
+	result, err := q.server.catalogClient.SearchProducts(ctx, query)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	products := make([]*Product, 0, len(result.Products))
+	for i := range result.Products {
+		products = append(products, toProduct(&result.Products[i]))
+	}
+	facets := &ProductFacets{
+		Categories:  toFacetValues(result.Facets.Categories),
+		PriceRanges: []*PriceRangeFacet{},
+		Attributes:  []*AttributeFacet{},
+	}
+	for _, f := range result.Facets.PriceRanges {
+		priceRange := &PriceRangeFacet{Count: int(f.Count)}
+		if f.Min != nil {
+			priceRange.Min = toMoney(*f.Min)
+		}
+		if f.Max != nil {
+			priceRange.Max = toMoney(*f.Max)
+		}
+		facets.PriceRanges = append(facets.PriceRanges, priceRange)
+	}
+	for _, f := range result.Facets.Attributes {
+		facets.Attributes = append(facets.Attributes, &AttributeFacet{Name: f.Name, Values: toFacetValues(f.Values)})
+	}
+	highlights := []*SearchHighlight{}
+	for _, p := range result.Products {
+		for _, h := range result.Highlights[p.ID] {
+			highlights = append(highlights, &SearchHighlight{ProductID: p.ID, Field: h.Field, Fragments: h.Fragments})
+		}
+	}
+	return &ProductSearchResult{
+		Products:   products,
+		Total:      int(result.Total),
+		Facets:     facets,
+		Highlights: highlights,
+	}, nil
+}
+
+func fromProductSort(sort *ProductSort) catalog.ProductSort {
+	if sort == nil {
+		return ""
+	}
+	return catalog.ProductSort(strings.ToLower(string(*sort)))
+}
+
+func fromAttributeType(t AttributeType) catalog.AttributeType {
+	return catalog.AttributeType(strings.ToLower(string(t)))
+}
+
+func toFacetValues(values []catalog.FacetValue) []*FacetValue {
+	result := make([]*FacetValue, 0, len(values))
+	for _, v := range values {
+		result = append(result, &FacetValue{Value: v.Value, Count: int(v.Count)})
+	}
+	return result
+}
+
+// ProductSuggestions implements QueryResolver.
+func (q *queryResolver) ProductSuggestions(ctx context.Context, prefix string, limit *int) ([]*ProductSuggestion, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	size := 0
+	if limit != nil {
+		if *limit < 0 {
+			return nil, ErrInvalidParameter
+		}
+		size = *limit
+	}
+	suggestions, err := q.server.catalogClient.Suggest(ctx, prefix, size)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	result := make([]*ProductSuggestion, 0, len(suggestions))
+	for _, s := range suggestions {
+		result = append(result, &ProductSuggestion{ProductID: s.ID, Name: s.Name})
+	}
+	return result, nil
+}
+
+// Categories implements QueryResolver.
+func (q *queryResolver) Categories(ctx context.Context, parentID *string) ([]*Category, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	categories, err := q.server.catalogClient.GetCategories(ctx)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return categoryTree(categories, stringValue(parentID)), nil
+}
+
+// Order implements QueryResolver.
+func (q *queryResolver) Order(ctx context.Context, id string) (*Order, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	o, err := q.server.orderClient.GetOrder(ctx, id)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	if err := authorizeAccount(ctx, o.AccountID); err != nil {
+		return nil, err
+	}
+	return toOrder(o), nil
+}
+
+// Orders implements QueryResolver.
+func (q *queryResolver) Orders(ctx context.Context, filter *OrderFilterInput, sort *OrderSort, first *int, after *string) (*OrderConnection, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	orderFilter := order.OrderFilter{}
+	if filter != nil {
+		var err error
+		if orderFilter, err = filter.orderFilter(); err != nil {
+			return nil, err
+		}
+	}
+	if orderFilter.AccountID == "" && !isStaff(ctx) {
+		orderFilter.AccountID = principalID(ctx)
+	}
+	if orderFilter.AccountID != "" {
+		if err := authorizeAccount(ctx, orderFilter.AccountID); err != nil {
+			return nil, err
+		}
+	}
+	orderSort := order.SortCreatedAtDesc
+	if sort != nil {
+		orderSort = order.OrderSort(strings.ToLower(string(*sort)))
+	}
+	limit := 0
+	if first != nil {
+		if *first < 0 {
+			return nil, ErrInvalidParameter
+		}
+		limit = *first
+	}
+
+	page, err := q.server.orderClient.ListOrders(ctx, orderFilter, orderSort, stringValue(after), limit)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	conn := &OrderConnection{
+		Orders:  make([]*Order, 0, len(page.Orders)),
+		HasMore: page.NextCursor != "",
+	}
+	if page.NextCursor != "" {
+		conn.NextCursor = &page.NextCursor
+	}
+	for i := range page.Orders {
+		conn.Orders = append(conn.Orders, toOrder(&page.Orders[i]))
+	}
+	return conn, nil
+}
+
+func (f *OrderFilterInput) orderFilter() (order.OrderFilter, error) {
+	filter := order.OrderFilter{
+		AccountID: stringValue(f.AccountID),
+		ProductID: stringValue(f.ProductID),
+	}
+	if f.CreatedAfter != nil {
+		filter.CreatedAfter = *f.CreatedAfter
+	}
+	if f.CreatedBefore != nil {
+		filter.CreatedBefore = *f.CreatedBefore
+	}
+	for _, st := range f.Statuses {
+		filter.Statuses = append(filter.Statuses, fromOrderStatus(st))
+	}
+	if f.MinTotal != nil {
+		minTotal, err := f.MinTotal.money()
+		if err != nil {
+			return filter, err
+		}
+		filter.MinTotal = &minTotal
+	}
+	if f.MaxTotal != nil {
+		maxTotal, err := f.MaxTotal.money()
+		if err != nil {
+			return filter, err
+		}
+		filter.MaxTotal = &maxTotal
+	}
+	return filter, nil
+}
+
 func (p *PaginationInput) bounds() (uint64, uint64) {
 	skipValue := uint64(0)
 	takeValue := uint64(0)
@@ -107,3 +353,96 @@ func (p *PaginationInput) bounds() (uint64, uint64) {
 	}
 	return skipValue, takeValue
 }
+
+// maxCategoryDepth bounds the levels of subcategories categoryTree fills in.
+const maxCategoryDepth = 32
+
+// categoryTree returns the children of parentID among categories, each with
+// its own children filled in. Each category appears once and at most
+// maxCategoryDepth levels deep, so inconsistent parent links cannot make it
+// loop.
+func categoryTree(categories []catalog.Category, parentID string) []*Category {
+	return subcategories(categories, parentID, map[string]bool{parentID: true}, maxCategoryDepth)
+}
+
+func subcategories(categories []catalog.Category, parentID string, visited map[string]bool, depth int) []*Category {
+	children := []*Category{}
+	if depth == 0 {
+		return children
+	}
+	for i := range categories {
+		if categories[i].ParentID == parentID && !visited[categories[i].ID] {
+			visited[categories[i].ID] = true
+			c := toCategory(&categories[i])
+			c.Children = subcategories(categories, c.ID, visited, depth-1)
+			children = append(children, c)
+		}
+	}
+	return children
+}
+
+func toCategory(c *catalog.Category) *Category {
+	category := &Category{
+		ID:         c.ID,
+		Name:       c.Name,
+		Path:       c.Path,
+		Children:   []*Category{},
+		Attributes: make([]*AttributeDefinition, 0, len(c.Attributes)),
+	}
+	if c.ParentID != "" {
+		category.ParentID = &c.ParentID
+	}
+	for _, d := range c.Attributes {
+		values := d.Values
+		if values == nil {
+			values = []string{}
+		}
+		category.Attributes = append(category.Attributes, &AttributeDefinition{
+			Name:     d.Name,
+			Type:     AttributeType(strings.ToUpper(string(d.Type))),
+			Values:   values,
+			Required: d.Required,
+		})
+	}
+	return category
+}
+
+func toProduct(p *catalog.Product) *Product {
+	product := &Product{
+		ID:          p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		Price:       p.Price.Float64(),
+		PriceMoney:  toMoney(p.Price),
+		Version:     p.Version.String(),
+		CategoryIds: p.CategoryIDs,
+		Attributes:  toAttributes(p.Attributes),
+		Variants:    make([]*ProductVariant, 0, len(p.Variants)),
+	}
+	for _, v := range p.Variants {
+		product.Variants = append(product.Variants, &ProductVariant{
+			Sku:        v.SKU,
+			Attributes: toAttributes(v.Attributes),
+			PriceMoney: toMoney(v.Price),
+		})
+	}
+	if !p.CreatedAt.IsZero() {
+		product.CreatedAt = &p.CreatedAt
+	}
+	if p.IsDeleted() {
+		product.DeletedAt = &p.DeletedAt
+	}
+	return product
+}
+
+// toAttributes orders attributes by name.
+func toAttributes(attributes map[string]string) []*ProductAttribute {
+	result := make([]*ProductAttribute, 0, len(attributes))
+	for name, value := range attributes {
+		result = append(result, &ProductAttribute{Name: name, Value: value})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
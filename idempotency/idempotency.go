@@ -0,0 +1,123 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// Window is how long a key and its response are kept for replays.
+	Window = 24 * time.Hour
+	// PendingTimeout is how long a key may stay claimed without a response
+	// before it is considered abandoned, e.g. by a crashed instance.
+	PendingTimeout = time.Minute
+	MaxKeyLength   = 255
+)
+
+var (
+	ErrKeyTooLong = errors.New("idempotency key is too long")
+	ErrKeyReused  = errors.New("idempotency key was already used for a different request")
+	ErrInProgress = errors.New("a request with this idempotency key is still in progress")
+)
+
+// Record is a stored key. Response is nil while the original request is
+// still being handled.
+type Record struct {
+	Scope       string
+	Key         string
+	RequestHash string
+	Response    []byte
+	CreatedAt   time.Time
+}
+
+// Expired reports whether r no longer blocks a new request with its key.
+func (r Record) Expired(now time.Time) bool {
+	if r.Response == nil {
+		return !now.Before(r.CreatedAt.Add(PendingTimeout))
+	}
+	return !now.Before(r.CreatedAt.Add(Window))
+}
+
+type Store interface {
+	// Claim stores a pending record for scope and key unless a live one
+	// exists. When it does, that record is returned with claimed false.
+	Claim(ctx context.Context, scope, key, requestHash string, now time.Time) (rec *Record, claimed bool, err error)
+	// Complete attaches the response to a claimed key.
+	Complete(ctx context.Context, scope, key string, response []byte) error
+	// Release drops a claimed key so the request can be retried.
+	Release(ctx context.Context, scope, key string) error
+}
+
+// Do runs handle at most once per scope and key within Window. A replay of
+// the same request gets the stored response; reusing a key for a different
+// request is rejected. An empty key or nil store simply calls handle.
+func Do[T proto.Message](ctx context.Context, store Store, scope, key string, req proto.Message, handle func() (T, error)) (T, error) {
+	var zero T
+	if key == "" || store == nil {
+		return handle()
+	}
+	if len(key) > MaxKeyLength {
+		return zero, status.Error(codes.InvalidArgument, ErrKeyTooLong.Error())
+	}
+	hash, err := requestHash(req)
+	if err != nil {
+		return zero, err
+	}
+
+	rec, claimed, err := store.Claim(ctx, scope, key, hash, time.Now().UTC())
+	if err != nil {
+		return zero, err
+	}
+	if !claimed {
+		if rec.RequestHash != hash {
+			return zero, status.Error(codes.FailedPrecondition, ErrKeyReused.Error())
+		}
+		if rec.Response == nil {
+			return zero, status.Error(codes.Aborted, ErrInProgress.Error())
+		}
+		resp := zero.ProtoReflect().New().Interface().(T)
+		if err := proto.Unmarshal(rec.Response, resp); err != nil {
+			return zero, err
+		}
+		return resp, nil
+	}
+
+	resp, err := handle()
+	if err != nil {
+		// Nothing was created, so let the client retry with the same key.
+		// The request context may already be cancelled.
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := store.Release(releaseCtx, scope, key); err != nil {
+			log.Printf("Failed to release idempotency key %s/%s: %v", scope, key, err)
+		}
+		return zero, err
+	}
+	data, err := proto.Marshal(resp)
+	if err == nil {
+		err = store.Complete(ctx, scope, key, data)
+	}
+	if err != nil {
+		// The entity exists, so still answer the client. Retries are
+		// rejected as in progress until the claim is abandoned.
+		log.Printf("Failed to store response for idempotency key %s/%s: %v", scope, key, err)
+	}
+	return resp, nil
+}
+
+func requestHash(req proto.Message) (string, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,117 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/master-wayne7/go-microservices/monitoring"
+)
+
+// PostgresStore keeps keys in the idempotency_keys table of the service's
+// own database:
+//
+//	CREATE TABLE IF NOT EXISTS idempotency_keys (
+//	    scope VARCHAR(64) NOT NULL,
+//	    key VARCHAR(255) NOT NULL,
+//	    request_hash CHAR(64) NOT NULL,
+//	    response BYTEA,
+//	    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+//	    PRIMARY KEY (scope, key)
+//	);
+type PostgresStore struct {
+	db      *sql.DB
+	metrics *monitoring.MetricsCollector
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Allow wiring metrics into the store
+func (s *PostgresStore) SetMetrics(mc *monitoring.MetricsCollector) {
+	s.metrics = mc
+}
+
+func (s *PostgresStore) Claim(ctx context.Context, scope, key, requestHash string, now time.Time) (*Record, bool, error) {
+	start := time.Now()
+	// Take the key if it is new, or replace a record that expired or whose
+	// request was abandoned.
+	var claimedScope string
+	err := s.db.QueryRowContext(
+		ctx,
+		`INSERT INTO idempotency_keys(scope, key, request_hash, response, created_at) VALUES($1, $2, $3, NULL, $4)
+		ON CONFLICT (scope, key) DO UPDATE SET
+			request_hash = EXCLUDED.request_hash,
+			response = NULL,
+			created_at = EXCLUDED.created_at
+		WHERE idempotency_keys.created_at <= $5
+			OR (idempotency_keys.response IS NULL AND idempotency_keys.created_at <= $6)
+		RETURNING scope`,
+		scope,
+		key,
+		requestHash,
+		now,
+		now.Add(-Window),
+		now.Add(-PendingTimeout),
+	).Scan(&claimedScope)
+	if s.metrics != nil {
+		s.metrics.RecordDBQuery("upsert", "idempotency_keys", time.Since(start))
+	}
+	if err == nil {
+		return &Record{Scope: scope, Key: key, RequestHash: requestHash, CreatedAt: now}, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, false, err
+	}
+
+	start = time.Now()
+	rec := &Record{Scope: scope, Key: key}
+	err = s.db.QueryRowContext(
+		ctx,
+		"SELECT request_hash, response, created_at FROM idempotency_keys WHERE scope = $1 AND key = $2",
+		scope,
+		key,
+	).Scan(&rec.RequestHash, &rec.Response, &rec.CreatedAt)
+	if s.metrics != nil {
+		s.metrics.RecordDBQuery("select", "idempotency_keys", time.Since(start))
+	}
+	if err == sql.ErrNoRows {
+		// Released between the two statements; report it as in flight and
+		// let the client retry.
+		return &Record{Scope: scope, Key: key, RequestHash: requestHash}, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return rec, false, nil
+}
+
+func (s *PostgresStore) Complete(ctx context.Context, scope, key string, response []byte) error {
+	start := time.Now()
+	_, err := s.db.ExecContext(
+		ctx,
+		"UPDATE idempotency_keys SET response = $3 WHERE scope = $1 AND key = $2",
+		scope,
+		key,
+		response,
+	)
+	if s.metrics != nil {
+		s.metrics.RecordDBQuery("update", "idempotency_keys", time.Since(start))
+	}
+	return err
+}
+
+func (s *PostgresStore) Release(ctx context.Context, scope, key string) error {
+	start := time.Now()
+	_, err := s.db.ExecContext(
+		ctx,
+		"DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2 AND response IS NULL",
+		scope,
+		key,
+	)
+	if s.metrics != nil {
+		s.metrics.RecordDBQuery("delete", "idempotency_keys", time.Since(start))
+	}
+	return err
+}
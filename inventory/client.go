@@ -0,0 +1,127 @@
+package inventory
+
+import (
+	"context"
+	"time"
+
+	"github.com/master-wayne7/go-microservices/auth"
+	"github.com/master-wayne7/go-microservices/inventory/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type Client struct {
+	conn    *grpc.ClientConn
+	service pb.InventoryServiceClient
+}
+
+func NewClient(url string) (*Client, error) {
+	// Calls carry the caller's identity from the context to the service.
+	conn, err := grpc.Dial(
+		url,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(auth.UnaryClientInterceptor()),
+		grpc.WithStreamInterceptor(auth.StreamClientInterceptor()),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	c := pb.NewInventoryServiceClient(conn)
+	return &Client{conn: conn, service: c}, nil
+}
+
+func (c *Client) Close() {
+	c.conn.Close()
+}
+
+func (c *Client) SetStock(ctx context.Context, sku string, onHand uint32) (*StockLevel, error) {
+	r, err := c.service.SetStock(ctx, &pb.SetStockRequest{
+		Sku:    sku,
+		OnHand: onHand,
+	})
+	if err != nil {
+		return nil, err
+	}
+	l := stockFromProto(r.Stock)
+	return &l, nil
+}
+
+func (c *Client) GetStock(ctx context.Context, skus []string) ([]StockLevel, error) {
+	r, err := c.service.GetStock(ctx, &pb.GetStockRequest{
+		Skus: skus,
+	})
+	if err != nil {
+		return nil, err
+	}
+	levels := make([]StockLevel, 0, len(r.Stock))
+	for _, l := range r.Stock {
+		levels = append(levels, stockFromProto(l))
+	}
+	return levels, nil
+}
+
+func (c *Client) ReserveStock(ctx context.Context, reference string, items []ReservationItem, ttl time.Duration) (*Reservation, error) {
+	protoItems := make([]*pb.ReservationItem, 0, len(items))
+	for _, item := range items {
+		protoItems = append(protoItems, &pb.ReservationItem{
+			Sku:      item.SKU,
+			Quantity: item.Quantity,
+		})
+	}
+	r, err := c.service.ReserveStock(ctx, &pb.ReserveStockRequest{
+		Reference:  reference,
+		Items:      protoItems,
+		TtlSeconds: uint32(ttl / time.Second),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reservationFromProto(r.Reservation), nil
+}
+
+func (c *Client) CommitReservation(ctx context.Context, id string) (*Reservation, error) {
+	r, err := c.service.CommitReservation(ctx, &pb.CommitReservationRequest{
+		Id: id,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reservationFromProto(r.Reservation), nil
+}
+
+func (c *Client) ReleaseReservation(ctx context.Context, id string) (*Reservation, error) {
+	r, err := c.service.ReleaseReservation(ctx, &pb.ReleaseReservationRequest{
+		Id: id,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reservationFromProto(r.Reservation), nil
+}
+
+func stockFromProto(l *pb.StockLevel) StockLevel {
+	return StockLevel{
+		SKU:      l.Sku,
+		OnHand:   l.OnHand,
+		Reserved: l.Reserved,
+	}
+}
+
+func reservationFromProto(rp *pb.Reservation) *Reservation {
+	res := &Reservation{
+		ID:        rp.Id,
+		Reference: rp.Reference,
+		Status:    ReservationStatus(rp.Status),
+		Items:     make([]ReservationItem, 0, len(rp.Items)),
+	}
+	res.CreatedAt.UnmarshalBinary(rp.CreatedAt)
+	res.ExpiresAt.UnmarshalBinary(rp.ExpiresAt)
+	for _, item := range rp.Items {
+		res.Items = append(res.Items, ReservationItem{
+			SKU:      item.Sku,
+			Quantity: item.Quantity,
+		})
+	}
+	return res
+}
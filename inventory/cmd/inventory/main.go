@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+	"github.com/master-wayne7/go-microservices/auth"
+	"github.com/master-wayne7/go-microservices/events"
+	"github.com/master-wayne7/go-microservices/inventory"
+	"github.com/master-wayne7/go-microservices/monitoring"
+	"github.com/master-wayne7/go-microservices/schema"
+	"github.com/tinrab/retry"
+)
+
+type Config struct {
+	DatabaseURL string `envconfig:"DATABASE_URL"`
+	// How often expired reservations are released back to stock
+	SweepInterval time.Duration `envconfig:"RESERVATION_SWEEP_INTERVAL" default:"30s"`
+	// Where outbox events are published, e.g. file:///var/lib/events/inventory.ndjson
+	EventBrokerURL string `envconfig:"EVENT_BROKER_URL"`
+	// How often the outbox is relayed to the broker
+	EventRelayInterval time.Duration `envconfig:"EVENT_RELAY_INTERVAL" default:"5s"`
+	// Verifies bearer tokens of callers and signs the service's own calls
+	TokenSecret string `envconfig:"AUTH_TOKEN_SECRET" required:"true"`
+}
+
+// Health check handler for container orchestration
+func healthCheck(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("OK"))
+}
+
+func main() {
+	var cfg Config
+	err := envconfig.Process("", &cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if cfg.DatabaseURL == "" {
+		log.Fatal("DATABASE_URL environment variable is required")
+	}
+
+	// Initialize Prometheus metrics
+	metrics := monitoring.NewMetricsCollector("inventory-service")
+	metrics.SetServiceInfo("1.0.0", "development")
+
+	// Start health check server on separate port
+	go func() {
+		mux := http.NewServeMux()
+		// Wrap health and metrics endpoints with HTTP metrics
+		mux.Handle("/health", monitoring.HTTPMiddleware(metrics)(http.HandlerFunc(healthCheck)))
+		mux.Handle("/metrics", monitoring.HTTPMiddleware(metrics)(metrics.PrometheusHandler()))
+		log.Println("Health check server starting on port 8090...")
+		log.Fatal(http.ListenAndServe(":8090", mux))
+	}()
+
+	var r inventory.Repository
+	retry.ForeverSleep(2*time.Second, func(_ int) (err error) {
+		r, err = inventory.NewPostgresRepository(cfg.DatabaseURL)
+		if errors.Is(err, schema.ErrOutdated) {
+			log.Fatal(err)
+		}
+		if err != nil {
+			log.Println(err)
+		}
+		return
+	})
+	defer r.Close()
+
+	// Wire metrics into repository for DB query metrics
+	if pr, ok := r.(*inventory.PostgresRepository); ok {
+		pr.SetMetrics(metrics)
+	}
+
+	// Start system metrics collection
+	metrics.StartSystemMetricsCollection(r.(*inventory.PostgresRepository).DB())
+
+	// Relay outbox events to the configured broker
+	broker, err := events.NewBroker(cfg.EventBrokerURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer broker.Close()
+	source := events.NewPostgresSource(r.(*inventory.PostgresRepository).DB())
+	source.SetMetrics(metrics)
+	events.StartRelay(source, broker, cfg.EventRelayInterval)
+
+	signer, err := auth.NewSigner(cfg.TokenSecret)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s := inventory.NewService(r)
+	inventory.StartReservationSweeper(s, cfg.SweepInterval)
+
+	log.Println("Listening on port 8089...")
+	log.Fatal(inventory.ListenGRPC(s, signer, 8089, metrics))
+}
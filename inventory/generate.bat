@@ -0,0 +1,11 @@
+@echo off
+echo Generating protobuf files...
+mkdir pb 2>nul
+protoc --go_out=./ --go-grpc_out=./ inventory.proto
+if %ERRORLEVEL% neq 0 (
+    echo Error: Failed to generate protobuf files
+    pause
+    exit /b 1
+)
+echo Done!
+pause
\ No newline at end of file
@@ -0,0 +1,17 @@
+package inventory
+
+import (
+	"github.com/master-wayne7/go-microservices/auth"
+	"github.com/master-wayne7/go-microservices/inventory/pb"
+)
+
+// permissions decides who may call each InventoryService method. Staff set
+// stock levels; reservations are only made by other services, e.g. the order
+// service.
+var permissions = auth.Rules{
+	pb.InventoryService_GetStock_FullMethodName:           auth.Authenticated,
+	pb.InventoryService_SetStock_FullMethodName:           auth.RequireRole(auth.RoleStaff),
+	pb.InventoryService_ReserveStock_FullMethodName:       auth.ServiceOnly,
+	pb.InventoryService_CommitReservation_FullMethodName:  auth.ServiceOnly,
+	pb.InventoryService_ReleaseReservation_FullMethodName: auth.ServiceOnly,
+}
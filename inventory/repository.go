@@ -0,0 +1,367 @@
+package inventory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/master-wayne7/go-microservices/events"
+	"github.com/master-wayne7/go-microservices/monitoring"
+	"github.com/master-wayne7/go-microservices/schema"
+)
+
+var (
+	ErrNotFound           = errors.New("reservation not found")
+	ErrInsufficientStock  = errors.New("insufficient stock")
+	ErrEmptyReservation   = errors.New("reservation has no items")
+	ErrInvalidQuantity    = errors.New("quantity out of range")
+	ErrReservationClosed  = errors.New("reservation is no longer pending")
+	ErrReservationExpired = errors.New("reservation has expired")
+)
+
+type Repository interface {
+	Close()
+	SetStock(ctx context.Context, sku string, onHand uint32) (*StockLevel, error)
+	GetStock(ctx context.Context, skus []string) ([]StockLevel, error)
+	PutReservation(ctx context.Context, r Reservation) error
+	CloseReservation(ctx context.Context, id string, to ReservationStatus, now time.Time) (*Reservation, error)
+	ListExpiredReservations(ctx context.Context, now time.Time) ([]string, error)
+}
+
+type PostgresRepository struct {
+	db *sql.DB
+	// Attach metrics collector for DB query metrics
+	metrics *monitoring.MetricsCollector
+}
+
+// columns are the columns the service needs, whose absence means the
+// database was created from an older up.sql.
+var columns = []schema.Column{
+	{Table: "stock", Name: "sku"},
+	{Table: "stock", Name: "reserved"},
+	{Table: "reservations", Name: "reference"},
+	{Table: "reservation_items", Name: "sku"},
+	{Table: "outbox", Name: "published_at"},
+}
+
+func NewPostgresRepository(url string) (Repository, error) {
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		return nil, err
+	}
+	err = db.Ping()
+	if err != nil {
+		return nil, err
+	}
+	if err := schema.Check(context.Background(), db, columns); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &PostgresRepository{
+		db: db,
+	}, nil
+}
+
+func (r *PostgresRepository) Close() {
+	r.db.Close()
+}
+
+// Add DB accessor for metrics
+func (r *PostgresRepository) DB() *sql.DB {
+	return r.db
+}
+
+// Allow wiring metrics into repository
+func (r *PostgresRepository) SetMetrics(mc *monitoring.MetricsCollector) {
+	r.metrics = mc
+}
+
+func (r *PostgresRepository) SetStock(ctx context.Context, sku string, onHand uint32) (l *StockLevel, err error) {
+	start := time.Now()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			l = nil
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	// On-hand stock may never drop below what is already promised to
+	// pending reservations.
+	row := tx.QueryRowContext(
+		ctx,
+		`INSERT INTO stock(sku, on_hand, reserved) VALUES($1, $2, 0)
+		ON CONFLICT (sku) DO UPDATE SET on_hand = EXCLUDED.on_hand
+		WHERE stock.reserved <= EXCLUDED.on_hand
+		RETURNING on_hand, reserved`,
+		sku,
+		onHand,
+	)
+	l = &StockLevel{SKU: sku}
+	err = row.Scan(&l.OnHand, &l.Reserved)
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("upsert", "stock", time.Since(start))
+	}
+	if err == sql.ErrNoRows {
+		err = fmt.Errorf("%w: %s has more units reserved than %d", ErrInsufficientStock, sku, onHand)
+		return
+	}
+	if err != nil {
+		return
+	}
+	event, err := events.NewEvent(events.StockUpdated, "stock", sku, l)
+	if err != nil {
+		return
+	}
+	err = events.WriteOutbox(ctx, tx, event)
+	return
+}
+
+func (r *PostgresRepository) GetStock(ctx context.Context, skus []string) ([]StockLevel, error) {
+	start := time.Now()
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT sku, on_hand, reserved FROM stock WHERE sku = ANY($1)",
+		pq.Array(skus),
+	)
+	if err != nil {
+		if r.metrics != nil {
+			r.metrics.RecordDBQuery("select", "stock", time.Since(start))
+		}
+		return nil, err
+	}
+	defer rows.Close()
+
+	levels := []StockLevel{}
+	for rows.Next() {
+		l := StockLevel{}
+		if err := rows.Scan(&l.SKU, &l.OnHand, &l.Reserved); err != nil {
+			return nil, err
+		}
+		levels = append(levels, l)
+	}
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("select", "stock", time.Since(start))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return levels, nil
+}
+
+func (r *PostgresRepository) PutReservation(ctx context.Context, res Reservation) (err error) {
+	event, err := events.NewEvent(events.ReservationCreated, "reservation", res.ID, res)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO reservations(id, reference, status, created_at, expires_at) VALUES($1, $2, $3, $4, $5)",
+		res.ID,
+		res.Reference,
+		res.Status,
+		res.CreatedAt,
+		res.ExpiresAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range res.Items {
+		var result sql.Result
+		result, err = tx.ExecContext(
+			ctx,
+			"UPDATE stock SET reserved = reserved + $2 WHERE sku = $1 AND on_hand - reserved >= $2",
+			item.SKU,
+			item.Quantity,
+		)
+		if err != nil {
+			return err
+		}
+		var n int64
+		if n, err = result.RowsAffected(); err != nil {
+			return err
+		}
+		if n == 0 {
+			err = fmt.Errorf("%w: SKU %s", ErrInsufficientStock, item.SKU)
+			return err
+		}
+		_, err = tx.ExecContext(
+			ctx,
+			"INSERT INTO reservation_items(reservation_id, sku, quantity) VALUES($1, $2, $3)",
+			res.ID,
+			item.SKU,
+			item.Quantity,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	if err = events.WriteOutbox(ctx, tx, event); err != nil {
+		return err
+	}
+
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("tx", "reservations", time.Since(start))
+	}
+	return
+}
+
+// CloseReservation moves a pending reservation to committed or released and
+// applies its quantities to stock in the same transaction. Releasing a
+// committed reservation, e.g. for a cancelled order, puts its units back on
+// hand. Closing a reservation again with the status it already has returns it
+// unchanged.
+func (r *PostgresRepository) CloseReservation(ctx context.Context, id string, to ReservationStatus, now time.Time) (res *Reservation, err error) {
+	start := time.Now()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			res = nil
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	res = &Reservation{ID: id}
+	err = tx.QueryRowContext(
+		ctx,
+		"SELECT reference, status, created_at, expires_at FROM reservations WHERE id = $1 FOR UPDATE",
+		id,
+	).Scan(&res.Reference, &res.Status, &res.CreatedAt, &res.ExpiresAt)
+	if err == sql.ErrNoRows {
+		err = ErrNotFound
+		return
+	}
+	if err != nil {
+		return
+	}
+	rows, err := tx.QueryContext(
+		ctx,
+		"SELECT sku, quantity FROM reservation_items WHERE reservation_id = $1 ORDER BY sku",
+		id,
+	)
+	if err != nil {
+		return
+	}
+	for rows.Next() {
+		item := ReservationItem{}
+		if err = rows.Scan(&item.SKU, &item.Quantity); err != nil {
+			rows.Close()
+			return
+		}
+		res.Items = append(res.Items, item)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return
+	}
+
+	// Repeating the close that already happened is a no-op, so callers can
+	// safely retry a commit or release.
+	if res.Status == to {
+		return
+	}
+	restock := res.Status == ReservationCommitted && to == ReservationReleased
+	if res.Status != ReservationPending && !restock {
+		err = fmt.Errorf("%w: %s is %s", ErrReservationClosed, id, res.Status)
+		return
+	}
+	if to == ReservationCommitted && !now.Before(res.ExpiresAt) {
+		err = ErrReservationExpired
+		return
+	}
+
+	stockUpdate := "UPDATE stock SET reserved = reserved - $2 WHERE sku = $1"
+	switch {
+	case restock:
+		stockUpdate = "UPDATE stock SET on_hand = on_hand + $2 WHERE sku = $1"
+	case to == ReservationCommitted:
+		stockUpdate = "UPDATE stock SET on_hand = on_hand - $2, reserved = reserved - $2 WHERE sku = $1"
+	}
+	for _, item := range res.Items {
+		if _, err = tx.ExecContext(ctx, stockUpdate, item.SKU, item.Quantity); err != nil {
+			return
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx, "UPDATE reservations SET status = $2 WHERE id = $1", id, to); err != nil {
+		return
+	}
+	res.Status = to
+
+	eventType := events.ReservationReleased
+	if to == ReservationCommitted {
+		eventType = events.ReservationCommitted
+	}
+	event, err := events.NewEvent(eventType, "reservation", res.ID, res)
+	if err != nil {
+		return
+	}
+	if err = events.WriteOutbox(ctx, tx, event); err != nil {
+		return
+	}
+
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("tx", "reservations", time.Since(start))
+	}
+	return
+}
+
+func (r *PostgresRepository) ListExpiredReservations(ctx context.Context, now time.Time) ([]string, error) {
+	start := time.Now()
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT id FROM reservations WHERE status = $1 AND expires_at <= $2 ORDER BY expires_at LIMIT 100",
+		ReservationPending,
+		now,
+	)
+	if err != nil {
+		if r.metrics != nil {
+			r.metrics.RecordDBQuery("select", "reservations", time.Since(start))
+		}
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("select", "reservations", time.Since(start))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
@@ -0,0 +1,165 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/master-wayne7/go-microservices/auth"
+	"github.com/master-wayne7/go-microservices/inventory/pb"
+	"github.com/master-wayne7/go-microservices/monitoring"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+type grpcServer struct {
+	pb.UnimplementedInventoryServiceServer
+	service Service
+}
+
+func ListenGRPC(s Service, signer *auth.Signer, port int, metrics *monitoring.MetricsCollector) error {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return err
+	}
+
+	// Add gRPC interceptors for metrics, then authentication and
+	// per-method permissions
+	serv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			monitoring.GRPCUnaryServerInterceptor(metrics),
+			auth.UnaryServerInterceptor(signer, permissions),
+		),
+		grpc.ChainStreamInterceptor(
+			monitoring.GRPCStreamServerInterceptor(metrics),
+			auth.StreamServerInterceptor(signer, permissions),
+		),
+	)
+	pb.RegisterInventoryServiceServer(serv, &grpcServer{service: s, UnimplementedInventoryServiceServer: pb.UnimplementedInventoryServiceServer{}})
+	reflection.Register(serv)
+	return serv.Serve(lis)
+}
+
+func (s *grpcServer) SetStock(ctx context.Context, r *pb.SetStockRequest) (*pb.SetStockResponse, error) {
+	l, err := s.service.SetStock(ctx, r.Sku, r.OnHand)
+	if err != nil {
+		return nil, inventoryError(err)
+	}
+	return &pb.SetStockResponse{
+		Stock: stockToProto(*l),
+	}, nil
+}
+
+func (s *grpcServer) GetStock(ctx context.Context, r *pb.GetStockRequest) (*pb.GetStockResponse, error) {
+	levels, err := s.service.GetStock(ctx, r.Skus)
+	if err != nil {
+		return nil, inventoryError(err)
+	}
+	stock := make([]*pb.StockLevel, 0, len(levels))
+	for _, l := range levels {
+		stock = append(stock, stockToProto(l))
+	}
+	return &pb.GetStockResponse{
+		Stock: stock,
+	}, nil
+}
+
+func (s *grpcServer) ReserveStock(ctx context.Context, r *pb.ReserveStockRequest) (*pb.ReserveStockResponse, error) {
+	items := make([]ReservationItem, 0, len(r.Items))
+	for _, item := range r.Items {
+		items = append(items, ReservationItem{
+			SKU:      item.Sku,
+			Quantity: item.Quantity,
+		})
+	}
+	res, err := s.service.ReserveStock(ctx, r.Reference, items, time.Duration(r.TtlSeconds)*time.Second)
+	if err != nil {
+		return nil, inventoryError(err)
+	}
+	resProto, err := reservationToProto(res)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ReserveStockResponse{
+		Reservation: resProto,
+	}, nil
+}
+
+func (s *grpcServer) CommitReservation(ctx context.Context, r *pb.CommitReservationRequest) (*pb.CommitReservationResponse, error) {
+	res, err := s.service.CommitReservation(ctx, r.Id)
+	if err != nil {
+		return nil, inventoryError(err)
+	}
+	resProto, err := reservationToProto(res)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CommitReservationResponse{
+		Reservation: resProto,
+	}, nil
+}
+
+func (s *grpcServer) ReleaseReservation(ctx context.Context, r *pb.ReleaseReservationRequest) (*pb.ReleaseReservationResponse, error) {
+	res, err := s.service.ReleaseReservation(ctx, r.Id)
+	if err != nil {
+		return nil, inventoryError(err)
+	}
+	resProto, err := reservationToProto(res)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ReleaseReservationResponse{
+		Reservation: resProto,
+	}, nil
+}
+
+func stockToProto(l StockLevel) *pb.StockLevel {
+	return &pb.StockLevel{
+		Sku:       l.SKU,
+		OnHand:    l.OnHand,
+		Reserved:  l.Reserved,
+		Available: l.Available(),
+	}
+}
+
+func reservationToProto(res *Reservation) (*pb.Reservation, error) {
+	rp := &pb.Reservation{
+		Id:        res.ID,
+		Reference: res.Reference,
+		Status:    string(res.Status),
+		Items:     []*pb.ReservationItem{},
+	}
+	var err error
+	if rp.CreatedAt, err = res.CreatedAt.MarshalBinary(); err != nil {
+		return nil, fmt.Errorf("could not marshal reservation timestamp: %w", err)
+	}
+	if rp.ExpiresAt, err = res.ExpiresAt.MarshalBinary(); err != nil {
+		return nil, fmt.Errorf("could not marshal reservation timestamp: %w", err)
+	}
+	for _, item := range res.Items {
+		rp.Items = append(rp.Items, &pb.ReservationItem{
+			Sku:      item.SKU,
+			Quantity: item.Quantity,
+		})
+	}
+	return rp, nil
+}
+
+// inventoryError maps service errors onto gRPC status codes.
+func inventoryError(err error) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, ErrEmptyReservation), errors.Is(err, ErrInvalidQuantity):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, ErrInsufficientStock),
+		errors.Is(err, ErrReservationClosed),
+		errors.Is(err, ErrReservationExpired):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return err
+}
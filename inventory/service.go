@@ -0,0 +1,196 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/segmentio/ksuid"
+)
+
+const (
+	DefaultReservationTTL = 15 * time.Minute
+	MaxReservationTTL     = 24 * time.Hour
+)
+
+type Service interface {
+	SetStock(ctx context.Context, sku string, onHand uint32) (*StockLevel, error)
+	GetStock(ctx context.Context, skus []string) ([]StockLevel, error)
+	ReserveStock(ctx context.Context, reference string, items []ReservationItem, ttl time.Duration) (*Reservation, error)
+	CommitReservation(ctx context.Context, id string) (*Reservation, error)
+	ReleaseReservation(ctx context.Context, id string) (*Reservation, error)
+	ReleaseExpiredReservations(ctx context.Context) (int, error)
+}
+
+// StockLevel is the stock of one SKU: a product variant, or a product
+// without variants, whose SKU is its ID.
+type StockLevel struct {
+	SKU      string `json:"sku"`
+	OnHand   uint32 `json:"on_hand"`
+	Reserved uint32 `json:"reserved"`
+}
+
+func (s StockLevel) Available() uint32 {
+	return s.OnHand - s.Reserved
+}
+
+type ReservationStatus string
+
+const (
+	ReservationPending   ReservationStatus = "pending"
+	ReservationCommitted ReservationStatus = "committed"
+	ReservationReleased  ReservationStatus = "released"
+)
+
+type ReservationItem struct {
+	SKU      string `json:"sku"`
+	Quantity uint32 `json:"quantity"`
+}
+
+type Reservation struct {
+	ID        string            `json:"id"`
+	Reference string            `json:"reference"`
+	Status    ReservationStatus `json:"status"`
+	Items     []ReservationItem `json:"items"`
+	CreatedAt time.Time         `json:"created_at"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+type inventoryService struct {
+	repository Repository
+}
+
+func NewService(r Repository) Service {
+	return &inventoryService{repository: r}
+}
+
+// MaxQuantity bounds stock levels and reserved quantities, which are
+// stored in INT columns.
+const MaxQuantity = math.MaxInt32
+
+// SetStock implements Service.
+func (s *inventoryService) SetStock(ctx context.Context, sku string, onHand uint32) (*StockLevel, error) {
+	if onHand > MaxQuantity {
+		return nil, fmt.Errorf("%w: stock of %s exceeds %d", ErrInvalidQuantity, sku, MaxQuantity)
+	}
+	return s.repository.SetStock(ctx, sku, onHand)
+}
+
+// GetStock implements Service. SKUs that were never stocked are reported
+// with zero stock.
+func (s *inventoryService) GetStock(ctx context.Context, skus []string) ([]StockLevel, error) {
+	levels, err := s.repository.GetStock(ctx, skus)
+	if err != nil {
+		return nil, err
+	}
+	bySKU := map[string]StockLevel{}
+	for _, l := range levels {
+		bySKU[l.SKU] = l
+	}
+	stock := make([]StockLevel, 0, len(skus))
+	for _, sku := range skus {
+		l, ok := bySKU[sku]
+		if !ok {
+			l = StockLevel{SKU: sku}
+		}
+		stock = append(stock, l)
+	}
+	return stock, nil
+}
+
+// ReserveStock implements Service. Either every item is reserved or none is.
+func (s *inventoryService) ReserveStock(ctx context.Context, reference string, items []ReservationItem, ttl time.Duration) (*Reservation, error) {
+	if ttl <= 0 {
+		ttl = DefaultReservationTTL
+	}
+	if ttl > MaxReservationTTL {
+		ttl = MaxReservationTTL
+	}
+
+	// Merge duplicate lines and lock rows in a stable order so concurrent
+	// reservations cannot deadlock each other.
+	quantities := map[string]uint64{}
+	for _, item := range items {
+		if item.Quantity == 0 {
+			continue
+		}
+		quantities[item.SKU] += uint64(item.Quantity)
+		if quantities[item.SKU] > MaxQuantity {
+			return nil, fmt.Errorf("%w: quantity of %s exceeds %d", ErrInvalidQuantity, item.SKU, MaxQuantity)
+		}
+	}
+	if len(quantities) == 0 {
+		return nil, ErrEmptyReservation
+	}
+	merged := make([]ReservationItem, 0, len(quantities))
+	for sku, q := range quantities {
+		merged = append(merged, ReservationItem{SKU: sku, Quantity: uint32(q)})
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].SKU < merged[j].SKU })
+
+	now := time.Now().UTC()
+	res := &Reservation{
+		ID:        ksuid.New().String(),
+		Reference: reference,
+		Status:    ReservationPending,
+		Items:     merged,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	if err := s.repository.PutReservation(ctx, *res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// CommitReservation implements Service. Committing turns the reserved
+// quantities into sold stock.
+func (s *inventoryService) CommitReservation(ctx context.Context, id string) (*Reservation, error) {
+	return s.repository.CloseReservation(ctx, id, ReservationCommitted, time.Now().UTC())
+}
+
+// ReleaseReservation implements Service. Releasing returns the reserved
+// quantities to available stock, or restocks them if the reservation was
+// already committed.
+func (s *inventoryService) ReleaseReservation(ctx context.Context, id string) (*Reservation, error) {
+	return s.repository.CloseReservation(ctx, id, ReservationReleased, time.Now().UTC())
+}
+
+// ReleaseExpiredReservations implements Service.
+func (s *inventoryService) ReleaseExpiredReservations(ctx context.Context) (int, error) {
+	ids, err := s.repository.ListExpiredReservations(ctx, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+	released := 0
+	for _, id := range ids {
+		if _, err := s.ReleaseReservation(ctx, id); err != nil {
+			log.Printf("Failed to release expired reservation %s: %v", id, err)
+			continue
+		}
+		released++
+	}
+	return released, nil
+}
+
+// StartReservationSweeper periodically releases reservations whose TTL has
+// passed, so stock held by abandoned orders becomes available again.
+func StartReservationSweeper(s Service, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			n, err := s.ReleaseExpiredReservations(context.Background())
+			if err != nil {
+				log.Println("Error releasing expired reservations: ", err)
+				continue
+			}
+			if n > 0 {
+				log.Printf("Released %d expired reservations", n)
+			}
+		}
+	}()
+}
@@ -0,0 +1,35 @@
+-- Stock is kept per SKU. Products without variants use their id as SKU.
+CREATE TABLE IF NOT EXISTS stock (
+    sku VARCHAR(64) PRIMARY KEY,
+    on_hand INT NOT NULL CHECK (on_hand >= 0),
+    reserved INT NOT NULL DEFAULT 0 CHECK (reserved >= 0 AND reserved <= on_hand)
+);
+
+CREATE TABLE IF NOT EXISTS reservations (
+    id CHAR(27) PRIMARY KEY,
+    reference VARCHAR(64) NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS reservations_pending_expiry_idx ON reservations (expires_at) WHERE status = 'pending';
+
+CREATE TABLE IF NOT EXISTS reservation_items (
+    reservation_id CHAR(27) REFERENCES reservations (id) ON DELETE CASCADE,
+    sku VARCHAR(64) NOT NULL,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (reservation_id, sku)
+);
+
+CREATE TABLE IF NOT EXISTS outbox (
+    id CHAR(27) PRIMARY KEY,
+    type VARCHAR(64) NOT NULL,
+    aggregate_type VARCHAR(32) NOT NULL,
+    aggregate_id VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL,
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    published_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS outbox_unpublished_idx ON outbox (occurred_at, id) WHERE published_at IS NULL;
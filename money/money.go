@@ -0,0 +1,187 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is used whenever a price arrives without a currency code,
+// e.g. from clients still sending plain float prices.
+const DefaultCurrency = "USD"
+
+var (
+	ErrInvalidAmount    = errors.New("invalid money amount")
+	ErrInvalidCurrency  = errors.New("invalid currency code")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrOverflow         = errors.New("money amount out of range")
+)
+
+// exponents lists ISO 4217 currencies whose minor unit is not 1/100.
+var exponents = map[string]int{
+	"BHD": 3,
+	"CLP": 0,
+	"IQD": 3,
+	"ISK": 0,
+	"JOD": 3,
+	"JPY": 0,
+	"KRW": 0,
+	"KWD": 3,
+	"OMR": 3,
+	"TND": 3,
+	"VND": 0,
+}
+
+// Money is an exact amount of a currency, held in minor units
+// (e.g. cents for USD).
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: normalizeCurrency(currency)}
+}
+
+// FromFloat converts a legacy float price into Money, rounding to the
+// nearest minor unit.
+func FromFloat(f float64, currency string) Money {
+	currency = normalizeCurrency(currency)
+	return Money{
+		Amount:   int64(math.Round(f * math.Pow10(Exponent(currency)))),
+		Currency: currency,
+	}
+}
+
+// Parse reads a decimal string such as "19.99" or "-5" into Money without
+// going through floating point. The amount takes an optional sign and at
+// least one digit; the currency is an ISO 4217 code.
+func Parse(s, currency string) (Money, error) {
+	currency = normalizeCurrency(currency)
+	if !ValidCurrency(currency) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidCurrency, currency)
+	}
+	exp := Exponent(currency)
+
+	s = strings.TrimSpace(s)
+	digits, neg := strings.CutPrefix(s, "-")
+	if !neg {
+		digits = strings.TrimPrefix(digits, "+")
+	}
+	whole, frac, _ := strings.Cut(digits, ".")
+	if whole+frac == "" || !isDigits(whole) || !isDigits(frac) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	// Extra fractional digits are accepted only when they are zeros, e.g. a
+	// NUMERIC(19,4) column read back as "19.9900".
+	if len(frac) > exp {
+		if strings.Trim(frac[exp:], "0") != "" {
+			return Money{}, fmt.Errorf("%w: %q has more than %d decimals", ErrInvalidAmount, s, exp)
+		}
+		frac = frac[:exp]
+	}
+	frac += strings.Repeat("0", exp-len(frac))
+
+	amount, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if neg {
+		amount = -amount
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// ValidCurrency reports whether currency has the form of an ISO 4217 code:
+// three uppercase letters.
+func ValidCurrency(currency string) bool {
+	if len(currency) != 3 {
+		return false
+	}
+	for _, c := range []byte(currency) {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigits(s string) bool {
+	for _, c := range []byte(s) {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Exponent returns the number of decimals of the currency's minor unit.
+func Exponent(currency string) int {
+	if exp, ok := exponents[strings.ToUpper(currency)]; ok {
+		return exp
+	}
+	return 2
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Float64 is the lossy compatibility view of m for callers that still use
+// float prices.
+func (m Money) Float64() float64 {
+	return float64(m.Amount) / math.Pow10(Exponent(m.Currency))
+}
+
+// String formats m as a plain decimal, e.g. "19.99".
+func (m Money) String() string {
+	exp := Exponent(m.Currency)
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	digits := strconv.FormatInt(amount, 10)
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+// Mul multiplies m by n, failing with ErrOverflow if the product does not
+// fit in an int64.
+func (m Money) Mul(n int64) (Money, error) {
+	amount := m.Amount * n
+	if n != 0 && (amount/n != m.Amount || (n == -1 && m.Amount == math.MinInt64)) {
+		return Money{}, fmt.Errorf("%w: %s times %d", ErrOverflow, m, n)
+	}
+	return Money{Amount: amount, Currency: m.Currency}, nil
+}
+
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency == "" {
+		m.Currency = o.Currency
+	}
+	if o.Amount != 0 && o.Currency != m.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	amount := m.Amount + o.Amount
+	if (o.Amount > 0 && amount < m.Amount) || (o.Amount < 0 && amount > m.Amount) {
+		return Money{}, fmt.Errorf("%w: %s plus %s", ErrOverflow, m, o)
+	}
+	return Money{Amount: amount, Currency: m.Currency}, nil
+}
+
+func normalizeCurrency(currency string) string {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		return DefaultCurrency
+	}
+	return currency
+}
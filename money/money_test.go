@@ -0,0 +1,115 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		s, currency string
+		want        Money
+		err         error
+	}{
+		{s: "19.99", currency: "USD", want: Money{Amount: 1999, Currency: "USD"}},
+		{s: " 5 ", currency: "usd", want: Money{Amount: 500, Currency: "USD"}},
+		{s: "+5", currency: "USD", want: Money{Amount: 500, Currency: "USD"}},
+		{s: "-5.5", currency: "USD", want: Money{Amount: -550, Currency: "USD"}},
+		{s: ".5", currency: "USD", want: Money{Amount: 50, Currency: "USD"}},
+		{s: "5.", currency: "USD", want: Money{Amount: 500, Currency: "USD"}},
+		{s: "19.9900", currency: "USD", want: Money{Amount: 1999, Currency: "USD"}},
+		{s: "1000", currency: "JPY", want: Money{Amount: 1000, Currency: "JPY"}},
+		{s: "1.234", currency: "KWD", want: Money{Amount: 1234, Currency: "KWD"}},
+		{s: "2", currency: "", want: Money{Amount: 200, Currency: DefaultCurrency}},
+		{s: "", currency: "USD", err: ErrInvalidAmount},
+		{s: ".", currency: "USD", err: ErrInvalidAmount},
+		{s: "-", currency: "USD", err: ErrInvalidAmount},
+		{s: "-+5", currency: "USD", err: ErrInvalidAmount},
+		{s: "+-5", currency: "USD", err: ErrInvalidAmount},
+		{s: "--5", currency: "USD", err: ErrInvalidAmount},
+		{s: "1.2.3", currency: "USD", err: ErrInvalidAmount},
+		{s: "1e3", currency: "USD", err: ErrInvalidAmount},
+		{s: "1 000", currency: "USD", err: ErrInvalidAmount},
+		{s: "19.999", currency: "USD", err: ErrInvalidAmount},
+		{s: "1.5", currency: "JPY", err: ErrInvalidAmount},
+		{s: "99999999999999999999", currency: "USD", err: ErrInvalidAmount},
+		{s: "5", currency: "US", err: ErrInvalidCurrency},
+		{s: "5", currency: "USDX", err: ErrInvalidCurrency},
+		{s: "5", currency: "U5D", err: ErrInvalidCurrency},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.s, tt.currency)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Parse(%q, %q) error = %v, want %v", tt.s, tt.currency, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Parse(%q, %q) = %+v, %v, want %+v", tt.s, tt.currency, got, err, tt.want)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		m    Money
+		want string
+	}{
+		{Money{Amount: 1999, Currency: "USD"}, "19.99"},
+		{Money{Amount: 5, Currency: "USD"}, "0.05"},
+		{Money{Amount: 0, Currency: "USD"}, "0.00"},
+		{Money{Amount: -550, Currency: "USD"}, "-5.50"},
+		{Money{Amount: 1000, Currency: "JPY"}, "1000"},
+		{Money{Amount: 1234, Currency: "KWD"}, "1.234"},
+	}
+	for _, tt := range tests {
+		if got := tt.m.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.m, got, tt.want)
+		}
+		if parsed, err := Parse(tt.want, tt.m.Currency); err != nil || parsed != tt.m {
+			t.Errorf("Parse(%q, %q) = %+v, %v, want %+v", tt.want, tt.m.Currency, parsed, err, tt.m)
+		}
+	}
+}
+
+func TestMul(t *testing.T) {
+	tests := []struct {
+		amount, n int64
+		want      int64
+		overflow  bool
+	}{
+		{amount: 1999, n: 3, want: 5997},
+		{amount: 1999, n: 0, want: 0},
+		{amount: -3, n: -4, want: 12},
+		{amount: math.MaxInt64, n: 1, want: math.MaxInt64},
+		{amount: math.MaxInt64/2 + 1, n: 2, overflow: true},
+		{amount: math.MaxInt64, n: -2, overflow: true},
+		{amount: math.MinInt64, n: -1, overflow: true},
+	}
+	for _, tt := range tests {
+		got, err := New(tt.amount, "USD").Mul(tt.n)
+		if tt.overflow {
+			if !errors.Is(err, ErrOverflow) {
+				t.Errorf("%d * %d error = %v, want %v", tt.amount, tt.n, err, ErrOverflow)
+			}
+			continue
+		}
+		if err != nil || got != New(tt.want, "USD") {
+			t.Errorf("%d * %d = %+v, %v, want %d", tt.amount, tt.n, got, err, tt.want)
+		}
+	}
+}
+
+func TestAdd(t *testing.T) {
+	if _, err := New(1, "USD").Add(New(1, "EUR")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("adding EUR to USD error = %v, want %v", err, ErrCurrencyMismatch)
+	}
+	if _, err := New(math.MaxInt64, "USD").Add(New(1, "USD")); !errors.Is(err, ErrOverflow) {
+		t.Errorf("adding past MaxInt64 error = %v, want %v", err, ErrOverflow)
+	}
+	if got, err := (Money{}).Add(New(250, "EUR")); err != nil || got != New(250, "EUR") {
+		t.Errorf("adding to zero Money = %+v, %v, want 2.50 EUR", got, err)
+	}
+}
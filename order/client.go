@@ -3,8 +3,8 @@ package order
 import (
 	"context"
 	"log"
-	"time"
 
+	"github.com/master-wayne7/go-microservices/auth"
 	"github.com/master-wayne7/go-microservices/order/pb"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
@@ -16,7 +16,13 @@ type Client struct {
 }
 
 func NewClient(url string) (*Client, error) {
-	conn, err := grpc.Dial(url, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	// Calls carry the caller's identity from the context to the service.
+	conn, err := grpc.Dial(
+		url,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(auth.UnaryClientInterceptor()),
+		grpc.WithStreamInterceptor(auth.StreamClientInterceptor()),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -29,43 +35,38 @@ func (c *Client) Close() {
 	c.conn.Close()
 }
 
-func (c *Client) PostOrder(ctx context.Context, accountId string, products []OrderedProduct) (*Order, error) {
+// PostOrder places an order for the SKU and quantity of each of products.
+// A non-empty idempotencyKey makes retries return the order placed by the
+// first attempt.
+func (c *Client) PostOrder(ctx context.Context, accountId string, products []OrderedProduct, idempotencyKey string) (*Order, error) {
 	protoProducts := []*pb.PostOrderRequest_OrderProduct{}
 	for _, p := range products {
 		protoProducts = append(protoProducts, &pb.PostOrderRequest_OrderProduct{
-			ProductId: p.ID,
-			Quantity:  p.Quantity,
+			Sku:      p.SKU,
+			Quantity: p.Quantity,
 		})
 	}
 	resp, err := c.service.PostOrder(ctx, &pb.PostOrderRequest{
-		AccountId: accountId,
-		Products:  protoProducts,
+		AccountId:      accountId,
+		Products:       protoProducts,
+		IdempotencyKey: idempotencyKey,
 	})
 	if err != nil {
 		log.Println(err)
 		return nil, err
 	}
-	newOrder := resp.Order
-	newOrderCreateAt := time.Time{}
-	newOrderCreateAt.UnmarshalBinary(newOrder.CreatedAt)
-	returnedProducts := make([]OrderedProduct, 0, len(newOrder.Products))
-	for _, p := range newOrder.Products {
-		returnedProducts = append(returnedProducts, OrderedProduct{
-			ID:          p.Id,
-			Name:        p.Name,
-			Description: p.Description,
-			Price:       p.Price,
-			Quantity:    p.Quantity,
-		})
-	}
-	return &Order{
-		ID:         newOrder.Id,
-		CreatedAt:  newOrderCreateAt,
-		TotalPrice: newOrder.TotalPrice,
-		AccountID:  newOrder.AccountId,
-		Products:   returnedProducts,
-	}, nil
+	return orderFromProto(resp.Order), nil
+}
 
+func (c *Client) GetOrder(ctx context.Context, id string) (*Order, error) {
+	r, err := c.service.GetOrder(ctx, &pb.GetOrderRequest{
+		Id: id,
+	})
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return orderFromProto(r.Order), nil
 }
 
 func (c *Client) GetOrdersForAccount(ctx context.Context, accountId string) ([]Order, error) {
@@ -78,25 +79,193 @@ func (c *Client) GetOrdersForAccount(ctx context.Context, accountId string) ([]O
 	}
 	orders := []Order{}
 	for _, orderProto := range r.Order {
-		newOrder := Order{
-			ID:         orderProto.Id,
-			TotalPrice: orderProto.TotalPrice,
-			AccountID:  orderProto.AccountId,
+		orders = append(orders, *orderFromProto(orderProto))
+	}
+	return orders, nil
+}
+
+// ListOrders returns one page of orders. Pass the previous page's
+// NextCursor as after to continue.
+func (c *Client) ListOrders(ctx context.Context, filter OrderFilter, sort OrderSort, after string, limit int) (*OrderPage, error) {
+	req := &pb.ListOrdersRequest{
+		AccountId: filter.AccountID,
+		ProductId: filter.ProductID,
+		Sort:      string(sort),
+		After:     after,
+		Limit:     uint32(limit),
+	}
+	var err error
+	if !filter.CreatedAfter.IsZero() {
+		if req.CreatedAfter, err = filter.CreatedAfter.MarshalBinary(); err != nil {
+			return nil, err
 		}
-		newOrder.CreatedAt = time.Time{}
-		newOrder.CreatedAt.UnmarshalBinary(orderProto.CreatedAt)
-		prodcucts := []OrderedProduct{}
-		for _, p := range orderProto.Products {
-			prodcucts = append(prodcucts, OrderedProduct{
-				ID:          p.Id,
-				Name:        p.Name,
-				Description: p.Description,
-				Price:       p.Price,
-				Quantity:    p.Quantity,
-			})
+	}
+	if !filter.CreatedBefore.IsZero() {
+		if req.CreatedBefore, err = filter.CreatedBefore.MarshalBinary(); err != nil {
+			return nil, err
 		}
-		newOrder.Products = prodcucts
-		orders = append(orders, newOrder)
 	}
-	return orders, nil
+	for _, st := range filter.Statuses {
+		req.Statuses = append(req.Statuses, string(st))
+	}
+	if filter.MinTotal != nil {
+		req.MinTotal = moneyToProto(*filter.MinTotal)
+	}
+	if filter.MaxTotal != nil {
+		req.MaxTotal = moneyToProto(*filter.MaxTotal)
+	}
+
+	r, err := c.service.ListOrders(ctx, req)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	page := &OrderPage{
+		Orders:     make([]Order, 0, len(r.Orders)),
+		NextCursor: r.NextCursor,
+	}
+	for _, o := range r.Orders {
+		page.Orders = append(page.Orders, *orderFromProto(o))
+	}
+	return page, nil
+}
+
+// TransitionOrder moves an order to status to. The order service records
+// the caller as the one who changed it.
+func (c *Client) TransitionOrder(ctx context.Context, id string, to Status, reason string) (*Order, error) {
+	r, err := c.service.TransitionOrder(ctx, &pb.TransitionOrderRequest{
+		Id:     id,
+		Status: string(to),
+		Reason: reason,
+	})
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return orderFromProto(r.Order), nil
+}
+
+func (c *Client) GetOrderStatusHistory(ctx context.Context, id string) ([]StatusChange, error) {
+	r, err := c.service.GetOrderStatusHistory(ctx, &pb.GetOrderStatusHistoryRequest{
+		Id: id,
+	})
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	history := make([]StatusChange, 0, len(r.History))
+	for _, c := range r.History {
+		change := StatusChange{
+			OrderID:   c.OrderId,
+			From:      Status(c.FromStatus),
+			To:        Status(c.ToStatus),
+			ChangedBy: c.ChangedBy,
+			Reason:    c.Reason,
+		}
+		change.ChangedAt.UnmarshalBinary(c.ChangedAt)
+		history = append(history, change)
+	}
+	return history, nil
+}
+
+// CancelOrder cancels an order and returns the refund created for it, which
+// is nil for orders that were never paid. No lines refunds the order in full.
+// The order service records the caller as the one who cancelled it.
+func (c *Client) CancelOrder(ctx context.Context, id, reason string, lines []RefundLine) (*Order, *Refund, error) {
+	protoLines := make([]*pb.CancelOrderRequest_RefundLine, 0, len(lines))
+	for _, l := range lines {
+		protoLines = append(protoLines, &pb.CancelOrderRequest_RefundLine{
+			ProductId: l.ProductID,
+			Sku:       l.SKU,
+			Quantity:  l.Quantity,
+		})
+	}
+	r, err := c.service.CancelOrder(ctx, &pb.CancelOrderRequest{
+		Id:          id,
+		Reason:      reason,
+		RefundLines: protoLines,
+	})
+	if err != nil {
+		log.Println(err)
+		return nil, nil, err
+	}
+	var refund *Refund
+	if r.Refund != nil {
+		refund = refundFromProto(r.Refund)
+	}
+	return orderFromProto(r.Order), refund, nil
+}
+
+func (c *Client) GetRefunds(ctx context.Context, orderID string) ([]Refund, error) {
+	r, err := c.service.GetRefunds(ctx, &pb.GetRefundsRequest{
+		OrderId: orderID,
+	})
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	refunds := make([]Refund, 0, len(r.Refunds))
+	for _, rp := range r.Refunds {
+		refunds = append(refunds, *refundFromProto(rp))
+	}
+	return refunds, nil
+}
+
+func (c *Client) UpdateRefundStatus(ctx context.Context, id string, to RefundStatus) (*Refund, error) {
+	r, err := c.service.UpdateRefundStatus(ctx, &pb.UpdateRefundStatusRequest{
+		Id:     id,
+		Status: string(to),
+	})
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return refundFromProto(r.Refund), nil
+}
+
+func orderFromProto(orderProto *pb.Order) *Order {
+	o := &Order{
+		ID:                 orderProto.Id,
+		TotalPrice:         moneyFromProto(orderProto.TotalPriceMoney, orderProto.TotalPrice),
+		AccountID:          orderProto.AccountId,
+		Status:             Status(orderProto.Status),
+		CancellationReason: orderProto.CancellationReason,
+	}
+	o.CreatedAt.UnmarshalBinary(orderProto.CreatedAt)
+	products := make([]OrderedProduct, 0, len(orderProto.Products))
+	for _, p := range orderProto.Products {
+		products = append(products, OrderedProduct{
+			ID:          p.Id,
+			SKU:         p.Sku,
+			Name:        p.Name,
+			Description: p.Description,
+			Price:       moneyFromProto(p.PriceMoney, p.Price),
+			Attributes:  p.Attributes,
+			Quantity:    p.Quantity,
+		})
+	}
+	o.Products = products
+	return o
+}
+
+func refundFromProto(rp *pb.Refund) *Refund {
+	r := &Refund{
+		ID:      rp.Id,
+		OrderID: rp.OrderId,
+		Amount:  moneyFromProto(rp.Amount, 0),
+		Status:  RefundStatus(rp.Status),
+		Reason:  rp.Reason,
+		Lines:   make([]RefundLine, 0, len(rp.Lines)),
+	}
+	r.CreatedAt.UnmarshalBinary(rp.CreatedAt)
+	r.UpdatedAt.UnmarshalBinary(rp.UpdatedAt)
+	for _, l := range rp.Lines {
+		r.Lines = append(r.Lines, RefundLine{
+			ProductID: l.ProductId,
+			SKU:       l.Sku,
+			Quantity:  l.Quantity,
+			Amount:    moneyFromProto(l.Amount, 0),
+		})
+	}
+	return r
 }
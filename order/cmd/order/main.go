@@ -1,20 +1,32 @@
 package main
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"time"
 
 	"github.com/kelseyhightower/envconfig"
+	"github.com/master-wayne7/go-microservices/auth"
+	"github.com/master-wayne7/go-microservices/events"
+	"github.com/master-wayne7/go-microservices/idempotency"
 	"github.com/master-wayne7/go-microservices/monitoring"
 	"github.com/master-wayne7/go-microservices/order"
+	"github.com/master-wayne7/go-microservices/schema"
 	"github.com/tinrab/retry"
 )
 
 type Config struct {
-	DatabaseURL string `envconfig:"DATABASE_URL"`
-	AccountURL  string `envconfig:"ACCOUNT_SERVICE_URL"`
-	CatalogURL  string `envconfig:"CATALOG_SERVICE_URL"`
+	DatabaseURL  string `envconfig:"DATABASE_URL"`
+	AccountURL   string `envconfig:"ACCOUNT_SERVICE_URL"`
+	CatalogURL   string `envconfig:"CATALOG_SERVICE_URL"`
+	InventoryURL string `envconfig:"INVENTORY_SERVICE_URL"`
+	// Where outbox events are published, e.g. file:///var/lib/events/order.ndjson
+	EventBrokerURL string `envconfig:"EVENT_BROKER_URL"`
+	// How often the outbox is relayed to the broker
+	EventRelayInterval time.Duration `envconfig:"EVENT_RELAY_INTERVAL" default:"5s"`
+	// Verifies bearer tokens of callers and signs the service's own calls
+	TokenSecret string `envconfig:"AUTH_TOKEN_SECRET" required:"true"`
 }
 
 // Health check handler for container orchestration
@@ -39,6 +51,9 @@ func main() {
 	if cfg.CatalogURL == "" {
 		log.Fatal("CATALOG_SERVICE_URL environment variable is required")
 	}
+	if cfg.InventoryURL == "" {
+		log.Fatal("INVENTORY_SERVICE_URL environment variable is required")
+	}
 
 	// Initialize Prometheus metrics
 	metrics := monitoring.NewMetricsCollector("order-service")
@@ -57,6 +72,9 @@ func main() {
 	var r order.Repository
 	retry.ForeverSleep(2*time.Second, func(_ int) (err error) {
 		r, err = order.NewPostgresRepository(cfg.DatabaseURL)
+		if errors.Is(err, schema.ErrOutdated) {
+			log.Fatal(err)
+		}
 		if err != nil {
 			log.Println(err)
 		}
@@ -72,8 +90,26 @@ func main() {
 	// Start system metrics collection
 	metrics.StartSystemMetricsCollection(r.(*order.PostgresRepository).DB())
 
+	// Relay outbox events to the configured broker
+	broker, err := events.NewBroker(cfg.EventBrokerURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer broker.Close()
+	source := events.NewPostgresSource(r.(*order.PostgresRepository).DB())
+	source.SetMetrics(metrics)
+	events.StartRelay(source, broker, cfg.EventRelayInterval)
+
+	signer, err := auth.NewSigner(cfg.TokenSecret)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// Changed port from 8080 to 8085 to avoid conflicts
 	log.Println("Listening on port 8085...")
 	s := order.NewService(r)
-	log.Fatal(order.ListenGRPC(s, cfg.AccountURL, cfg.CatalogURL, 8085, metrics))
+	// Idempotency keys for PostOrder share the order database
+	store := idempotency.NewPostgresStore(r.(*order.PostgresRepository).DB())
+	store.SetMetrics(metrics)
+	log.Fatal(order.ListenGRPC(s, store, signer, cfg.AccountURL, cfg.CatalogURL, cfg.InventoryURL, 8085, metrics))
 }
@@ -0,0 +1,144 @@
+package order
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/master-wayne7/go-microservices/money"
+)
+
+const (
+	DefaultListLimit = 20
+	MaxListLimit     = 100
+)
+
+var (
+	ErrInvalidSort   = errors.New("invalid order sort")
+	ErrInvalidCursor = errors.New("invalid order cursor")
+	ErrInvalidFilter = errors.New("invalid order filter")
+)
+
+type OrderSort string
+
+const (
+	SortCreatedAtDesc OrderSort = "created_at_desc"
+	SortCreatedAtAsc  OrderSort = "created_at_asc"
+	SortTotalDesc     OrderSort = "total_desc"
+	SortTotalAsc      OrderSort = "total_asc"
+)
+
+// ParseOrderSort reads a sort option; the empty string means newest first.
+func ParseOrderSort(s string) (OrderSort, error) {
+	switch sort := OrderSort(s); sort {
+	case "":
+		return SortCreatedAtDesc, nil
+	case SortCreatedAtDesc, SortCreatedAtAsc, SortTotalDesc, SortTotalAsc:
+		return sort, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidSort, s)
+}
+
+// OrderFilter narrows ListOrders. Zero values do not filter.
+type OrderFilter struct {
+	AccountID     string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Statuses      []Status
+	// MinTotal and MaxTotal also restrict results to their currency.
+	MinTotal  *money.Money
+	MaxTotal  *money.Money
+	ProductID string
+}
+
+// validate checks f for use with sort. Totals only compare within a
+// currency, so sorting by total needs MinTotal or MaxTotal to pick one.
+func (f OrderFilter) validate(sort OrderSort) error {
+	for _, st := range f.Statuses {
+		if _, err := ParseStatus(string(st)); err != nil {
+			return err
+		}
+	}
+	if f.MinTotal != nil && f.MaxTotal != nil && f.MinTotal.Currency != f.MaxTotal.Currency {
+		return fmt.Errorf("%w: min and max total use different currencies", ErrInvalidFilter)
+	}
+	if !f.CreatedAfter.IsZero() && !f.CreatedBefore.IsZero() && !f.CreatedAfter.Before(f.CreatedBefore) {
+		return fmt.Errorf("%w: createdAfter must be before createdBefore", ErrInvalidFilter)
+	}
+	if (sort == SortTotalAsc || sort == SortTotalDesc) && f.MinTotal == nil && f.MaxTotal == nil {
+		return fmt.Errorf("%w: sorting by total needs a minTotal or maxTotal to choose the currency", ErrInvalidFilter)
+	}
+	return nil
+}
+
+// hash identifies f in cursors. Equal filters hash alike, whatever the order
+// of their statuses or the time zones of their times.
+func (f OrderFilter) hash() string {
+	statuses := slices.Clone(f.Statuses)
+	slices.Sort(statuses)
+	data, _ := json.Marshal([]interface{}{
+		f.AccountID,
+		f.CreatedAfter.UTC(),
+		f.CreatedBefore.UTC(),
+		statuses,
+		f.MinTotal,
+		f.MaxTotal,
+		f.ProductID,
+	})
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
+
+// OrderPage is one page of ListOrders. NextCursor continues after the last
+// order and is empty on the last page.
+type OrderPage struct {
+	Orders     []Order
+	NextCursor string
+}
+
+// orderCursor marks the last order of a page by its sort key and ID. It is
+// handed out base64 encoded and only valid for the sort and filter it was
+// made with, which it carries as a hash.
+type orderCursor struct {
+	Sort   OrderSort `json:"s"`
+	Filter string    `json:"f"`
+	Key    string    `json:"k"`
+	ID     string    `json:"id"`
+}
+
+func newOrderCursor(sort OrderSort, filter OrderFilter, o Order) string {
+	c := orderCursor{Sort: sort, Filter: filter.hash(), ID: o.ID}
+	switch sort {
+	case SortTotalAsc, SortTotalDesc:
+		c.Key = o.TotalPrice.String()
+	default:
+		c.Key = o.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func parseOrderCursor(s string, sort OrderSort, filter OrderFilter) (*orderCursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	c := &orderCursor{}
+	if err := json.Unmarshal(data, c); err != nil || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	if c.Sort != sort {
+		return nil, fmt.Errorf("%w: cursor was issued for sort %s", ErrInvalidCursor, c.Sort)
+	}
+	if c.Filter != filter.hash() {
+		return nil, fmt.Errorf("%w: cursor was issued for a different filter", ErrInvalidCursor)
+	}
+	return c, nil
+}
@@ -0,0 +1,107 @@
+package order
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/master-wayne7/go-microservices/money"
+)
+
+func TestOrderCursor(t *testing.T) {
+	created := time.Date(2025, 3, 1, 12, 30, 0, 500, time.FixedZone("CET", 3600))
+	o := Order{ID: "2abc", CreatedAt: created, TotalPrice: money.New(1999, "USD")}
+	minTotal := money.New(1000, "USD")
+	tests := []struct {
+		sort    OrderSort
+		filter  OrderFilter
+		wantKey string
+	}{
+		{SortCreatedAtDesc, OrderFilter{}, "2025-03-01T11:30:00.0000005Z"},
+		{SortCreatedAtAsc, OrderFilter{AccountID: "account", Statuses: []Status{StatusPaid}}, "2025-03-01T11:30:00.0000005Z"},
+		{SortTotalDesc, OrderFilter{MinTotal: &minTotal}, "19.99"},
+		{SortTotalAsc, OrderFilter{MinTotal: &minTotal}, "19.99"},
+	}
+	for _, tt := range tests {
+		c, err := parseOrderCursor(newOrderCursor(tt.sort, tt.filter, o), tt.sort, tt.filter)
+		if err != nil {
+			t.Errorf("%s: parsing cursor: %v", tt.sort, err)
+			continue
+		}
+		if c.Key != tt.wantKey || c.ID != o.ID {
+			t.Errorf("%s: cursor key %q, id %q, want %q, %q", tt.sort, c.Key, c.ID, tt.wantKey, o.ID)
+		}
+	}
+}
+
+func TestParseOrderCursorRejects(t *testing.T) {
+	o := Order{ID: "2abc", CreatedAt: time.Now()}
+	filter := OrderFilter{Statuses: []Status{StatusPaid, StatusShipped}}
+	cursor := newOrderCursor(SortCreatedAtDesc, filter, o)
+
+	if c, err := parseOrderCursor("", SortCreatedAtDesc, filter); c != nil || err != nil {
+		t.Errorf("empty cursor = %+v, %v, want nil, nil", c, err)
+	}
+	// Statuses are a set, and times compare in any zone.
+	reordered := OrderFilter{Statuses: []Status{StatusShipped, StatusPaid}}
+	if _, err := parseOrderCursor(cursor, SortCreatedAtDesc, reordered); err != nil {
+		t.Errorf("cursor with reordered statuses: %v", err)
+	}
+	after := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	inUTC := newOrderCursor(SortCreatedAtDesc, OrderFilter{CreatedAfter: after}, o)
+	if _, err := parseOrderCursor(inUTC, SortCreatedAtDesc, OrderFilter{CreatedAfter: after.In(time.FixedZone("EST", -5*3600))}); err != nil {
+		t.Errorf("cursor with createdAfter in another zone: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		cursor string
+		sort   OrderSort
+		filter OrderFilter
+	}{
+		{"not base64", "!!!", SortCreatedAtDesc, filter},
+		{"not JSON", base64.RawURLEncoding.EncodeToString([]byte("nope")), SortCreatedAtDesc, filter},
+		{"no ID", base64.RawURLEncoding.EncodeToString([]byte(`{"s":"created_at_desc"}`)), SortCreatedAtDesc, filter},
+		{"other sort", cursor, SortCreatedAtAsc, filter},
+		{"other statuses", cursor, SortCreatedAtDesc, OrderFilter{Statuses: []Status{StatusPaid}}},
+		{"other account", cursor, SortCreatedAtDesc, OrderFilter{AccountID: "account", Statuses: filter.Statuses}},
+		{"no filter", cursor, SortCreatedAtDesc, OrderFilter{}},
+	}
+	for _, tt := range tests {
+		if _, err := parseOrderCursor(tt.cursor, tt.sort, tt.filter); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, ErrInvalidCursor)
+		}
+	}
+}
+
+func TestOrderFilterValidate(t *testing.T) {
+	usd, eur := money.New(100, "USD"), money.New(100, "EUR")
+	now := time.Now()
+	tests := []struct {
+		name   string
+		filter OrderFilter
+		sort   OrderSort
+		valid  bool
+	}{
+		{"empty", OrderFilter{}, SortCreatedAtDesc, true},
+		{"statuses", OrderFilter{Statuses: []Status{StatusPaid, StatusCancelled}}, SortCreatedAtDesc, true},
+		{"unknown status", OrderFilter{Statuses: []Status{"lost"}}, SortCreatedAtDesc, false},
+		{"total range", OrderFilter{MinTotal: &usd, MaxTotal: &usd}, SortCreatedAtDesc, true},
+		{"mixed currencies", OrderFilter{MinTotal: &usd, MaxTotal: &eur}, SortCreatedAtDesc, false},
+		{"times in order", OrderFilter{CreatedAfter: now.Add(-time.Hour), CreatedBefore: now}, SortCreatedAtAsc, true},
+		{"times reversed", OrderFilter{CreatedAfter: now, CreatedBefore: now.Add(-time.Hour)}, SortCreatedAtAsc, false},
+		{"total sort with currency", OrderFilter{MaxTotal: &eur}, SortTotalDesc, true},
+		{"total sort without currency", OrderFilter{}, SortTotalDesc, false},
+		{"ascending total sort without currency", OrderFilter{AccountID: "account"}, SortTotalAsc, false},
+	}
+	for _, tt := range tests {
+		err := tt.filter.validate(tt.sort)
+		if tt.valid && err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidFilter) && !errors.Is(err, ErrInvalidStatus) {
+			t.Errorf("%s: error = %v, want an invalid filter", tt.name, err)
+		}
+	}
+}
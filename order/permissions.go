@@ -0,0 +1,29 @@
+package order
+
+import (
+	"github.com/master-wayne7/go-microservices/auth"
+	"github.com/master-wayne7/go-microservices/order/pb"
+)
+
+// permissions decides who may call each OrderService method. Methods that
+// take an order ID are Authenticated here and check the order's owner in
+// the handler, once the order is loaded. Staff may act for any account.
+var permissions = auth.Rules{
+	pb.OrderService_PostOrder_FullMethodName: auth.AccountOwner(func(r *pb.PostOrderRequest) string {
+		return r.AccountId
+	}),
+	pb.OrderService_GetOrdersForAccount_FullMethodName: auth.AccountOwner(func(r *pb.GetOrdersForAccountRequest) string {
+		return r.AccountId
+	}),
+	pb.OrderService_ListOrders_FullMethodName: auth.AccountOwner(func(r *pb.ListOrdersRequest) string {
+		return r.AccountId
+	}),
+
+	pb.OrderService_GetOrder_FullMethodName:              auth.Authenticated,
+	pb.OrderService_TransitionOrder_FullMethodName:       auth.RequireRole(auth.RoleStaff),
+	pb.OrderService_GetOrderStatusHistory_FullMethodName: auth.Authenticated,
+	pb.OrderService_CancelOrder_FullMethodName:           auth.Authenticated,
+	pb.OrderService_GetRefunds_FullMethodName:            auth.Authenticated,
+
+	pb.OrderService_UpdateRefundStatus_FullMethodName: auth.RequireRole(auth.RoleStaff),
+}
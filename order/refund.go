@@ -0,0 +1,130 @@
+package order
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/master-wayne7/go-microservices/money"
+)
+
+type RefundStatus string
+
+const (
+	RefundPending   RefundStatus = "pending"
+	RefundCompleted RefundStatus = "completed"
+	RefundFailed    RefundStatus = "failed"
+)
+
+var (
+	ErrReasonRequired          = errors.New("a cancellation reason is required")
+	ErrRefundNotFound          = errors.New("refund not found")
+	ErrInvalidRefund           = errors.New("invalid refund")
+	ErrInvalidRefundStatus     = errors.New("invalid refund status")
+	ErrInvalidRefundTransition = errors.New("invalid refund status transition")
+)
+
+// Refund is money owed back to the customer for a cancelled order. Lines
+// cover all or part of what was ordered.
+type Refund struct {
+	ID        string       `json:"id"`
+	OrderID   string       `json:"order_id"`
+	Amount    money.Money  `json:"amount"`
+	Status    RefundStatus `json:"status"`
+	Reason    string       `json:"reason"`
+	Lines     []RefundLine `json:"lines"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+type RefundLine struct {
+	ProductID string `json:"product_id"`
+	// SKU picks the line of the order. Without it, ProductID has to name a
+	// product the order has a single line for.
+	SKU      string      `json:"sku"`
+	Quantity uint32      `json:"quantity"`
+	Amount   money.Money `json:"amount"`
+}
+
+func ParseRefundStatus(s string) (RefundStatus, error) {
+	switch st := RefundStatus(s); st {
+	case RefundPending, RefundCompleted, RefundFailed:
+		return st, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidRefundStatus, s)
+}
+
+// CanTransitionTo reports whether a refund may move from s to to. A failed
+// refund can be retried and complete later; a completed one is final.
+func (s RefundStatus) CanTransitionTo(to RefundStatus) bool {
+	switch s {
+	case RefundPending:
+		return to == RefundCompleted || to == RefundFailed
+	case RefundFailed:
+		return to == RefundCompleted
+	}
+	return false
+}
+
+// refundLines prices the requested lines from the order's snapshot. No lines
+// means a full refund of every ordered line.
+func refundLines(o *Order, requested []RefundLine) ([]RefundLine, money.Money, error) {
+	if len(requested) == 0 {
+		for _, p := range o.Products {
+			requested = append(requested, RefundLine{ProductID: p.ID, SKU: p.SKU, Quantity: p.Quantity})
+		}
+	}
+
+	// Lines for the same SKU are merged.
+	index := map[string]int{}
+	lines := make([]RefundLine, 0, len(requested))
+	for _, l := range requested {
+		p, err := orderedLine(o, l)
+		if err != nil {
+			return nil, money.Money{}, err
+		}
+		i, seen := index[p.SKU]
+		if !seen {
+			i = len(lines)
+			index[p.SKU] = i
+			lines = append(lines, RefundLine{ProductID: p.ID, SKU: p.SKU})
+		}
+		lines[i].Quantity += l.Quantity
+		if l.Quantity == 0 || lines[i].Quantity > p.Quantity {
+			return nil, money.Money{}, fmt.Errorf("%w: quantity for SKU %s must be between 1 and %d", ErrInvalidRefund, p.SKU, p.Quantity)
+		}
+		amount, err := p.Price.Mul(int64(lines[i].Quantity))
+		if err != nil {
+			return nil, money.Money{}, err
+		}
+		lines[i].Amount = amount
+	}
+
+	total := money.New(0, o.TotalPrice.Currency)
+	for _, l := range lines {
+		var err error
+		if total, err = total.Add(l.Amount); err != nil {
+			return nil, money.Money{}, err
+		}
+	}
+	return lines, total, nil
+}
+
+// orderedLine finds the line of o that l refunds, by SKU or else by product.
+func orderedLine(o *Order, l RefundLine) (OrderedProduct, error) {
+	var found []OrderedProduct
+	for _, p := range o.Products {
+		if (l.SKU != "" && p.SKU == l.SKU) || (l.SKU == "" && p.ID == l.ProductID) {
+			found = append(found, p)
+		}
+	}
+	switch {
+	case len(found) == 0 && l.SKU != "":
+		return OrderedProduct{}, fmt.Errorf("%w: SKU %s is not part of order %s", ErrInvalidRefund, l.SKU, o.ID)
+	case len(found) == 0:
+		return OrderedProduct{}, fmt.Errorf("%w: product %s is not part of order %s", ErrInvalidRefund, l.ProductID, o.ID)
+	case len(found) > 1:
+		return OrderedProduct{}, fmt.Errorf("%w: order %s has several variants of product %s, refund them by SKU", ErrInvalidRefund, o.ID, l.ProductID)
+	}
+	return found[0], nil
+}
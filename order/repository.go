@@ -3,16 +3,39 @@ package order
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/lib/pq"
+	"github.com/master-wayne7/go-microservices/events"
+	"github.com/master-wayne7/go-microservices/money"
 	"github.com/master-wayne7/go-microservices/monitoring"
+	"github.com/master-wayne7/go-microservices/schema"
+)
+
+var (
+	ErrNotFound = errors.New("order not found")
 )
 
 type Repository interface {
 	Close()
 	PutOrder(ctx context.Context, o Order) error
+	GetOrderByID(ctx context.Context, id string) (*Order, error)
 	GetOrdersForAccount(ctx context.Context, accountID string) ([]Order, error)
+	// ListOrders returns up to limit orders after the order identified by
+	// afterKey (its sort key) and afterID, or from the start if afterID is empty.
+	ListOrders(ctx context.Context, filter OrderFilter, sort OrderSort, afterKey, afterID string, limit int) ([]Order, error)
+	UpdateOrderStatus(ctx context.Context, change StatusChange) error
+	GetOrderStatusHistory(ctx context.Context, orderID string) ([]StatusChange, error)
+	CancelOrder(ctx context.Context, change StatusChange, refund *Refund) error
+	GetRefundsForOrder(ctx context.Context, orderID string) ([]Refund, error)
+	GetRefundByID(ctx context.Context, id string) (*Refund, error)
+	UpdateRefundStatus(ctx context.Context, id string, from, to RefundStatus, at time.Time) error
+	PutSaga(ctx context.Context, saga OrderSaga) error
+	ListUnfinishedSagas(ctx context.Context, updatedBefore time.Time) ([]OrderSaga, error)
 }
 
 type PostgresRepository struct {
@@ -21,6 +44,27 @@ type PostgresRepository struct {
 	metrics *monitoring.MetricsCollector
 }
 
+// columns are the columns added since the first release, whose absence
+// means the database was created from an older up.sql.
+var columns = []schema.Column{
+	{Table: "orders", Name: "total_price", Type: "numeric"},
+	{Table: "orders", Name: "currency"},
+	{Table: "orders", Name: "status"},
+	{Table: "orders", Name: "reservation_id"},
+	{Table: "orders", Name: "cancellation_reason"},
+	{Table: "order_products", Name: "sku"},
+	{Table: "order_products", Name: "name"},
+	{Table: "order_products", Name: "description"},
+	{Table: "order_products", Name: "price", Type: "numeric"},
+	{Table: "order_products", Name: "attributes", Type: "jsonb"},
+	{Table: "order_status_history", Name: "changed_by"},
+	{Table: "refunds", Name: "currency"},
+	{Table: "refund_lines", Name: "sku"},
+	{Table: "order_sagas", Name: "data", Type: "jsonb"},
+	{Table: "idempotency_keys", Name: "request_hash"},
+	{Table: "outbox", Name: "published_at"},
+}
+
 func NewPostgresRepository(url string) (Repository, error) {
 	db, err := sql.Open("postgres", url)
 	if err != nil {
@@ -30,6 +74,10 @@ func NewPostgresRepository(url string) (Repository, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := schema.Check(context.Background(), db, columns); err != nil {
+		db.Close()
+		return nil, err
+	}
 	return &PostgresRepository{
 		db: db,
 	}, nil
@@ -45,6 +93,10 @@ func (r *PostgresRepository) DB() *sql.DB {
 }
 
 func (r *PostgresRepository) PutOrder(ctx context.Context, o Order) (err error) {
+	event, err := events.NewEvent(events.OrderPlaced, "order", o.ID, o)
+	if err != nil {
+		return err
+	}
 	startTx := time.Now()
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -61,11 +113,14 @@ func (r *PostgresRepository) PutOrder(ctx context.Context, o Order) (err error)
 	startInsert := time.Now()
 	_, err = tx.ExecContext(
 		ctx,
-		"INSERT INTO orders(id, created_at, account_id, total_price) VALUES($1, $2, $3, $4)",
+		"INSERT INTO orders(id, created_at, account_id, total_price, currency, status, reservation_id) VALUES($1, $2, $3, $4, $5, $6, $7)",
 		o.ID,
 		o.CreatedAt,
 		o.AccountID,
-		o.TotalPrice,
+		o.TotalPrice.String(),
+		o.TotalPrice.Currency,
+		o.Status,
+		o.ReservationID,
 	)
 	if err != nil {
 		return err
@@ -74,14 +129,40 @@ func (r *PostgresRepository) PutOrder(ctx context.Context, o Order) (err error)
 		r.metrics.RecordDBQuery("insert", "orders", time.Since(startInsert))
 	}
 
-	stmt, _ := tx.PrepareContext(ctx, pq.CopyIn("order_products", "order_id", "product_id", "quantity"))
+	startHistory := time.Now()
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO order_status_history(order_id, to_status, changed_at) VALUES($1, $2, $3)",
+		o.ID,
+		o.Status,
+		o.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("insert", "order_status_history", time.Since(startHistory))
+	}
+
+	stmt, _ := tx.PrepareContext(ctx, pq.CopyIn("order_products", "order_id", "product_id", "sku", "name", "description", "price", "attributes", "quantity"))
 
 	for _, p := range o.Products {
 		startCopy := time.Now()
+		attributes := []byte("{}")
+		if len(p.Attributes) > 0 {
+			if attributes, err = json.Marshal(p.Attributes); err != nil {
+				return err
+			}
+		}
 		_, err = stmt.ExecContext(
 			ctx,
 			o.ID,
 			p.ID,
+			p.SKU,
+			p.Name,
+			p.Description,
+			p.Price.String(),
+			string(attributes),
 			p.Quantity,
 		)
 		if err != nil {
@@ -102,23 +183,120 @@ func (r *PostgresRepository) PutOrder(ctx context.Context, o Order) (err error)
 	}
 
 	stmt.Close()
+	if err = events.WriteOutbox(ctx, tx, event); err != nil {
+		return err
+	}
 	if r.metrics != nil {
 		r.metrics.RecordDBQuery("tx", "orders", time.Since(startTx))
 	}
 	return
 }
 
-func (r *PostgresRepository) GetOrdersForAccount(ctx context.Context, accountId string) ([]Order, error) {
-	start := time.Now()
-	rows, err := r.db.QueryContext(
-		ctx,
-		`SELECT
+// orderColumns selects one row per order line; scanOrderRow reads it back.
+const orderColumns = `
 		o.id,
 		o.created_at,
 		o.account_id,
-		o.total_price::money::numeric::float8,
+		o.total_price::text,
+		o.currency,
+		o.status,
+		o.reservation_id,
+		o.cancellation_reason,
 		op.product_id,
-		op.quantity
+		op.sku,
+		op.name,
+		op.description,
+		op.price::text,
+		op.attributes,
+		op.quantity`
+
+func scanOrderRow(rows *sql.Rows) (Order, OrderedProduct, error) {
+	o := Order{}
+	p := OrderedProduct{}
+	var totalPrice, price, currency string
+	var attributes []byte
+	if err := rows.Scan(
+		&o.ID,
+		&o.CreatedAt,
+		&o.AccountID,
+		&totalPrice,
+		&currency,
+		&o.Status,
+		&o.ReservationID,
+		&o.CancellationReason,
+		&p.ID,
+		&p.SKU,
+		&p.Name,
+		&p.Description,
+		&price,
+		&attributes,
+		&p.Quantity,
+	); err != nil {
+		return o, p, err
+	}
+	if err := json.Unmarshal(attributes, &p.Attributes); err != nil {
+		return o, p, err
+	}
+	if len(p.Attributes) == 0 {
+		p.Attributes = nil
+	}
+	var err error
+	if o.TotalPrice, err = money.Parse(totalPrice, currency); err != nil {
+		return o, p, err
+	}
+	if p.Price, err = money.Parse(price, currency); err != nil {
+		return o, p, err
+	}
+	return o, p, nil
+}
+
+func (r *PostgresRepository) GetOrderByID(ctx context.Context, id string) (*Order, error) {
+	start := time.Now()
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT `+orderColumns+`
+		FROM orders o JOIN order_products op ON(o.id = op.order_id)
+		WHERE o.id = $1
+		`,
+		id,
+	)
+	if err != nil {
+		if r.metrics != nil {
+			r.metrics.RecordDBQuery("select", "orders_join_order_products", time.Since(start))
+		}
+		return nil, err
+	}
+	defer rows.Close()
+
+	var order *Order
+	for rows.Next() {
+		o, p, err := scanOrderRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		if order == nil {
+			order = &o
+		}
+		order.Products = append(order.Products, p)
+	}
+
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("select", "orders_join_order_products", time.Since(start))
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, ErrNotFound
+	}
+	return order, nil
+}
+
+func (r *PostgresRepository) GetOrdersForAccount(ctx context.Context, accountId string) ([]Order, error) {
+	start := time.Now()
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT `+orderColumns+`
 		FROM orders o JOIN order_products op ON(o.id = op.order_id)
 		WHERE o.account_id = $1
 		ORDER BY o.id
@@ -134,48 +312,43 @@ func (r *PostgresRepository) GetOrdersForAccount(ctx context.Context, accountId
 	defer rows.Close()
 	orders := []Order{}
 	lastOrder := &Order{}
-	orderedProduct := &OrderedProduct{}
 	products := []OrderedProduct{}
-	order := &Order{}
 
 	for rows.Next() {
-		if err = rows.Scan(
-			&order.ID,
-			&order.CreatedAt,
-			&order.AccountID,
-			&order.TotalPrice,
-			&orderedProduct.ID,
-			&orderedProduct.Quantity,
-		); err != nil {
+		order, orderedProduct, err := scanOrderRow(rows)
+		if err != nil {
 			return nil, err
 		}
 		if lastOrder.ID != "" && lastOrder.ID != order.ID {
 			lastOrder.Products = products
 			newOrder := Order{
-				ID:         lastOrder.ID,
-				AccountID:  lastOrder.AccountID,
-				CreatedAt:  lastOrder.CreatedAt,
-				TotalPrice: lastOrder.TotalPrice,
-				Products:   lastOrder.Products,
+				ID:                 lastOrder.ID,
+				AccountID:          lastOrder.AccountID,
+				CreatedAt:          lastOrder.CreatedAt,
+				TotalPrice:         lastOrder.TotalPrice,
+				Status:             lastOrder.Status,
+				ReservationID:      lastOrder.ReservationID,
+				Products:           lastOrder.Products,
+				CancellationReason: lastOrder.CancellationReason,
 			}
 			orders = append(orders, newOrder)
 			products = []OrderedProduct{}
 		}
-		products = append(products, OrderedProduct{
-			ID:       orderedProduct.ID,
-			Quantity: orderedProduct.Quantity,
-		})
-		*lastOrder = *order
+		products = append(products, orderedProduct)
+		*lastOrder = order
 	}
 
 	if lastOrder.ID != "" {
 		lastOrder.Products = products
 		newOrder := Order{
-			ID:         lastOrder.ID,
-			AccountID:  lastOrder.AccountID,
-			CreatedAt:  lastOrder.CreatedAt,
-			TotalPrice: lastOrder.TotalPrice,
-			Products:   lastOrder.Products,
+			ID:                 lastOrder.ID,
+			AccountID:          lastOrder.AccountID,
+			CreatedAt:          lastOrder.CreatedAt,
+			TotalPrice:         lastOrder.TotalPrice,
+			Status:             lastOrder.Status,
+			ReservationID:      lastOrder.ReservationID,
+			Products:           lastOrder.Products,
+			CancellationReason: lastOrder.CancellationReason,
 		}
 		orders = append(orders, newOrder)
 	}
@@ -192,6 +365,562 @@ func (r *PostgresRepository) GetOrdersForAccount(ctx context.Context, accountId
 	return orders, nil
 }
 
+func (r *PostgresRepository) ListOrders(ctx context.Context, filter OrderFilter, sort OrderSort, afterKey, afterID string, limit int) ([]Order, error) {
+	start := time.Now()
+	args := []interface{}{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conditions := []string{}
+	if filter.AccountID != "" {
+		conditions = append(conditions, "o.account_id = "+arg(filter.AccountID))
+	}
+	if !filter.CreatedAfter.IsZero() {
+		conditions = append(conditions, "o.created_at >= "+arg(filter.CreatedAfter))
+	}
+	if !filter.CreatedBefore.IsZero() {
+		conditions = append(conditions, "o.created_at < "+arg(filter.CreatedBefore))
+	}
+	if len(filter.Statuses) != 0 {
+		statuses := make([]string, 0, len(filter.Statuses))
+		for _, st := range filter.Statuses {
+			statuses = append(statuses, string(st))
+		}
+		conditions = append(conditions, "o.status = ANY("+arg(pq.Array(statuses))+")")
+	}
+	if filter.MinTotal != nil {
+		conditions = append(conditions, "o.currency = "+arg(filter.MinTotal.Currency))
+		conditions = append(conditions, "o.total_price >= "+arg(filter.MinTotal.String())+"::numeric")
+	}
+	if filter.MaxTotal != nil {
+		conditions = append(conditions, "o.currency = "+arg(filter.MaxTotal.Currency))
+		conditions = append(conditions, "o.total_price <= "+arg(filter.MaxTotal.String())+"::numeric")
+	}
+	if filter.ProductID != "" {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM order_products p WHERE p.order_id = o.id AND p.product_id = "+arg(filter.ProductID)+")")
+	}
+
+	// Keyset pagination: continue strictly after the cursor's (key, id).
+	column, keyType, direction, comparison := "o.created_at", "timestamptz", "DESC", "<"
+	switch sort {
+	case SortCreatedAtAsc:
+		direction, comparison = "ASC", ">"
+	case SortTotalDesc:
+		column, keyType = "o.total_price", "numeric"
+	case SortTotalAsc:
+		column, keyType, direction, comparison = "o.total_price", "numeric", "ASC", ">"
+	}
+	if afterID != "" {
+		conditions = append(conditions, fmt.Sprintf("(%s, o.id) %s (%s::%s, %s)", column, comparison, arg(afterKey), keyType, arg(afterID)))
+	}
+
+	where := ""
+	if len(conditions) != 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	orderBy := fmt.Sprintf("%s %s, o.id %s", column, direction, direction)
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT `+orderColumns+`
+		FROM (
+			SELECT o.id FROM orders o `+where+`
+			ORDER BY `+orderBy+`
+			LIMIT `+arg(limit)+`
+		) page
+		JOIN orders o ON(o.id = page.id)
+		JOIN order_products op ON(o.id = op.order_id)
+		ORDER BY `+orderBy+`, op.product_id, op.sku`,
+		args...,
+	)
+	if err != nil {
+		if r.metrics != nil {
+			r.metrics.RecordDBQuery("select", "orders_join_order_products", time.Since(start))
+		}
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []Order{}
+	for rows.Next() {
+		o, p, err := scanOrderRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		if len(orders) == 0 || orders[len(orders)-1].ID != o.ID {
+			orders = append(orders, o)
+		}
+		last := &orders[len(orders)-1]
+		last.Products = append(last.Products, p)
+	}
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("select", "orders_join_order_products", time.Since(start))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (r *PostgresRepository) UpdateOrderStatus(ctx context.Context, change StatusChange) (err error) {
+	event, err := events.NewEvent(events.OrderStatusChanged, "order", change.OrderID, change)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	// Only move the order if nobody else changed its status in the meantime.
+	res, err := tx.ExecContext(
+		ctx,
+		"UPDATE orders SET status = $1 WHERE id = $2 AND status = $3",
+		change.To,
+		change.OrderID,
+		change.From,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrStatusConflict
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO order_status_history(order_id, from_status, to_status, changed_by, reason, changed_at)
+		VALUES($1, $2, $3, $4, $5, $6)`,
+		change.OrderID,
+		change.From,
+		change.To,
+		change.ChangedBy,
+		change.Reason,
+		change.ChangedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if err = events.WriteOutbox(ctx, tx, event); err != nil {
+		return err
+	}
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("tx", "order_status_history", time.Since(start))
+	}
+	return
+}
+
+func (r *PostgresRepository) GetOrderStatusHistory(ctx context.Context, orderID string) ([]StatusChange, error) {
+	start := time.Now()
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT order_id, COALESCE(from_status, ''), to_status, changed_by, reason, changed_at
+		FROM order_status_history
+		WHERE order_id = $1
+		ORDER BY changed_at, id`,
+		orderID,
+	)
+	if err != nil {
+		if r.metrics != nil {
+			r.metrics.RecordDBQuery("select", "order_status_history", time.Since(start))
+		}
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []StatusChange{}
+	for rows.Next() {
+		c := StatusChange{}
+		if err := rows.Scan(&c.OrderID, &c.From, &c.To, &c.ChangedBy, &c.Reason, &c.ChangedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, c)
+	}
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("select", "order_status_history", time.Since(start))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// CancelOrder moves an order to cancelled and stores the refund, if any, in
+// one transaction.
+func (r *PostgresRepository) CancelOrder(ctx context.Context, change StatusChange, refund *Refund) (err error) {
+	orderEvents := []events.Event{}
+	event, err := events.NewEvent(events.OrderStatusChanged, "order", change.OrderID, change)
+	if err != nil {
+		return err
+	}
+	orderEvents = append(orderEvents, event)
+	if refund != nil {
+		event, err := events.NewEvent(events.RefundCreated, "refund", refund.ID, refund)
+		if err != nil {
+			return err
+		}
+		orderEvents = append(orderEvents, event)
+	}
+
+	start := time.Now()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	res, err := tx.ExecContext(
+		ctx,
+		"UPDATE orders SET status = $1, cancellation_reason = $2 WHERE id = $3 AND status = $4",
+		change.To,
+		change.Reason,
+		change.OrderID,
+		change.From,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrStatusConflict
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO order_status_history(order_id, from_status, to_status, changed_by, reason, changed_at)
+		VALUES($1, $2, $3, $4, $5, $6)`,
+		change.OrderID,
+		change.From,
+		change.To,
+		change.ChangedBy,
+		change.Reason,
+		change.ChangedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if refund != nil {
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO refunds(id, order_id, amount, currency, status, reason, created_at, updated_at)
+			VALUES($1, $2, $3, $4, $5, $6, $7, $8)`,
+			refund.ID,
+			refund.OrderID,
+			refund.Amount.String(),
+			refund.Amount.Currency,
+			refund.Status,
+			refund.Reason,
+			refund.CreatedAt,
+			refund.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+		for _, l := range refund.Lines {
+			_, err = tx.ExecContext(
+				ctx,
+				"INSERT INTO refund_lines(refund_id, product_id, sku, quantity, amount) VALUES($1, $2, $3, $4, $5)",
+				refund.ID,
+				l.ProductID,
+				l.SKU,
+				l.Quantity,
+				l.Amount.String(),
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if err = events.WriteOutbox(ctx, tx, orderEvents...); err != nil {
+		return err
+	}
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("tx", "refunds", time.Since(start))
+	}
+	return
+}
+
+// refundColumns selects one row per refund line; scanRefunds reads them back.
+const refundColumns = `
+		r.id,
+		r.order_id,
+		r.amount::text,
+		r.currency,
+		r.status,
+		r.reason,
+		r.created_at,
+		r.updated_at,
+		rl.product_id,
+		rl.sku,
+		rl.quantity,
+		rl.amount::text`
+
+func scanRefunds(rows *sql.Rows) ([]Refund, error) {
+	refunds := []Refund{}
+	for rows.Next() {
+		rf := Refund{}
+		l := RefundLine{}
+		var amount, currency, lineAmount string
+		if err := rows.Scan(
+			&rf.ID,
+			&rf.OrderID,
+			&amount,
+			&currency,
+			&rf.Status,
+			&rf.Reason,
+			&rf.CreatedAt,
+			&rf.UpdatedAt,
+			&l.ProductID,
+			&l.SKU,
+			&l.Quantity,
+			&lineAmount,
+		); err != nil {
+			return nil, err
+		}
+		var err error
+		if rf.Amount, err = money.Parse(amount, currency); err != nil {
+			return nil, err
+		}
+		if l.Amount, err = money.Parse(lineAmount, currency); err != nil {
+			return nil, err
+		}
+		if len(refunds) == 0 || refunds[len(refunds)-1].ID != rf.ID {
+			refunds = append(refunds, rf)
+		}
+		last := &refunds[len(refunds)-1]
+		last.Lines = append(last.Lines, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return refunds, nil
+}
+
+func (r *PostgresRepository) GetRefundsForOrder(ctx context.Context, orderID string) ([]Refund, error) {
+	start := time.Now()
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT `+refundColumns+`
+		FROM refunds r JOIN refund_lines rl ON(r.id = rl.refund_id)
+		WHERE r.order_id = $1
+		ORDER BY r.created_at, r.id, rl.product_id, rl.sku`,
+		orderID,
+	)
+	if err != nil {
+		if r.metrics != nil {
+			r.metrics.RecordDBQuery("select", "refunds_join_refund_lines", time.Since(start))
+		}
+		return nil, err
+	}
+	defer rows.Close()
+
+	refunds, err := scanRefunds(rows)
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("select", "refunds_join_refund_lines", time.Since(start))
+	}
+	return refunds, err
+}
+
+func (r *PostgresRepository) GetRefundByID(ctx context.Context, id string) (*Refund, error) {
+	start := time.Now()
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT `+refundColumns+`
+		FROM refunds r JOIN refund_lines rl ON(r.id = rl.refund_id)
+		WHERE r.id = $1
+		ORDER BY rl.product_id, rl.sku`,
+		id,
+	)
+	if err != nil {
+		if r.metrics != nil {
+			r.metrics.RecordDBQuery("select", "refunds_join_refund_lines", time.Since(start))
+		}
+		return nil, err
+	}
+	defer rows.Close()
+
+	refunds, err := scanRefunds(rows)
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("select", "refunds_join_refund_lines", time.Since(start))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(refunds) == 0 {
+		return nil, ErrRefundNotFound
+	}
+	return &refunds[0], nil
+}
+
+func (r *PostgresRepository) UpdateRefundStatus(ctx context.Context, id string, from, to RefundStatus, at time.Time) (err error) {
+	event, err := events.NewEvent(events.RefundStatusChanged, "refund", id, map[string]interface{}{
+		"refund_id":  id,
+		"from":       from,
+		"to":         to,
+		"changed_at": at,
+	})
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	res, err := tx.ExecContext(
+		ctx,
+		"UPDATE refunds SET status = $1, updated_at = $2 WHERE id = $3 AND status = $4",
+		to,
+		at,
+		id,
+		from,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrStatusConflict
+	}
+	if err = events.WriteOutbox(ctx, tx, event); err != nil {
+		return err
+	}
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("tx", "refunds", time.Since(start))
+	}
+	return
+}
+
+// sagaData is the part of a saga stored as JSON.
+type sagaData struct {
+	Items    []SagaItem       `json:"items"`
+	Products []OrderedProduct `json:"products"`
+}
+
+// PutSaga inserts saga or overwrites its stored state.
+func (r *PostgresRepository) PutSaga(ctx context.Context, saga OrderSaga) error {
+	start := time.Now()
+	data, err := json.Marshal(sagaData{Items: saga.Items, Products: saga.Products})
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(
+		ctx,
+		`INSERT INTO order_sagas(id, account_id, step, status, reservation_id, data, error, created_at, updated_at)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (id) DO UPDATE SET
+			step = EXCLUDED.step,
+			status = EXCLUDED.status,
+			reservation_id = EXCLUDED.reservation_id,
+			data = EXCLUDED.data,
+			error = EXCLUDED.error,
+			updated_at = EXCLUDED.updated_at`,
+		saga.ID,
+		saga.AccountID,
+		saga.Step,
+		saga.Status,
+		saga.ReservationID,
+		data,
+		saga.Error,
+		saga.CreatedAt,
+		saga.UpdatedAt,
+	)
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("upsert", "order_sagas", time.Since(start))
+	}
+	return err
+}
+
+func (r *PostgresRepository) ListUnfinishedSagas(ctx context.Context, updatedBefore time.Time) ([]OrderSaga, error) {
+	start := time.Now()
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT id, account_id, step, status, reservation_id, data, error, created_at, updated_at
+		FROM order_sagas
+		WHERE status IN ($1, $2) AND updated_at < $3
+		ORDER BY updated_at
+		LIMIT 100`,
+		SagaRunning,
+		SagaCompensating,
+		updatedBefore,
+	)
+	if err != nil {
+		if r.metrics != nil {
+			r.metrics.RecordDBQuery("select", "order_sagas", time.Since(start))
+		}
+		return nil, err
+	}
+	defer rows.Close()
+
+	sagas := []OrderSaga{}
+	for rows.Next() {
+		saga := OrderSaga{}
+		var data []byte
+		if err := rows.Scan(
+			&saga.ID,
+			&saga.AccountID,
+			&saga.Step,
+			&saga.Status,
+			&saga.ReservationID,
+			&data,
+			&saga.Error,
+			&saga.CreatedAt,
+			&saga.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		d := sagaData{}
+		if err := json.Unmarshal(data, &d); err != nil {
+			return nil, err
+		}
+		saga.Items = d.Items
+		saga.Products = d.Products
+		sagas = append(sagas, saga)
+	}
+	if r.metrics != nil {
+		r.metrics.RecordDBQuery("select", "order_sagas", time.Since(start))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return sagas, nil
+}
+
 // Allow wiring metrics into repository
 func (r *PostgresRepository) SetMetrics(mc *monitoring.MetricsCollector) {
 	r.metrics = mc
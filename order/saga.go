@@ -0,0 +1,365 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/master-wayne7/go-microservices/account"
+	"github.com/master-wayne7/go-microservices/auth"
+	"github.com/master-wayne7/go-microservices/catalog"
+	"github.com/master-wayne7/go-microservices/inventory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type SagaStep string
+
+// Order placement runs these steps in order. persist_order is the pivot:
+// a saga interrupted before it is rolled back, one interrupted after it is
+// rolled forward.
+const (
+	StepValidateAccount SagaStep = "validate_account"
+	StepPriceProducts   SagaStep = "price_products"
+	StepReserveStock    SagaStep = "reserve_stock"
+	StepPersistOrder    SagaStep = "persist_order"
+	StepConfirmOrder    SagaStep = "confirm_order"
+)
+
+var sagaSteps = []SagaStep{
+	StepValidateAccount,
+	StepPriceProducts,
+	StepReserveStock,
+	StepPersistOrder,
+	StepConfirmOrder,
+}
+
+type SagaStatus string
+
+const (
+	SagaRunning      SagaStatus = "running"
+	SagaCompensating SagaStatus = "compensating"
+	SagaCompleted    SagaStatus = "completed"
+	SagaCompensated  SagaStatus = "compensated"
+)
+
+const (
+	sagaActor = "order-saga"
+	// A running saga not touched for this long is assumed to belong to a
+	// crashed instance and is recovered.
+	sagaStaleAfter       = time.Minute
+	sagaRecoveryInterval = 30 * time.Second
+	compensationTimeout  = 10 * time.Second
+)
+
+// OrderSaga is the persisted state of one order placement. Its ID is also
+// the ID of the order it creates.
+type OrderSaga struct {
+	ID            string           `json:"id"`
+	AccountID     string           `json:"account_id"`
+	Items         []SagaItem       `json:"items"`
+	Products      []OrderedProduct `json:"products"`
+	ReservationID string           `json:"reservation_id"`
+	// Step is the last step that completed.
+	Step      SagaStep   `json:"step"`
+	Status    SagaStatus `json:"status"`
+	Error     string     `json:"error"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+type SagaItem struct {
+	ProductID string `json:"product_id"`
+	SKU       string `json:"sku"`
+	Quantity  uint32 `json:"quantity"`
+}
+
+// sku returns the SKU ordered. Items ordered by product ID, which only works
+// for products without variants, use the ID.
+func (i SagaItem) sku() string {
+	if i.SKU != "" {
+		return i.SKU
+	}
+	return i.ProductID
+}
+
+// pastPivot reports whether the order was stored, after which the saga is
+// rolled forward rather than compensated.
+func (s *OrderSaga) pastPivot() bool {
+	return slices.Index(sagaSteps, s.Step) >= slices.Index(sagaSteps, StepPersistOrder)
+}
+
+// nextSteps returns the steps that still have to run.
+func (s *OrderSaga) nextSteps() []SagaStep {
+	for i, step := range sagaSteps {
+		if step == s.Step {
+			return sagaSteps[i+1:]
+		}
+	}
+	return sagaSteps
+}
+
+// orderSaga orchestrates order placement across account, catalog, inventory
+// and the order database, compensating completed steps when a later one fails.
+type orderSaga struct {
+	service         Service
+	accountClient   *account.Client
+	catalogClient   *catalog.Client
+	inventoryClient *inventory.Client
+	// Inventory is called as the order service rather than as the
+	// customer, and recovery has no customer to act for.
+	identity *auth.ServiceIdentity
+}
+
+// Run drives saga to completion and returns the placed order. If a step
+// fails before the order is stored, the completed steps are compensated and
+// the error of the failing step is returned. Once the order is stored, a
+// failing step leaves the saga running for recovery to retry, and the order
+// is returned.
+func (o *orderSaga) Run(ctx context.Context, saga *OrderSaga) (*Order, error) {
+	saga.Status = SagaRunning
+	if err := o.save(ctx, saga); err != nil {
+		return nil, err
+	}
+	for _, step := range saga.nextSteps() {
+		err := o.exec(ctx, saga, step)
+		if err == nil {
+			saga.Step = step
+			err = o.save(ctx, saga)
+		}
+		if err != nil {
+			log.Printf("Order saga %s failed at %s: %v", saga.ID, step, err)
+			saga.Error = fmt.Sprintf("%s: %v", step, err)
+			if saga.pastPivot() && !abandoned(step, err) {
+				o.suspend(saga)
+				return o.service.GetOrder(ctx, saga.ID)
+			}
+			o.compensate(saga)
+			return nil, err
+		}
+	}
+	saga.Status = SagaCompleted
+	if err := o.save(ctx, saga); err != nil {
+		log.Printf("Failed to mark order saga %s completed: %v", saga.ID, err)
+	}
+	return o.service.GetOrder(ctx, saga.ID)
+}
+
+func (o *orderSaga) exec(ctx context.Context, saga *OrderSaga, step SagaStep) error {
+	switch step {
+	case StepValidateAccount:
+		a, err := o.accountClient.GetAccount(ctx, saga.AccountID)
+		if err != nil {
+			return err
+		}
+		// Deactivated and deleted accounts keep their orders but cannot
+		// place new ones.
+		if !a.IsActive() {
+			return status.Errorf(codes.FailedPrecondition, "account %s is %s", a.ID, a.Status)
+		}
+		return nil
+
+	case StepPriceProducts:
+		skus := make([]string, 0, len(saga.Items))
+		for _, item := range saga.Items {
+			skus = append(skus, item.sku())
+		}
+		catalogProducts, err := o.catalogClient.GetProductsBySKUs(ctx, skus)
+		if err != nil {
+			return err
+		}
+		products := []OrderedProduct{}
+		lines := map[string]int{}
+		for _, item := range saga.Items {
+			if item.Quantity == 0 {
+				continue
+			}
+			sku := item.sku()
+			if i, ok := lines[sku]; ok {
+				products[i].Quantity += item.Quantity
+				continue
+			}
+			for _, p := range catalogProducts {
+				v, ok := p.Variant(sku)
+				// Deleted products still resolve by SKU but cannot be
+				// ordered.
+				if !ok || p.IsDeleted() {
+					continue
+				}
+				lines[sku] = len(products)
+				products = append(products, OrderedProduct{
+					ID:          p.ID,
+					SKU:         v.SKU,
+					Name:        p.Name,
+					Description: p.Description,
+					Price:       v.Price,
+					Attributes:  v.Attributes,
+					Quantity:    item.Quantity,
+				})
+				break
+			}
+		}
+		if len(products) == 0 {
+			return status.Error(codes.InvalidArgument, "order has no known products")
+		}
+		saga.Products = products
+		return nil
+
+	case StepReserveStock:
+		items := make([]inventory.ReservationItem, 0, len(saga.Products))
+		for _, p := range saga.Products {
+			items = append(items, inventory.ReservationItem{SKU: p.SKU, Quantity: p.Quantity})
+		}
+		ctx, err := o.identity.Context(ctx)
+		if err != nil {
+			return err
+		}
+		reservation, err := o.inventoryClient.ReserveStock(ctx, saga.ID, items, 0)
+		if err != nil {
+			return err
+		}
+		saga.ReservationID = reservation.ID
+		return nil
+
+	case StepPersistOrder:
+		// The order may already exist if we crashed right after storing it.
+		if _, err := o.service.GetOrder(ctx, saga.ID); err == nil {
+			return nil
+		} else if !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		_, err := o.service.PostOrder(ctx, Order{
+			ID:            saga.ID,
+			AccountID:     saga.AccountID,
+			ReservationID: saga.ReservationID,
+			Products:      saga.Products,
+		})
+		return err
+
+	case StepConfirmOrder:
+		ctx, err := o.identity.Context(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = o.inventoryClient.CommitReservation(ctx, saga.ReservationID)
+		return err
+	}
+	return fmt.Errorf("unknown saga step %q", step)
+}
+
+// abandoned reports whether step failed for good, so that even a saga past
+// the pivot has to be compensated. That is only the case when the
+// reservation to confirm was released or has expired: its stock is gone and
+// the order can no longer be fulfilled.
+func abandoned(step SagaStep, err error) bool {
+	return step == StepConfirmOrder && status.Code(err) == codes.FailedPrecondition
+}
+
+// suspend saves a saga past the pivot that failed a step. It stays running,
+// so recovery picks it up once it is stale and retries the remaining steps.
+func (o *orderSaga) suspend(saga *OrderSaga) {
+	ctx, cancel := context.WithTimeout(context.Background(), compensationTimeout)
+	defer cancel()
+	if err := o.save(ctx, saga); err != nil {
+		log.Printf("Failed to save order saga %s: %v", saga.ID, err)
+	}
+}
+
+// compensate undoes whatever the saga managed to do. It runs detached from
+// the request context, which may already be cancelled. If it cannot finish,
+// the saga stays compensating and recovery retries it later.
+func (o *orderSaga) compensate(saga *OrderSaga) {
+	ctx, cancel := context.WithTimeout(context.Background(), compensationTimeout)
+	defer cancel()
+	ctx, err := o.identity.Context(ctx)
+	if err != nil {
+		log.Printf("Failed to compensate order saga %s: %v", saga.ID, err)
+		return
+	}
+
+	saga.Status = SagaCompensating
+	if err := o.save(ctx, saga); err != nil {
+		log.Printf("Failed to save order saga %s: %v", saga.ID, err)
+		return
+	}
+
+	if _, err := o.service.GetOrder(ctx, saga.ID); err == nil {
+		reason := "order placement failed: " + saga.Error
+		_, err := o.service.AbandonOrder(ctx, saga.ID, reason, sagaActor)
+		if err != nil && !errors.Is(err, ErrInvalidTransition) {
+			log.Printf("Failed to cancel order %s: %v", saga.ID, err)
+			return
+		}
+	} else if !errors.Is(err, ErrNotFound) {
+		log.Printf("Failed to look up order %s: %v", saga.ID, err)
+		return
+	}
+
+	if saga.ReservationID != "" {
+		_, err := o.inventoryClient.ReleaseReservation(ctx, saga.ReservationID)
+		if err != nil && status.Code(err) != codes.FailedPrecondition {
+			log.Printf("Failed to release reservation %s: %v", saga.ReservationID, err)
+			return
+		}
+	}
+
+	saga.Status = SagaCompensated
+	if err := o.save(ctx, saga); err != nil {
+		log.Printf("Failed to save order saga %s: %v", saga.ID, err)
+	}
+}
+
+func (o *orderSaga) save(ctx context.Context, saga *OrderSaga) error {
+	saga.UpdatedAt = time.Now().UTC()
+	return o.service.SaveSaga(ctx, *saga)
+}
+
+// Recover resumes sagas left unfinished by a crashed instance or a failed
+// step. Sagas that got past persisting the order are rolled forward; the rest
+// are compensated.
+func (o *orderSaga) Recover(ctx context.Context) {
+	ctx, err := o.identity.Context(ctx)
+	if err != nil {
+		log.Println("Error authenticating order saga recovery: ", err)
+		return
+	}
+	sagas, err := o.service.GetStaleSagas(ctx, time.Now().UTC().Add(-sagaStaleAfter))
+	if err != nil {
+		log.Println("Error loading unfinished order sagas: ", err)
+		return
+	}
+	for i := range sagas {
+		saga := &sagas[i]
+		if saga.Status == SagaCompensating {
+			o.compensate(saga)
+			continue
+		}
+		if _, err := o.service.GetOrder(ctx, saga.ID); err != nil {
+			if !errors.Is(err, ErrNotFound) {
+				log.Printf("Failed to look up order %s: %v", saga.ID, err)
+				continue
+			}
+			saga.Error = "interrupted before the order was stored"
+			o.compensate(saga)
+			continue
+		}
+		saga.Step = StepPersistOrder
+		if _, err := o.Run(ctx, saga); err != nil {
+			log.Printf("Failed to resume order saga %s: %v", saga.ID, err)
+		}
+	}
+}
+
+func (o *orderSaga) startRecovery() {
+	go func() {
+		ticker := time.NewTicker(sagaRecoveryInterval)
+		defer ticker.Stop()
+		for {
+			o.Recover(context.Background())
+			<-ticker.C
+		}
+	}()
+}
@@ -2,26 +2,41 @@ package order
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net"
+	"time"
 
 	"github.com/master-wayne7/go-microservices/account"
+	"github.com/master-wayne7/go-microservices/auth"
 	"github.com/master-wayne7/go-microservices/catalog"
+	"github.com/master-wayne7/go-microservices/idempotency"
+	"github.com/master-wayne7/go-microservices/inventory"
+	"github.com/master-wayne7/go-microservices/money"
 	"github.com/master-wayne7/go-microservices/monitoring"
 	"github.com/master-wayne7/go-microservices/order/pb"
+	"github.com/segmentio/ksuid"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 )
 
 type grpcServer struct {
 	pb.UnimplementedOrderServiceServer
-	service       Service
-	accountClient *account.Client
-	catalogClient *catalog.Client
+	service         Service
+	accountClient   *account.Client
+	catalogClient   *catalog.Client
+	inventoryClient *inventory.Client
+	saga            *orderSaga
+	idempotency     idempotency.Store
+	// identity is used for calls the order service makes as itself, such
+	// as releasing stock.
+	identity *auth.ServiceIdentity
 }
 
-func ListenGRPC(s Service, accountUrl, catalogUrl string, port int, metrics *monitoring.MetricsCollector) error {
+func ListenGRPC(s Service, store idempotency.Store, signer *auth.Signer, accountUrl, catalogUrl, inventoryUrl string, port int, metrics *monitoring.MetricsCollector) error {
 	accountClient, err := account.NewClient(accountUrl)
 	if err != nil {
 		return err
@@ -31,22 +46,52 @@ func ListenGRPC(s Service, accountUrl, catalogUrl string, port int, metrics *mon
 		accountClient.Close()
 		return err
 	}
+	inventoryClient, err := inventory.NewClient(inventoryUrl)
+	if err != nil {
+		accountClient.Close()
+		catalogClient.Close()
+		return err
+	}
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
 	if err != nil {
 		accountClient.Close()
 		catalogClient.Close()
+		inventoryClient.Close()
 		return err
 	}
 
-	// Add gRPC interceptors for metrics
+	identity := auth.NewServiceIdentity(signer, "order")
+
+	// Finish or roll back placements interrupted by an earlier crash.
+	saga := &orderSaga{
+		service:         s,
+		accountClient:   accountClient,
+		catalogClient:   catalogClient,
+		inventoryClient: inventoryClient,
+		identity:        identity,
+	}
+	saga.startRecovery()
+
+	// Add gRPC interceptors for metrics, then authentication and
+	// per-method permissions
 	serv := grpc.NewServer(
-		grpc.UnaryInterceptor(monitoring.GRPCUnaryServerInterceptor(metrics)),
-		grpc.StreamInterceptor(monitoring.GRPCStreamServerInterceptor(metrics)),
+		grpc.ChainUnaryInterceptor(
+			monitoring.GRPCUnaryServerInterceptor(metrics),
+			auth.UnaryServerInterceptor(signer, permissions),
+		),
+		grpc.ChainStreamInterceptor(
+			monitoring.GRPCStreamServerInterceptor(metrics),
+			auth.StreamServerInterceptor(signer, permissions),
+		),
 	)
 	pb.RegisterOrderServiceServer(serv, &grpcServer{
 		service:                         s,
 		accountClient:                   accountClient,
 		catalogClient:                   catalogClient,
+		inventoryClient:                 inventoryClient,
+		saga:                            saga,
+		idempotency:                     store,
+		identity:                        identity,
 		UnimplementedOrderServiceServer: pb.UnimplementedOrderServiceServer{},
 	})
 	reflection.Register(serv)
@@ -54,130 +99,429 @@ func ListenGRPC(s Service, accountUrl, catalogUrl string, port int, metrics *mon
 }
 
 func (s *grpcServer) PostOrder(ctx context.Context, r *pb.PostOrderRequest) (*pb.PostOrderResponse, error) {
-	_, err := s.accountClient.GetAccount(ctx, r.AccountId)
+	return idempotency.Do(ctx, s.idempotency, "PostOrder", r.IdempotencyKey, r, func() (*pb.PostOrderResponse, error) {
+		return s.postOrder(ctx, r)
+	})
+}
+
+func (s *grpcServer) postOrder(ctx context.Context, r *pb.PostOrderRequest) (*pb.PostOrderResponse, error) {
+	now := time.Now().UTC()
+	saga := &OrderSaga{
+		ID:        ksuid.New().String(),
+		AccountID: r.AccountId,
+		Items:     make([]SagaItem, 0, len(r.Products)),
+		CreatedAt: now,
+	}
+	for _, p := range r.Products {
+		saga.Items = append(saga.Items, SagaItem{ProductID: p.ProductId, SKU: p.Sku, Quantity: p.Quantity})
+	}
+	order, err := s.saga.Run(ctx, saga)
+	if err != nil {
+		log.Printf("Failed to post order: %v", err)
+		return nil, orderError(err)
+	}
+	orderProto, err := orderToProto(order)
 	if err != nil {
-		log.Println("Error getting account: ", err)
 		return nil, err
 	}
+	return &pb.PostOrderResponse{
+		Order: orderProto,
+	}, nil
+}
 
-	productIds := make([]string, 0, len(r.Products))
-	for _, p := range r.Products {
-		productIds = append(productIds, p.ProductId)
+func (s *grpcServer) GetOrder(ctx context.Context, r *pb.GetOrderRequest) (*pb.GetOrderResponse, error) {
+	o, err := s.service.GetOrder(ctx, r.Id)
+	if err != nil {
+		log.Println(err)
+		return nil, orderError(err)
+	}
+	if err := auth.AuthorizeAccount(ctx, o.AccountID); err != nil {
+		return nil, orderError(err)
 	}
-	orderedProducts, err := s.catalogClient.GetProducts(ctx, 0, 0, "", productIds)
+	s.enrichProducts(ctx, o)
+	orderProto, err := orderToProto(o)
 	if err != nil {
-		log.Println("error getting products: ", err)
 		return nil, err
 	}
-	products := []OrderedProduct{}
-	for _, p := range orderedProducts {
-		product := OrderedProduct{
-			ID:          p.ID,
-			Quantity:    0,
-			Price:       p.Price,
-			Name:        p.Name,
-			Description: p.Description,
+	return &pb.GetOrderResponse{
+		Order: orderProto,
+	}, nil
+}
+
+func (s *grpcServer) GetOrdersForAccount(
+	ctx context.Context,
+	r *pb.GetOrdersForAccountRequest,
+) (*pb.GetOrdersForAccountResponse, error) {
+
+	accountsOrder, err := s.service.GetOrdersForAccount(ctx, r.AccountId)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	accountOrders := make([]*Order, 0, len(accountsOrder))
+	for i := range accountsOrder {
+		accountOrders = append(accountOrders, &accountsOrder[i])
+	}
+	s.enrichProducts(ctx, accountOrders...)
+
+	orders := []*pb.Order{}
+	for _, o := range accountOrders {
+		op, err := orderToProto(o)
+		if err != nil {
+			return nil, err
 		}
-		for _, rp := range r.Products {
-			if rp.ProductId == p.ID {
-				product.Quantity = rp.Quantity
-				break
-			}
+		orders = append(orders, op)
+	}
+	return &pb.GetOrdersForAccountResponse{
+		Order: orders,
+	}, nil
+}
+
+func (s *grpcServer) ListOrders(ctx context.Context, r *pb.ListOrdersRequest) (*pb.ListOrdersResponse, error) {
+	sort, err := ParseOrderSort(r.Sort)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	filter := OrderFilter{
+		AccountID: r.AccountId,
+		ProductID: r.ProductId,
+	}
+	if len(r.CreatedAfter) != 0 {
+		if err := filter.CreatedAfter.UnmarshalBinary(r.CreatedAfter); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid createdAfter")
+		}
+	}
+	if len(r.CreatedBefore) != 0 {
+		if err := filter.CreatedBefore.UnmarshalBinary(r.CreatedBefore); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid createdBefore")
 		}
-		if product.Quantity != 0 {
-			products = append(products, product)
+	}
+	for _, st := range r.Statuses {
+		parsed, err := ParseStatus(st)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
 		}
+		filter.Statuses = append(filter.Statuses, parsed)
+	}
+	if r.MinTotal != nil {
+		minTotal := moneyFromProto(r.MinTotal, 0)
+		filter.MinTotal = &minTotal
 	}
-	order, err := s.service.PostOrder(ctx, r.AccountId, products)
+	if r.MaxTotal != nil {
+		maxTotal := moneyFromProto(r.MaxTotal, 0)
+		filter.MaxTotal = &maxTotal
+	}
+
+	page, err := s.service.ListOrders(ctx, filter, sort, r.After, int(r.Limit))
 	if err != nil {
-		log.Printf("Failed to post order: %v", err)
-		return nil, fmt.Errorf("could not post order: %w", err)
+		log.Println(err)
+		return nil, orderError(err)
+	}
+	orders := make([]*Order, 0, len(page.Orders))
+	for i := range page.Orders {
+		orders = append(orders, &page.Orders[i])
 	}
-	orderProto := &pb.Order{
-		Id:        order.ID,
-		AccountId: order.AccountID,
-		Products:  []*pb.Order_OrderProduct{},
+	s.enrichProducts(ctx, orders...)
+
+	resp := &pb.ListOrdersResponse{
+		Orders:     make([]*pb.Order, 0, len(orders)),
+		NextCursor: page.NextCursor,
+	}
+	for _, o := range orders {
+		op, err := orderToProto(o)
+		if err != nil {
+			return nil, err
+		}
+		resp.Orders = append(resp.Orders, op)
 	}
-	orderProto.TotalPrice = order.TotalPrice
-	orderProto.CreatedAt, err = order.CreatedAt.MarshalBinary()
+	return resp, nil
+}
+
+func (s *grpcServer) TransitionOrder(ctx context.Context, r *pb.TransitionOrderRequest) (*pb.TransitionOrderResponse, error) {
+	to, err := ParseStatus(r.Status)
 	if err != nil {
-		log.Printf("Failed to marshal CreatedAt: %v", err)
-		return nil, fmt.Errorf("could not marshal order timestamp: %w", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
-	for _, op := range order.Products {
-		orderProto.Products = append(orderProto.Products, &pb.Order_OrderProduct{
-			Id:          op.ID,
-			Name:        op.Name,
-			Description: op.Description,
-			Price:       op.Price,
-			Quantity:    op.Quantity,
-		})
+	o, err := s.service.TransitionOrder(ctx, r.Id, to, actor(ctx), r.Reason)
+	if err != nil {
+		log.Printf("Failed to transition order %s: %v", r.Id, err)
+		return nil, orderError(err)
 	}
-	return &pb.PostOrderResponse{
+	s.enrichProducts(ctx, o)
+	orderProto, err := orderToProto(o)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.TransitionOrderResponse{
 		Order: orderProto,
 	}, nil
 }
 
-func (s *grpcServer) GetOrdersForAccount(
-	ctx context.Context,
-	r *pb.GetOrdersForAccountRequest,
-) (*pb.GetOrdersForAccountResponse, error) {
+func (s *grpcServer) GetOrderStatusHistory(ctx context.Context, r *pb.GetOrderStatusHistoryRequest) (*pb.GetOrderStatusHistoryResponse, error) {
+	if err := s.authorizeOrder(ctx, r.Id); err != nil {
+		return nil, err
+	}
+	history, err := s.service.GetOrderStatusHistory(ctx, r.Id)
+	if err != nil {
+		log.Println(err)
+		return nil, orderError(err)
+	}
+	changes := make([]*pb.OrderStatusChange, 0, len(history))
+	for _, c := range history {
+		changedAt, err := c.ChangedAt.MarshalBinary()
+		if err != nil {
+			return nil, fmt.Errorf("could not marshal status change timestamp: %w", err)
+		}
+		changes = append(changes, &pb.OrderStatusChange{
+			OrderId:    c.OrderID,
+			FromStatus: string(c.From),
+			ToStatus:   string(c.To),
+			ChangedBy:  c.ChangedBy,
+			Reason:     c.Reason,
+			ChangedAt:  changedAt,
+		})
+	}
+	return &pb.GetOrderStatusHistoryResponse{
+		History: changes,
+	}, nil
+}
 
-	accountsOrder, err := s.service.GetOrdersForAccount(ctx, r.AccountId)
+func (s *grpcServer) CancelOrder(ctx context.Context, r *pb.CancelOrderRequest) (*pb.CancelOrderResponse, error) {
+	if err := s.authorizeOrder(ctx, r.Id); err != nil {
+		return nil, err
+	}
+	lines := make([]RefundLine, 0, len(r.RefundLines))
+	for _, l := range r.RefundLines {
+		lines = append(lines, RefundLine{ProductID: l.ProductId, SKU: l.Sku, Quantity: l.Quantity})
+	}
+	o, refund, err := s.service.CancelOrder(ctx, r.Id, r.Reason, actor(ctx), lines)
+	if err != nil {
+		log.Printf("Failed to cancel order %s: %v", r.Id, err)
+		return nil, orderError(err)
+	}
+	// The cancellation is committed; stock that fails to come back here has
+	// to be released by hand, so only log.
+	if o.ReservationID != "" {
+		if err := s.releaseReservation(ctx, o.ReservationID); err != nil {
+			log.Printf("Failed to release reservation %s for cancelled order %s: %v", o.ReservationID, o.ID, err)
+		}
+	}
+	s.enrichProducts(ctx, o)
+	orderProto, err := orderToProto(o)
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.CancelOrderResponse{
+		Order: orderProto,
+	}
+	if refund != nil {
+		if resp.Refund, err = refundToProto(refund); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+func (s *grpcServer) GetRefunds(ctx context.Context, r *pb.GetRefundsRequest) (*pb.GetRefundsResponse, error) {
+	if err := s.authorizeOrder(ctx, r.OrderId); err != nil {
+		return nil, err
+	}
+	refunds, err := s.service.GetRefunds(ctx, r.OrderId)
 	if err != nil {
 		log.Println(err)
+		return nil, orderError(err)
+	}
+	protos := make([]*pb.Refund, 0, len(refunds))
+	for i := range refunds {
+		rp, err := refundToProto(&refunds[i])
+		if err != nil {
+			return nil, err
+		}
+		protos = append(protos, rp)
+	}
+	return &pb.GetRefundsResponse{
+		Refunds: protos,
+	}, nil
+}
+
+func (s *grpcServer) UpdateRefundStatus(ctx context.Context, r *pb.UpdateRefundStatusRequest) (*pb.UpdateRefundStatusResponse, error) {
+	to, err := ParseRefundStatus(r.Status)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	refund, err := s.service.UpdateRefundStatus(ctx, r.Id, to)
+	if err != nil {
+		log.Printf("Failed to update refund %s: %v", r.Id, err)
+		return nil, orderError(err)
+	}
+	refundProto, err := refundToProto(refund)
+	if err != nil {
 		return nil, err
 	}
+	return &pb.UpdateRefundStatusResponse{
+		Refund: refundProto,
+	}, nil
+}
+
+// authorizeOrder checks that the caller owns order id, or is a service.
+func (s *grpcServer) authorizeOrder(ctx context.Context, id string) error {
+	o, err := s.service.GetOrder(ctx, id)
+	if err != nil {
+		return orderError(err)
+	}
+	return orderError(auth.AuthorizeAccount(ctx, o.AccountID))
+}
+
+// actor names the authenticated caller in the status history.
+func actor(ctx context.Context) string {
+	if p, ok := auth.FromContext(ctx); ok {
+		return p.Name()
+	}
+	return ""
+}
 
+// releaseReservation returns a reservation's stock as the order service;
+// inventory does not accept stock changes from customers.
+func (s *grpcServer) releaseReservation(ctx context.Context, id string) error {
+	ctx, err := s.identity.Context(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = s.inventoryClient.ReleaseReservation(ctx, id)
+	return err
+}
+
+// enrichProducts fills in details for order lines that carry no snapshot of
+// the product, e.g. lines written before snapshots were stored. Snapshotted
+// lines are left untouched so historical orders keep the prices they were
+// placed at. Catalog failures are logged and otherwise ignored.
+func (s *grpcServer) enrichProducts(ctx context.Context, orders ...*Order) {
 	productIdMap := map[string]bool{}
-	for _, o := range accountsOrder {
+	for _, o := range orders {
 		for _, p := range o.Products {
-			productIdMap[p.ID] = true
+			if p.Name == "" {
+				productIdMap[p.ID] = true
+			}
 		}
 	}
-	productIds := []string{}
+	if len(productIdMap) == 0 {
+		return
+	}
+	productIds := make([]string, 0, len(productIdMap))
 	for id := range productIdMap {
 		productIds = append(productIds, id)
 	}
 
-	products, err := s.catalogClient.GetProducts(ctx, 0, 0, "", productIds)
+	products, err := s.catalogClient.GetProducts(ctx, 0, 0, "", productIds, "", "")
 	if err != nil {
-		log.Println("error getting account products: ", err)
-		return nil, err
+		log.Println("error getting order products: ", err)
+		return
 	}
-	orders := []*pb.Order{}
-	for _, o := range accountsOrder {
-		op := &pb.Order{
-			AccountId: o.AccountID,
-			Id:        o.ID,
-			Products:  []*pb.Order_OrderProduct{},
-		}
-		op.TotalPrice = o.TotalPrice
-		op.CreatedAt, err = o.CreatedAt.MarshalBinary()
-		if err != nil {
-			log.Printf("Failed to marshal CreatedAt for order %s: %v", o.ID, err)
-			return nil, fmt.Errorf("could not marshal order timestamp: %w", err)
-		}
-		for _, product := range o.Products {
+	for _, o := range orders {
+		for i := range o.Products {
+			product := &o.Products[i]
+			if product.Name != "" {
+				continue
+			}
 			for _, p := range products {
 				if p.ID == product.ID {
 					product.Name = p.Name
 					product.Description = p.Description
-					product.Price = p.Price
+					if product.Price.IsZero() {
+						product.Price = p.Price
+					}
 					break
 				}
 			}
-			op.Products = append(op.Products, &pb.Order_OrderProduct{
-				Id:          product.ID,
-				Name:        product.Name,
-				Description: product.Description,
-				Price:       product.Price,
-				Quantity:    product.Quantity,
-			})
 		}
-		orders = append(orders, op)
 	}
-	return &pb.GetOrdersForAccountResponse{
-		Order: orders,
-	}, nil
+}
+
+func orderToProto(o *Order) (*pb.Order, error) {
+	op := &pb.Order{
+		Id:                 o.ID,
+		AccountId:          o.AccountID,
+		TotalPrice:         o.TotalPrice.Float64(),
+		TotalPriceMoney:    moneyToProto(o.TotalPrice),
+		Status:             string(o.Status),
+		Products:           []*pb.Order_OrderProduct{},
+		CancellationReason: o.CancellationReason,
+	}
+	var err error
+	op.CreatedAt, err = o.CreatedAt.MarshalBinary()
+	if err != nil {
+		log.Printf("Failed to marshal CreatedAt for order %s: %v", o.ID, err)
+		return nil, fmt.Errorf("could not marshal order timestamp: %w", err)
+	}
+	for _, p := range o.Products {
+		op.Products = append(op.Products, &pb.Order_OrderProduct{
+			Id:          p.ID,
+			Sku:         p.SKU,
+			Name:        p.Name,
+			Description: p.Description,
+			Price:       p.Price.Float64(),
+			PriceMoney:  moneyToProto(p.Price),
+			Attributes:  p.Attributes,
+			Quantity:    p.Quantity,
+		})
+	}
+	return op, nil
+}
+
+func refundToProto(r *Refund) (*pb.Refund, error) {
+	rp := &pb.Refund{
+		Id:      r.ID,
+		OrderId: r.OrderID,
+		Amount:  moneyToProto(r.Amount),
+		Status:  string(r.Status),
+		Reason:  r.Reason,
+		Lines:   make([]*pb.Refund_Line, 0, len(r.Lines)),
+	}
+	var err error
+	if rp.CreatedAt, err = r.CreatedAt.MarshalBinary(); err != nil {
+		return nil, fmt.Errorf("could not marshal refund timestamp: %w", err)
+	}
+	if rp.UpdatedAt, err = r.UpdatedAt.MarshalBinary(); err != nil {
+		return nil, fmt.Errorf("could not marshal refund timestamp: %w", err)
+	}
+	for _, l := range r.Lines {
+		rp.Lines = append(rp.Lines, &pb.Refund_Line{
+			ProductId: l.ProductID,
+			Sku:       l.SKU,
+			Quantity:  l.Quantity,
+			Amount:    moneyToProto(l.Amount),
+		})
+	}
+	return rp, nil
+}
+
+func moneyToProto(m money.Money) *pb.Amount {
+	return &pb.Amount{Amount: m.Amount, Currency: m.Currency}
+}
+
+// moneyFromProto prefers the exact amount and falls back to the legacy float
+// field for peers that do not send one.
+func moneyFromProto(m *pb.Amount, legacy float64) money.Money {
+	if m == nil {
+		return money.FromFloat(legacy, money.DefaultCurrency)
+	}
+	return money.New(m.Amount, m.Currency)
+}
+
+// orderError maps service errors onto gRPC status codes.
+func orderError(err error) error {
+	switch {
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrRefundNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, ErrInvalidTransition), errors.Is(err, ErrInvalidRefundTransition):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, ErrReasonRequired), errors.Is(err, ErrInvalidRefund),
+		errors.Is(err, ErrInvalidCursor), errors.Is(err, ErrInvalidFilter), errors.Is(err, ErrInvalidStatus), errors.Is(err, ErrDedicatedStatus),
+		errors.Is(err, money.ErrCurrencyMismatch), errors.Is(err, money.ErrOverflow):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, ErrStatusConflict):
+		return status.Error(codes.Aborted, err.Error())
+	}
+	return auth.StatusError(err)
 }
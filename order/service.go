@@ -2,30 +2,56 @@ package order
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/master-wayne7/go-microservices/money"
 	"github.com/segmentio/ksuid"
 )
 
 type Service interface {
-	PostOrder(ctx context.Context, accountID string, products []OrderedProduct) (*Order, error)
+	PostOrder(ctx context.Context, o Order) (*Order, error)
+	GetOrder(ctx context.Context, id string) (*Order, error)
 	GetOrdersForAccount(ctx context.Context, accountID string) ([]Order, error)
+	ListOrders(ctx context.Context, filter OrderFilter, sort OrderSort, after string, limit int) (*OrderPage, error)
+	// TransitionOrder moves an order along its lifecycle. Cancelling and
+	// refunding have their own operations and fail with ErrDedicatedStatus.
+	TransitionOrder(ctx context.Context, id string, to Status, changedBy, reason string) (*Order, error)
+	GetOrderStatusHistory(ctx context.Context, id string) ([]StatusChange, error)
+	CancelOrder(ctx context.Context, id, reason, cancelledBy string, lines []RefundLine) (*Order, *Refund, error)
+	// AbandonOrder cancels a pending order whose placement failed, without a
+	// refund. It is for the placement saga and not exposed over gRPC.
+	AbandonOrder(ctx context.Context, id, reason, cancelledBy string) (*Order, error)
+	GetRefunds(ctx context.Context, orderID string) ([]Refund, error)
+	UpdateRefundStatus(ctx context.Context, id string, to RefundStatus) (*Refund, error)
+	SaveSaga(ctx context.Context, saga OrderSaga) error
+	GetStaleSagas(ctx context.Context, updatedBefore time.Time) ([]OrderSaga, error)
 }
 
 type Order struct {
 	ID         string           `json:"id"`
 	CreatedAt  time.Time        `json:"created_at"`
-	TotalPrice float64          `json:"total_price"`
+	TotalPrice money.Money      `json:"total_price"`
 	AccountID  string           `json:"account_id"`
+	Status     Status           `json:"status"`
 	Products   []OrderedProduct `json:"products"`
+	// ReservationID is the inventory reservation holding this order's stock.
+	ReservationID      string `json:"reservation_id"`
+	CancellationReason string `json:"cancellation_reason"`
 }
 
 type OrderedProduct struct {
-	ID          string  `json:"id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-	Quantity    uint32  `json:"quantity"`
+	ID string `json:"id"`
+	// SKU is the ordered variant, or the product ID for products without
+	// variants.
+	SKU         string      `json:"sku"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Price       money.Money `json:"price"`
+	// Attributes are those of the ordered variant, e.g. its size.
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Quantity   uint32            `json:"quantity"`
 }
 
 type orderService struct {
@@ -36,23 +62,219 @@ func NewService(r Repository) Service {
 	return &orderService{repository: r}
 }
 
-func (s *orderService) PostOrder(ctx context.Context, accountID string, products []OrderedProduct) (*Order, error) {
-	o := &Order{
-		ID:        ksuid.New().String(),
-		CreatedAt: time.Now().UTC(),
-		AccountID: accountID,
-		Products:  products,
+// PostOrder stores o as a new pending order. An ID is generated unless the
+// caller supplies one, and the total is computed from the ordered products.
+func (s *orderService) PostOrder(ctx context.Context, o Order) (*Order, error) {
+	if o.ID == "" {
+		o.ID = ksuid.New().String()
 	}
-	o.TotalPrice = 0.0
-	for _, p := range products {
-		o.TotalPrice += p.Price * float64(p.Quantity)
+	o.CreatedAt = time.Now().UTC()
+	o.Status = StatusPending
+	o.TotalPrice = money.Money{}
+	for _, p := range o.Products {
+		line, err := p.Price.Mul(int64(p.Quantity))
+		if err != nil {
+			return nil, err
+		}
+		total, err := o.TotalPrice.Add(line)
+		if err != nil {
+			return nil, err
+		}
+		o.TotalPrice = total
 	}
-	err := s.repository.PutOrder(ctx, *o)
+	if o.TotalPrice.Currency == "" {
+		o.TotalPrice = money.New(0, money.DefaultCurrency)
+	}
+	err := s.repository.PutOrder(ctx, o)
 	if err != nil {
 		return nil, err
 	}
-	return o, nil
+	return &o, nil
 }
+
+func (s *orderService) GetOrder(ctx context.Context, id string) (*Order, error) {
+	return s.repository.GetOrderByID(ctx, id)
+}
+
 func (s *orderService) GetOrdersForAccount(ctx context.Context, accountID string) ([]Order, error) {
 	return s.repository.GetOrdersForAccount(ctx, accountID)
 }
+
+// ListOrders returns one page of orders matching filter. after is the
+// NextCursor of the previous page and must come from the same sort and
+// filter.
+func (s *orderService) ListOrders(ctx context.Context, filter OrderFilter, sort OrderSort, after string, limit int) (*OrderPage, error) {
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	if limit > MaxListLimit {
+		limit = MaxListLimit
+	}
+	if err := filter.validate(sort); err != nil {
+		return nil, err
+	}
+	cursor, err := parseOrderCursor(after, sort, filter)
+	if err != nil {
+		return nil, err
+	}
+	afterKey, afterID := "", ""
+	if cursor != nil {
+		afterKey, afterID = cursor.Key, cursor.ID
+	}
+
+	// Fetch one extra order to learn whether another page follows.
+	orders, err := s.repository.ListOrders(ctx, filter, sort, afterKey, afterID, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &OrderPage{Orders: orders}
+	if len(orders) > limit {
+		page.Orders = orders[:limit]
+		page.NextCursor = newOrderCursor(sort, filter, page.Orders[limit-1])
+	}
+	return page, nil
+}
+
+func (s *orderService) TransitionOrder(ctx context.Context, id string, to Status, changedBy, reason string) (*Order, error) {
+	switch to {
+	case StatusCancelled:
+		return nil, fmt.Errorf("%w: use CancelOrder to cancel order %s", ErrDedicatedStatus, id)
+	case StatusRefunded:
+		return nil, fmt.Errorf("%w: refund order %s through CancelOrder and its refunds", ErrDedicatedStatus, id)
+	}
+	o, err := s.repository.GetOrderByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !o.Status.CanTransitionTo(to) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, o.Status, to)
+	}
+	change := StatusChange{
+		OrderID:   o.ID,
+		From:      o.Status,
+		To:        to,
+		ChangedBy: changedBy,
+		Reason:    reason,
+		ChangedAt: time.Now().UTC(),
+	}
+	if err := s.repository.UpdateOrderStatus(ctx, change); err != nil {
+		return nil, err
+	}
+	o.Status = to
+	return o, nil
+}
+
+func (s *orderService) GetOrderStatusHistory(ctx context.Context, id string) ([]StatusChange, error) {
+	return s.repository.GetOrderStatusHistory(ctx, id)
+}
+
+// CancelOrder cancels an order that has not shipped yet. A paid order gets a
+// pending refund in the same transaction: a full one, or one covering only
+// lines when they are given. Unpaid orders are cancelled without a refund.
+func (s *orderService) CancelOrder(ctx context.Context, id, reason, cancelledBy string, lines []RefundLine) (*Order, *Refund, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, nil, ErrReasonRequired
+	}
+	o, err := s.repository.GetOrderByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !o.Status.CanTransitionTo(StatusCancelled) {
+		return nil, nil, fmt.Errorf("%w: order %s is %s and can no longer be cancelled", ErrInvalidTransition, o.ID, o.Status)
+	}
+
+	now := time.Now().UTC()
+	var refund *Refund
+	if o.Status == StatusPending {
+		if len(lines) != 0 {
+			return nil, nil, fmt.Errorf("%w: order %s was never paid", ErrInvalidRefund, o.ID)
+		}
+	} else {
+		refundLines, amount, err := refundLines(o, lines)
+		if err != nil {
+			return nil, nil, err
+		}
+		refund = &Refund{
+			ID:        ksuid.New().String(),
+			OrderID:   o.ID,
+			Amount:    amount,
+			Status:    RefundPending,
+			Reason:    reason,
+			Lines:     refundLines,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+	}
+
+	change := StatusChange{
+		OrderID:   o.ID,
+		From:      o.Status,
+		To:        StatusCancelled,
+		ChangedBy: cancelledBy,
+		Reason:    reason,
+		ChangedAt: now,
+	}
+	if err := s.repository.CancelOrder(ctx, change, refund); err != nil {
+		return nil, nil, err
+	}
+	o.Status = StatusCancelled
+	o.CancellationReason = reason
+	return o, refund, nil
+}
+
+func (s *orderService) GetRefunds(ctx context.Context, orderID string) ([]Refund, error) {
+	return s.repository.GetRefundsForOrder(ctx, orderID)
+}
+
+// AbandonOrder implements Service. Only pending orders are abandoned: once
+// paid, an order needs the refund CancelOrder creates.
+func (s *orderService) AbandonOrder(ctx context.Context, id, reason, cancelledBy string) (*Order, error) {
+	o, err := s.repository.GetOrderByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if o.Status != StatusPending {
+		return nil, fmt.Errorf("%w: order %s is %s and can no longer be abandoned", ErrInvalidTransition, o.ID, o.Status)
+	}
+	change := StatusChange{
+		OrderID:   o.ID,
+		From:      o.Status,
+		To:        StatusCancelled,
+		ChangedBy: cancelledBy,
+		Reason:    reason,
+		ChangedAt: time.Now().UTC(),
+	}
+	if err := s.repository.CancelOrder(ctx, change, nil); err != nil {
+		return nil, err
+	}
+	o.Status = StatusCancelled
+	o.CancellationReason = reason
+	return o, nil
+}
+
+func (s *orderService) UpdateRefundStatus(ctx context.Context, id string, to RefundStatus) (*Refund, error) {
+	r, err := s.repository.GetRefundByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !r.Status.CanTransitionTo(to) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidRefundTransition, r.Status, to)
+	}
+	now := time.Now().UTC()
+	if err := s.repository.UpdateRefundStatus(ctx, id, r.Status, to, now); err != nil {
+		return nil, err
+	}
+	r.Status = to
+	r.UpdatedAt = now
+	return r, nil
+}
+
+func (s *orderService) SaveSaga(ctx context.Context, saga OrderSaga) error {
+	return s.repository.PutSaga(ctx, saga)
+}
+
+// GetStaleSagas returns running or compensating sagas not updated since
+// updatedBefore.
+func (s *orderService) GetStaleSagas(ctx context.Context, updatedBefore time.Time) ([]OrderSaga, error) {
+	return s.repository.ListUnfinishedSagas(ctx, updatedBefore)
+}
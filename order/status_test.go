@@ -0,0 +1,64 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from, to Status
+		want     bool
+	}{
+		{StatusPending, StatusPaid, true},
+		{StatusPending, StatusCancelled, true},
+		{StatusPending, StatusFulfilled, false},
+		{StatusPending, StatusRefunded, false},
+		{StatusPaid, StatusFulfilled, true},
+		{StatusPaid, StatusCancelled, true},
+		{StatusPaid, StatusRefunded, true},
+		{StatusPaid, StatusPending, false},
+		{StatusFulfilled, StatusShipped, true},
+		{StatusFulfilled, StatusCancelled, true},
+		{StatusFulfilled, StatusRefunded, true},
+		{StatusShipped, StatusDelivered, true},
+		{StatusShipped, StatusCancelled, false},
+		{StatusShipped, StatusRefunded, false},
+		{StatusDelivered, StatusRefunded, true},
+		{StatusDelivered, StatusShipped, false},
+		{StatusCancelled, StatusPending, false},
+		{StatusCancelled, StatusRefunded, false},
+		{StatusRefunded, StatusPaid, false},
+		{StatusPending, StatusPending, false},
+		{Status("lost"), StatusPaid, false},
+	}
+	for _, tt := range tests {
+		if got := tt.from.CanTransitionTo(tt.to); got != tt.want {
+			t.Errorf("%s.CanTransitionTo(%s) = %t, want %t", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestParseStatus(t *testing.T) {
+	for status := range transitions {
+		if got, err := ParseStatus(string(status)); err != nil || got != status {
+			t.Errorf("ParseStatus(%q) = %q, %v", status, got, err)
+		}
+	}
+	for _, s := range []string{"", "PAID", "lost"} {
+		if _, err := ParseStatus(s); !errors.Is(err, ErrInvalidStatus) {
+			t.Errorf("ParseStatus(%q) error = %v, want %v", s, err, ErrInvalidStatus)
+		}
+	}
+}
+
+func TestTransitionOrderRejectsDedicatedStatuses(t *testing.T) {
+	// The check comes before the order is read, so no repository is needed.
+	s := &orderService{}
+	for _, to := range []Status{StatusCancelled, StatusRefunded} {
+		if _, err := s.TransitionOrder(context.Background(), "order", to, "staff", "reason"); !errors.Is(err, ErrDedicatedStatus) {
+			t.Errorf("TransitionOrder to %s error = %v, want %v", to, err, ErrDedicatedStatus)
+		}
+	}
+}
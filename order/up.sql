@@ -2,12 +2,102 @@ CREATE TABLE IF NOT EXISTS orders (
     id CHAR(27) PRIMARY KEY,
     created_at TIMESTAMP WITH TIME ZONE NOT NULL,
     account_id CHAR(27) NOT NULL,
-    total_price MONEY NOT NULL
+    total_price NUMERIC(19, 4) NOT NULL,
+    currency CHAR(3) NOT NULL DEFAULT 'USD',
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    reservation_id CHAR(27) NOT NULL DEFAULT '',
+    cancellation_reason TEXT NOT NULL DEFAULT ''
 );
 
 CREATE TABLE IF NOT EXISTS order_products (
     order_id CHAR(27) REFERENCES orders (id) ON DELETE CASCADE,
     product_id CHAR(27),
+    -- The ordered variant; products without variants use their id.
+    sku VARCHAR(64) NOT NULL DEFAULT '',
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    price NUMERIC(19, 4) NOT NULL DEFAULT 0,
+    -- Attributes of the ordered variant.
+    attributes JSONB NOT NULL DEFAULT '{}',
     quantity INT NOT NULL,
-    PRIMARY KEY (product_id,order_id)
-);
\ No newline at end of file
+    PRIMARY KEY (product_id, order_id, sku)
+);
+
+-- ListOrders pages with keyset pagination on (sort key, id); each index
+-- matches one filter + sort combination.
+CREATE INDEX IF NOT EXISTS orders_created_at_idx ON orders (created_at, id);
+CREATE INDEX IF NOT EXISTS orders_account_id_created_at_idx ON orders (account_id, created_at, id);
+CREATE INDEX IF NOT EXISTS orders_status_created_at_idx ON orders (status, created_at, id);
+CREATE INDEX IF NOT EXISTS orders_total_price_idx ON orders (currency, total_price, id);
+
+-- The primary key leads with product_id, which serves the product filter;
+-- this one serves joining lines to their order.
+CREATE INDEX IF NOT EXISTS order_products_order_id_idx ON order_products (order_id);
+
+CREATE TABLE IF NOT EXISTS order_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    order_id CHAR(27) NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    from_status VARCHAR(16),
+    to_status VARCHAR(16) NOT NULL,
+    changed_by VARCHAR(64) NOT NULL DEFAULT '',
+    reason TEXT NOT NULL DEFAULT '',
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS order_status_history_order_id_idx ON order_status_history (order_id, changed_at);
+CREATE TABLE IF NOT EXISTS refunds (
+    id CHAR(27) PRIMARY KEY,
+    order_id CHAR(27) NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    amount NUMERIC(19, 4) NOT NULL,
+    currency CHAR(3) NOT NULL DEFAULT 'USD',
+    status VARCHAR(16) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS refunds_order_id_idx ON refunds (order_id, created_at);
+
+CREATE TABLE IF NOT EXISTS refund_lines (
+    refund_id CHAR(27) NOT NULL REFERENCES refunds (id) ON DELETE CASCADE,
+    product_id CHAR(27) NOT NULL,
+    sku VARCHAR(64) NOT NULL DEFAULT '',
+    quantity INT NOT NULL,
+    amount NUMERIC(19, 4) NOT NULL,
+    PRIMARY KEY (refund_id, product_id, sku)
+);
+
+CREATE TABLE IF NOT EXISTS order_sagas (
+    id CHAR(27) PRIMARY KEY,
+    account_id CHAR(27) NOT NULL,
+    step VARCHAR(32) NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL,
+    reservation_id CHAR(27) NOT NULL DEFAULT '',
+    data JSONB NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS order_sagas_unfinished_idx ON order_sagas (updated_at) WHERE status IN ('running', 'compensating');
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    scope VARCHAR(64) NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    request_hash CHAR(64) NOT NULL,
+    response BYTEA,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (scope, key)
+);
+
+CREATE TABLE IF NOT EXISTS outbox (
+    id CHAR(27) PRIMARY KEY,
+    type VARCHAR(64) NOT NULL,
+    aggregate_type VARCHAR(32) NOT NULL,
+    aggregate_id VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL,
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    published_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS outbox_unpublished_idx ON outbox (occurred_at, id) WHERE published_at IS NULL;
@@ -0,0 +1,60 @@
+// Package schema checks that a Postgres database has the columns a service
+// expects. The up.sql scripts only run when a database volume is created, so
+// a database created by an older version keeps its old tables; checking at
+// startup fails fast instead of failing every query that touches them.
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrOutdated = errors.New("database schema is out of date, recreate the database volume to apply up.sql")
+
+// Column is a column a service needs. Type is its information_schema
+// data_type, such as "numeric" or "jsonb"; empty accepts any type.
+type Column struct {
+	Table string
+	Name  string
+	Type  string
+}
+
+// Check fails with ErrOutdated, listing every problem, unless all columns
+// exist in the current schema with their types.
+func Check(ctx context.Context, db *sql.DB, columns []Column) error {
+	rows, err := db.QueryContext(ctx, "SELECT table_name, column_name, data_type FROM information_schema.columns WHERE table_schema = current_schema()")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	types := map[string]string{}
+	for rows.Next() {
+		var table, column, dataType string
+		if err := rows.Scan(&table, &column, &dataType); err != nil {
+			return err
+		}
+		types[table+"."+column] = dataType
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var problems []string
+	for _, c := range columns {
+		name := c.Table + "." + c.Name
+		dataType, ok := types[name]
+		switch {
+		case !ok:
+			problems = append(problems, name+" is missing")
+		case c.Type != "" && dataType != c.Type:
+			problems = append(problems, fmt.Sprintf("%s is %s, not %s", name, dataType, c.Type))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrOutdated, strings.Join(problems, ", "))
+	}
+	return nil
+}